@@ -0,0 +1,345 @@
+// Command mockoidc is a local OpenID Connect provider for trying single
+// sign-on without a real identity provider. It implements discovery, the
+// authorization code flow with PKCE (S256 only) and a JWKS, and signs ID
+// tokens with a key generated at startup. Nobody has to log in: the
+// authorization endpoint lists the built-in users, or picks one directly
+// when the request carries login_hint=<user>.
+//
+//	MOCK_OIDC_ADDR       listen address (default :9000)
+//	MOCK_OIDC_ISSUER     issuer URL (default http://localhost:9000)
+//	MOCK_OIDC_CLIENT_ID  accepted client id (default fleetify)
+//
+// Point the server at it with OIDC_ISSUER=http://localhost:9000,
+// OIDC_CLIENT_ID=fleetify and for example
+// OIDC_ROLE_MAPPING=fleetify-admins=ADMIN,fleetify-managers=MANAGER.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mockoidc"
+
+// users are the identities the provider can sign in as.
+var users = map[string]map[string]any{
+	"alice": {
+		"sub":                "mock-alice",
+		"preferred_username": "alice",
+		"name":               "Alice Admin",
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"groups":             []string{"fleetify-admins"},
+	},
+	"marco": {
+		"sub":                "mock-marco",
+		"preferred_username": "marco",
+		"name":               "Marco Manager",
+		"email":              "marco@example.com",
+		"email_verified":     true,
+		"groups":             []string{"fleetify-managers"},
+	},
+	"nina": {
+		"sub":                "mock-nina",
+		"preferred_username": "nina",
+		"name":               "Nina No-Groups",
+		"email":              "nina@example.com",
+		"email_verified":     true,
+		"groups":             []string{},
+	},
+	// manager1 shares the email of the seeded manager1 account. MANAGER
+	// requires two-factor authentication by default, so the account is not
+	// linked by email; sign in as manager1 and link it at /auth/oidc/link.
+	"manager1": {
+		"sub":                "mock-manager1",
+		"preferred_username": "manager1.sso",
+		"name":               "Manager One",
+		"email":              "manager1@fleetify.com",
+		"email_verified":     true,
+		"groups":             []string{"fleetify-managers"},
+	},
+}
+
+type authorization struct {
+	user        string
+	clientID    string
+	redirectURI string
+	nonce       string
+	challenge   string
+	expiresAt   time.Time
+}
+
+type provider struct {
+	issuer   string
+	clientID string
+	key      *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+func main() {
+	addr := getEnv("MOCK_OIDC_ADDR", ":9000")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal("ERROR: Failed to generate signing key: ", err)
+	}
+
+	p := &provider{
+		issuer:   strings.TrimSuffix(getEnv("MOCK_OIDC_ISSUER", "http://localhost:9000"), "/"),
+		clientID: getEnv("MOCK_OIDC_CLIENT_ID", "fleetify"),
+		key:      key,
+		codes:    map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/jwks", p.jwks)
+
+	log.Printf("Mock OIDC provider %s listening on %s (client id %s)", p.issuer, addr, p.clientID)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func (p *provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "profile", "email", "groups"},
+	})
+}
+
+func (p *provider) jwks(w http.ResponseWriter, r *http.Request) {
+	public := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize validates the request and, once a user is chosen, redirects back
+// to the client with a code.
+func (p *provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+
+	switch {
+	case q.Get("client_id") != p.clientID:
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	case redirectURI == "":
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	}
+
+	back, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	redirectError := func(code, description string) {
+		values := back.Query()
+		values.Set("error", code)
+		values.Set("error_description", description)
+		values.Set("state", q.Get("state"))
+		back.RawQuery = values.Encode()
+		http.Redirect(w, r, back.String(), http.StatusFound)
+	}
+
+	switch {
+	case q.Get("response_type") != "code":
+		redirectError("unsupported_response_type", "only the code flow is supported")
+		return
+	case !strings.Contains(" "+q.Get("scope")+" ", " openid "):
+		redirectError("invalid_scope", "the openid scope is required")
+		return
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		redirectError("invalid_request", "PKCE with S256 is required")
+		return
+	}
+
+	user := q.Get("login_hint")
+	if user == "" {
+		p.chooser(w, r)
+		return
+	}
+	if _, ok := users[user]; !ok {
+		redirectError("access_denied", "unknown user "+user)
+		return
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	code := base64.RawURLEncoding.EncodeToString(buf)
+
+	p.mu.Lock()
+	p.codes[code] = authorization{
+		user:        user,
+		clientID:    q.Get("client_id"),
+		redirectURI: redirectURI,
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	p.mu.Unlock()
+
+	values := back.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	back.RawQuery = values.Encode()
+	log.Printf("Authorized %s, redirecting to %s", user, redirectURI)
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+// chooser lists the users as links back to authorize with a login_hint.
+func (p *provider) chooser(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(users))
+	for name := range users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, "<!doctype html><title>Mock OIDC</title><h1>Sign in as</h1><ul>")
+	for _, name := range names {
+		q := r.URL.Query()
+		q.Set("login_hint", name)
+		fmt.Fprintf(w, `<li><a href="/authorize?%s">%s</a> (%s, groups %v)</li>`,
+			html.EscapeString(q.Encode()),
+			html.EscapeString(name),
+			html.EscapeString(users[name]["email"].(string)),
+			users[name]["groups"])
+	}
+	fmt.Fprint(w, "</ul>")
+}
+
+// token redeems a code for an ID token after checking the client, the
+// redirect URI and the PKCE verifier.
+func (p *provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if id, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(id)
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	auth, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	switch {
+	case !ok || time.Now().After(auth.expiresAt):
+		tokenError(w, "invalid_grant", "unknown or expired code")
+		return
+	case clientID != auth.clientID:
+		tokenError(w, "invalid_client", "code was issued to another client")
+		return
+	case r.PostForm.Get("redirect_uri") != auth.redirectURI:
+		tokenError(w, "invalid_grant", "redirect_uri does not match")
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		tokenError(w, "invalid_grant", "code_verifier does not match the challenge")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": p.issuer,
+		"aud": auth.clientID,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+	if auth.nonce != "" {
+		claims["nonce"] = auth.nonce
+	}
+	for name, value := range users[auth.user] {
+		claims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		tokenError(w, "server_error", err.Error())
+		return
+	}
+
+	accessToken := make([]byte, 24)
+	rand.Read(accessToken)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": base64.RawURLEncoding.EncodeToString(accessToken),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"fleetify/internal/config"
 	"fleetify/internal/database"
 	"fleetify/internal/middleware"
+	"fleetify/internal/revocation"
 	"fleetify/internal/routes"
+	"fleetify/internal/signingkeys"
 	"fleetify/pkg/errors"
+	"fleetify/pkg/notify"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,6 +22,9 @@ func main() {
 		errors.LogError("Failed to load configuration", err)
 		log.Fatal("Failed to load configuration:", err)
 	}
+	if err := config.AppConfig.Validate(); err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
 
 	// Connect to database
 	if err := database.Connect(); err != nil {
@@ -26,6 +33,28 @@ func main() {
 	}
 	defer database.Close()
 
+	// Load the token signing keys and rotate them on schedule
+	if err := signingkeys.Start(context.Background()); err != nil {
+		errors.LogError("Failed to load signing keys", err)
+		log.Fatal("Failed to load signing keys:", err)
+	}
+
+	// Load revoked tokens and keep them in sync with other instances
+	revocation.Start(context.Background())
+
+	// Deliver mail through SMTP when configured; otherwise only log it
+	if smtp := config.AppConfig.SMTP; smtp.Host != "" {
+		notify.Default = &notify.SMTP{
+			Host:     smtp.Host,
+			Port:     smtp.Port,
+			Username: smtp.Username,
+			Password: smtp.Password,
+			From:     smtp.From,
+		}
+	} else {
+		notify.Default = notify.NewMemory(config.AppConfig.Server.Env != "production")
+	}
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName:      "Fleetify API",
@@ -0,0 +1,156 @@
+// Package apikeys authenticates integrations with long-lived API keys instead
+// of a user's password. A key acts as the user who created it, limited to the
+// permissions it was scoped to, and can be tied to an expiry and a list of
+// client IPs. Only a SHA-256 hash of each key is stored; the short prefix is
+// kept in clear so keys can be told apart in listings and logs.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/pkg/jwt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Prefix starts every key, so they are recognisable in an Authorization
+// header and by secret scanners.
+const Prefix = "flk_"
+
+var (
+	ErrInvalid      = errors.New("invalid API key")
+	ErrExpired      = errors.New("API key has expired")
+	ErrIPNotAllowed = errors.New("API key is not allowed from this IP address")
+)
+
+// usageInterval limits how often last_used_at is written for a busy key.
+const usageInterval = time.Minute
+
+// IsKey reports whether token looks like an API key rather than a JWT.
+func IsKey(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// Generate returns a new key, its visible prefix and the hash to store.
+func Generate() (key, prefix, hash string, err error) {
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err = rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = Prefix + hex.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, jwt.HashOpaqueToken(key), nil
+}
+
+// ValidateAllowlist checks that every entry is an IP address or a CIDR range
+// and returns them trimmed.
+func ValidateAllowlist(entries []string) ([]string, error) {
+	cleaned := []string{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			cleaned = append(cleaned, entry)
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		cleaned = append(cleaned, entry)
+	}
+	return cleaned, nil
+}
+
+// AllowedIP reports whether ip matches the allowlist. An empty allowlist
+// allows every address.
+func AllowedIP(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate looks up key for a request from ip and returns claims for its
+// owner carrying the key's id and scopes. Like a login, the claims flag an
+// owner who has to change their password or set up two-factor
+// authentication. It records when and from where the key was last used.
+func Authenticate(ctx context.Context, key, ip string) (*jwt.Claims, error) {
+	var claims jwt.Claims
+	var allowedIPs []string
+	var expiresAt *time.Time
+	var twoFactorEnabled bool
+	err := database.DB.QueryRow(ctx, `
+		SELECT k.api_keys_id, k.scopes, k.allowed_ips, k.expires_at, u.users_id, u.username, u.role,
+			u.must_change_password, u.two_factor_enabled
+		FROM api_keys k
+		JOIN users u ON k.user_id = u.users_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND u.is_active
+	`, jwt.HashOpaqueToken(key)).Scan(
+		&claims.APIKeyID,
+		&claims.Scopes,
+		&allowedIPs,
+		&expiresAt,
+		&claims.UserID,
+		&claims.Username,
+		&claims.Role,
+		&claims.MustChangePassword,
+		&twoFactorEnabled,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims.TwoFactorSetupRequired = !twoFactorEnabled && config.AppConfig.TwoFactor.RoleRequired(claims.Role)
+
+	now := time.Now()
+	if expiresAt != nil && now.After(*expiresAt) {
+		return nil, ErrExpired
+	}
+	if !AllowedIP(allowedIPs, ip) {
+		return nil, ErrIPNotAllowed
+	}
+
+	_, err = database.DB.Exec(ctx, `
+		UPDATE api_keys SET last_used_at = $1, last_used_ip = $2
+		WHERE api_keys_id = $3 AND (last_used_at IS NULL OR last_used_at < $4 OR last_used_ip IS DISTINCT FROM $2)
+	`, now, ip, claims.APIKeyID, now.Add(-usageInterval))
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
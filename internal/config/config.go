@@ -5,16 +5,29 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+
+	"fleetify/pkg/ratelimit"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
-	Webhook  WebhookConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Register   RegisterConfig
+	Login      LoginConfig
+	Password   PasswordConfig
+	TwoFactor  TwoFactorConfig
+	OIDC       OIDCConfig
+	SMTP       SMTPConfig
+	RateLimit  RateLimitConfig
+	CORS       CORSConfig
+	Webhook    WebhookConfig
+	Upload     UploadConfig
+	Compliance ComplianceConfig
+	Inventory  InventoryConfig
 }
 
 type ServerConfig struct {
@@ -38,9 +51,117 @@ func (d *DatabaseConfig) GetDSN() string {
 		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
 }
 
+// DefaultJWTSecret is the placeholder HS256 secret used when JWT_SECRET is
+// not set. The server refuses to start with it in production.
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// JWTConfig controls access tokens. Algorithm is HS256 (signed with Secret),
+// RS256 or EdDSA. The asymmetric algorithms sign with keys kept in the
+// database: a new key is made every KeyRotationInterval and the old one still
+// verifies for KeyGracePeriod after it stopped signing.
 type JWTConfig struct {
-	Secret    string
-	ExpiresIn string
+	Secret                 string
+	Algorithm              string
+	ExpiresIn              string
+	RefreshExpiresIn       string
+	RevocationSyncInterval string
+	KeyRotationInterval    string
+	KeyGracePeriod         string
+}
+
+// RegisterConfig controls self-registration. Mode is "disabled", "invite"
+// (an admin-issued invite is required and sets the role) or "open" (anyone
+// may register with DefaultRole; invites still work).
+type RegisterConfig struct {
+	Mode            string
+	DefaultRole     string
+	InviteExpiresIn string
+}
+
+// LoginConfig controls failed-login tracking. After each failure the next
+// attempt for the same username has to wait DelayBase, doubling per failure
+// up to DelayMax. MaxAttempts failures within AttemptWindow lock the username
+// for LockoutDuration; IPMaxAttempts does the same per client IP.
+type LoginConfig struct {
+	MaxAttempts     int
+	IPMaxAttempts   int
+	AttemptWindow   string
+	LockoutDuration string
+	DelayBase       string
+	DelayMax        string
+}
+
+// PasswordConfig holds the password policy and controls self-service
+// password resets. History is how many recent passwords may not be reused.
+// ResetURL is the frontend page that completes a reset; "{token}" in it is
+// replaced with the reset token. Without it the mail contains the bare token.
+type PasswordConfig struct {
+	MinLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSymbol  bool
+	History        int
+	RejectCommon   bool
+	ResetExpiresIn string
+	ResetURL       string
+}
+
+// TwoFactorConfig controls TOTP two-factor authentication. RequiredRoles is
+// a comma-separated list of roles whose users must enroll before they can use
+// the API. Issuer is the name authenticator apps show for the account.
+type TwoFactorConfig struct {
+	Issuer             string
+	RequiredRoles      string
+	ChallengeExpiresIn string
+	RecoveryCodes      int
+}
+
+// RoleRequired reports whether role is one of RequiredRoles.
+func (t TwoFactorConfig) RoleRequired(role string) bool {
+	for _, required := range strings.Split(t.RequiredRoles, ",") {
+		if strings.EqualFold(strings.TrimSpace(required), role) {
+			return true
+		}
+	}
+	return false
+}
+
+// OIDCConfig enables single sign-on with an OpenID Connect provider; it is
+// off while Issuer is empty. RedirectURL is the frontend page the provider
+// returns to, which posts the code and state to the callback endpoint.
+// RoleMapping is a comma-separated list of "group=ROLE" pairs checked in
+// order against the GroupsClaim; the first match wins, then DefaultRole.
+// AutoProvision creates unknown users on first login and SyncRoles updates
+// a linked user's role from their groups on every login.
+type OIDCConfig struct {
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	Scopes         string
+	UsernameClaim  string
+	GroupsClaim    string
+	RoleMapping    string
+	DefaultRole    string
+	AutoProvision  bool
+	SyncRoles      bool
+	StateExpiresIn string
+}
+
+// SMTPConfig is used to mail users. Without a Host messages are only logged.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// RateLimitConfig holds per-route limits as "name=max/window" pairs, e.g.
+// "login=10/1m,register=5/1h". Routes without a rule are not limited.
+type RateLimitConfig struct {
+	Rules string
 }
 
 type CORSConfig struct {
@@ -53,6 +174,18 @@ type WebhookConfig struct {
 	URL string
 }
 
+type UploadConfig struct {
+	Dir string
+}
+
+type ComplianceConfig struct {
+	BlockingDocumentTypes string
+}
+
+type InventoryConfig struct {
+	CostingMethod string
+}
+
 var AppConfig *Config
 
 // LoadConfig configuration
@@ -87,8 +220,67 @@ func LoadConfig() error {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:    getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			ExpiresIn: getEnv("JWT_EXPIRES_IN", "24h"),
+			Secret:                 getEnv("JWT_SECRET", DefaultJWTSecret),
+			Algorithm:              getEnv("JWT_ALGORITHM", "RS256"),
+			ExpiresIn:              getEnv("JWT_EXPIRES_IN", "15m"),
+			RefreshExpiresIn:       getEnv("JWT_REFRESH_EXPIRES_IN", "168h"),
+			RevocationSyncInterval: getEnv("JWT_REVOCATION_SYNC_INTERVAL", "30s"),
+			KeyRotationInterval:    getEnv("JWT_KEY_ROTATION_INTERVAL", "720h"),
+			KeyGracePeriod:         getEnv("JWT_KEY_GRACE_PERIOD", "24h"),
+		},
+		Register: RegisterConfig{
+			Mode:            getEnv("REGISTER_MODE", "invite"),
+			DefaultRole:     getEnv("REGISTER_DEFAULT_ROLE", "MITRA"),
+			InviteExpiresIn: getEnv("REGISTER_INVITE_EXPIRES_IN", "72h"),
+		},
+		Login: LoginConfig{
+			MaxAttempts:     getEnvAsInt("LOGIN_MAX_ATTEMPTS", 5),
+			IPMaxAttempts:   getEnvAsInt("LOGIN_IP_MAX_ATTEMPTS", 50),
+			AttemptWindow:   getEnv("LOGIN_ATTEMPT_WINDOW", "15m"),
+			LockoutDuration: getEnv("LOGIN_LOCKOUT_DURATION", "15m"),
+			DelayBase:       getEnv("LOGIN_DELAY_BASE", "1s"),
+			DelayMax:        getEnv("LOGIN_DELAY_MAX", "30s"),
+		},
+		Password: PasswordConfig{
+			MinLength:      getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			RequireUpper:   getEnvAsBool("PASSWORD_REQUIRE_UPPER", true),
+			RequireLower:   getEnvAsBool("PASSWORD_REQUIRE_LOWER", true),
+			RequireDigit:   getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol:  getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			History:        getEnvAsInt("PASSWORD_HISTORY", 5),
+			RejectCommon:   getEnvAsBool("PASSWORD_REJECT_COMMON", true),
+			ResetExpiresIn: getEnv("PASSWORD_RESET_EXPIRES_IN", "1h"),
+			ResetURL:       getEnv("PASSWORD_RESET_URL", ""),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:             getEnv("TWO_FACTOR_ISSUER", "Fleetify"),
+			RequiredRoles:      getEnv("TWO_FACTOR_REQUIRED_ROLES", "ADMIN,MANAGER"),
+			ChallengeExpiresIn: getEnv("TWO_FACTOR_CHALLENGE_EXPIRES_IN", "5m"),
+			RecoveryCodes:      getEnvAsInt("TWO_FACTOR_RECOVERY_CODES", 10),
+		},
+		OIDC: OIDCConfig{
+			Issuer:         getEnv("OIDC_ISSUER", ""),
+			ClientID:       getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:    getEnv("OIDC_REDIRECT_URL", ""),
+			Scopes:         getEnv("OIDC_SCOPES", "openid profile email groups"),
+			UsernameClaim:  getEnv("OIDC_USERNAME_CLAIM", "preferred_username"),
+			GroupsClaim:    getEnv("OIDC_GROUPS_CLAIM", "groups"),
+			RoleMapping:    getEnv("OIDC_ROLE_MAPPING", ""),
+			DefaultRole:    getEnv("OIDC_DEFAULT_ROLE", ""),
+			AutoProvision:  getEnvAsBool("OIDC_AUTO_PROVISION", true),
+			SyncRoles:      getEnvAsBool("OIDC_SYNC_ROLES", true),
+			StateExpiresIn: getEnv("OIDC_STATE_EXPIRES_IN", "10m"),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "Fleetify <no-reply@fleetify.local>"),
+		},
+		RateLimit: RateLimitConfig{
+			Rules: getEnv("RATE_LIMIT_RULES", "login=20/1m,register=10/1h,refresh=60/1m,password_reset=5/1h,two_factor=10/1m,password_change=10/1h,oidc=20/1m"),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:5173,http://127.0.0.1:5500"),
@@ -98,11 +290,40 @@ func LoadConfig() error {
 		Webhook: WebhookConfig{
 			URL: getEnv("WEBHOOK_URL", ""),
 		},
+		Upload: UploadConfig{
+			Dir: getEnv("UPLOAD_DIR", "uploads"),
+		},
+		Compliance: ComplianceConfig{
+			BlockingDocumentTypes: getEnv("COMPLIANCE_BLOCKING_DOCUMENT_TYPES", "KIR"),
+		},
+		Inventory: InventoryConfig{
+			CostingMethod: getEnv("INVENTORY_COSTING_METHOD", "average"),
+		},
 	}
 
 	return nil
 }
 
+// Validate refuses settings the server must not run with.
+func (c *Config) Validate() error {
+	switch c.JWT.Algorithm {
+	case "HS256":
+		if c.Server.Env == "production" && c.JWT.Secret == DefaultJWTSecret {
+			return fmt.Errorf("JWT_SECRET must be set in production when JWT_ALGORITHM is HS256")
+		}
+	case "RS256", "EdDSA":
+	default:
+		return fmt.Errorf("unsupported JWT_ALGORITHM %q; use RS256, EdDSA or HS256", c.JWT.Algorithm)
+	}
+	if c.OIDC.Issuer != "" && (c.OIDC.ClientID == "" || c.OIDC.RedirectURL == "") {
+		return fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL must be set when OIDC_ISSUER is")
+	}
+	if _, err := ratelimit.ParseRules(c.RateLimit.Rules); err != nil {
+		return fmt.Errorf("invalid RATE_LIMIT_RULES: %w", err)
+	}
+	return nil
+}
+
 // getEnv
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/apikeys"
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/internal/permissions"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateApiKeyRequest struct {
+	Name       string   `json:"name" validate:"required"`
+	Scopes     []string `json:"scopes"`
+	ExpiresIn  string   `json:"expires_in"`
+	AllowedIps []string `json:"allowed_ips"`
+}
+
+type ApiKeyResponse struct {
+	models.ApiKeys
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	Key      string `json:"key,omitempty"`
+}
+
+const apiKeyColumns = `api_keys_id, name, prefix, user_id, (SELECT username FROM users WHERE users_id = api_keys.user_id),
+	scopes, allowed_ips, expires_at, last_used_at, last_used_ip, revoked_at, created_at, updated_at`
+
+func scanApiKey(row interface{ Scan(...any) error }, key *ApiKeyResponse) error {
+	err := row.Scan(
+		&key.ApiKeysId,
+		&key.Name,
+		&key.Prefix,
+		&key.UserId,
+		&key.Username,
+		&key.Scopes,
+		&key.AllowedIps,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.LastUsedIp,
+		&key.RevokedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err == nil {
+		key.Status = apiKeyStatus(key.ApiKeys)
+	}
+	return err
+}
+
+func apiKeyStatus(key models.ApiKeys) string {
+	switch {
+	case key.RevokedAt != nil:
+		return "revoked"
+	case key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt):
+		return "expired"
+	}
+	return "active"
+}
+
+// apiKeyOwner returns the user whose keys the caller may see and revoke: the
+// caller, or "" for every user when they hold api_keys:admin.
+func apiKeyOwner(ctx context.Context, c *fiber.Ctx) (string, error) {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return "", fiber.ErrUnauthorized
+	}
+
+	granted, err := permissions.Granted(ctx, claims, permissions.APIKeysAdmin)
+	if err != nil {
+		return "", err
+	}
+	if granted {
+		return "", nil
+	}
+	return claims.UserID, nil
+}
+
+func GetApiKeys(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := apiKeyOwner(ctx, c)
+	if err != nil {
+		errors.LogError("API key permission check error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check permissions",
+		})
+	}
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"name", "prefix"}
+	filterFields := map[string]string{
+		"user_id": "user_id",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	statusConditions := map[string]string{
+		"active":  "revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())",
+		"expired": "revoked_at IS NULL AND expires_at <= NOW()",
+		"revoked": "revoked_at IS NOT NULL",
+	}
+	conditions := []string{}
+	if condition, ok := statusConditions[params.Filters["status"]]; ok {
+		conditions = append(conditions, condition)
+	}
+	if owner != "" {
+		whereArgs = append(whereArgs, owner)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(whereArgs)))
+	}
+	if len(conditions) > 0 {
+		if whereClause == "" {
+			whereClause = "WHERE " + strings.Join(conditions, " AND ")
+		} else {
+			whereClause += " AND " + strings.Join(conditions, " AND ")
+		}
+	}
+	orderClause := query.BuildOrderClause(params, "created_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("api_keys", whereClause)
+
+	var totalCount int
+	err = database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get API keys count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count API keys",
+		})
+	}
+
+	fullQuery := "SELECT " + apiKeyColumns + " FROM api_keys " + whereClause + " " + orderClause + " " + paginationClause
+
+	rows, err := database.DB.Query(ctx, fullQuery, append(whereArgs, paginationArgs...)...)
+	if err != nil {
+		errors.LogError("Get API keys query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch API keys",
+		})
+	}
+	defer rows.Close()
+
+	keys := []ApiKeyResponse{}
+	for rows.Next() {
+		var key ApiKeyResponse
+		if err := scanApiKey(rows, &key); err != nil {
+			errors.LogError("API key scan error", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process API keys",
+		})
+	}
+
+	response := query.NewPaginatedResponse(keys, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetApiKeyById(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := apiKeyOwner(ctx, c)
+	if err != nil {
+		errors.LogError("API key permission check error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check permissions",
+		})
+	}
+
+	var key ApiKeyResponse
+	row := database.DB.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE api_keys_id = $1 AND ($2 = '' OR user_id::text = $2)", id, owner)
+	if err := scanApiKey(row, &key); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "API key not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  key,
+	})
+}
+
+// CreateApiKey issues a key that acts as the caller, limited to the given
+// scopes. Every scope has to be a permission the caller holds. The key is
+// only returned here; the database keeps its hash.
+func CreateApiKey(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req CreateApiKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Name is required",
+		})
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn != "" {
+		ttl, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "expires_in must be a positive duration such as 2160h",
+			})
+		}
+		expires := time.Now().Add(ttl)
+		expiresAt = &expires
+	}
+
+	allowedIps, err := apikeys.ValidateAllowlist(req.AllowedIps)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid allowed_ips: " + err.Error(),
+		})
+	}
+
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, scope := range req.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var known int
+	err = database.DB.QueryRow(ctx, "SELECT COUNT(*) FROM permissions WHERE code = ANY($1)", scopes).Scan(&known)
+	if err != nil {
+		errors.LogError("API key scope lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create API key",
+		})
+	}
+	if known != len(scopes) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unknown permission; see GET /roles/permissions",
+		})
+	}
+
+	for _, scope := range scopes {
+		granted, err := permissions.Granted(ctx, claims, scope)
+		if err != nil {
+			errors.LogError("Permission lookup error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to create API key",
+			})
+		}
+		if !granted {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Cannot grant permission " + scope + " that you do not hold",
+			})
+		}
+	}
+
+	secret, prefix, keyHash, err := apikeys.Generate()
+	if err != nil {
+		errors.LogError("API key generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create API key",
+		})
+	}
+
+	now := time.Now()
+	key := ApiKeyResponse{Key: secret}
+	row := database.DB.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, user_id, scopes, allowed_ips, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING `+apiKeyColumns,
+		req.Name, prefix, keyHash, claims.UserID, scopes, allowedIps, expiresAt, now)
+	if err := scanApiKey(row, &key); err != nil {
+		errors.LogError("API key creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create API key",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "API key created successfully. Store it now; it cannot be shown again",
+		"data":    key,
+	})
+}
+
+// RevokeApiKey stops a key from working. The record stays for auditing.
+// Users revoke their own keys; api_keys:admin revokes anyone's.
+func RevokeApiKey(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	owner, err := apiKeyOwner(ctx, c)
+	if err != nil {
+		errors.LogError("API key permission check error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check permissions",
+		})
+	}
+
+	var revokedAt *time.Time
+	err = database.DB.QueryRow(ctx, "SELECT revoked_at FROM api_keys WHERE api_keys_id = $1 AND ($2 = '' OR user_id::text = $2)", id, owner).Scan(&revokedAt)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "API key not found",
+		})
+	}
+
+	if revokedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "API key has already been revoked",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "UPDATE api_keys SET revoked_at = $1, updated_at = $1 WHERE api_keys_id = $2", time.Now(), id)
+	if err != nil {
+		errors.LogError("API key revoke error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke API key",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "API key revoked successfully",
+	})
+}
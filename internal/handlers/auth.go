@@ -2,24 +2,32 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"fleetify/internal/config"
 	"fleetify/internal/database"
+	"fleetify/internal/loginguard"
 	"fleetify/internal/models"
+	"fleetify/internal/revocation"
 	"fleetify/pkg/errors"
 	"fleetify/pkg/jwt"
 	"fleetify/pkg/password"
+	"fleetify/pkg/ratelimit"
 )
 
 type RegisterRequest struct {
-	Username string `json:"username" validate:"required,min=3"`
-	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role" validate:"required"`
-	FullName string `json:"full_name" validate:"required"`
-	Email    string `json:"email"`
-	Phone    string `json:"phone"`
+	Username    string `json:"username" validate:"required,min=3"`
+	Password    string `json:"password" validate:"required"`
+	Role        string `json:"role"`
+	FullName    string `json:"full_name" validate:"required"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	InviteToken string `json:"invite_token"`
 }
 
 type LoginRequest struct {
@@ -27,13 +35,89 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 type AuthResponse struct {
-	Token     string      `json:"token"`
-	User      interface{} `json:"user"`
-	ExpiresIn string      `json:"expires_in"`
+	Token            string      `json:"token"`
+	RefreshToken     string      `json:"refresh_token"`
+	User             interface{} `json:"user"`
+	ExpiresIn        string      `json:"expires_in"`
+	RefreshExpiresIn string      `json:"refresh_expires_in"`
 }
 
+// issueTokens signs an access token and stores a new refresh token for the
+// given session, creating the session on its first tokens. The session id
+// is also the refresh token family. It returns the response and the id of
+// the refresh token.
+func issueTokens(ctx context.Context, q rowQuerier, c *fiber.Ctx, user models.Users, sessionId string) (AuthResponse, string, error) {
+	// A forced password change comes first; enrollment is asked for once the
+	// user logs in with the new password.
+	setupRequired := !user.MustChangePassword && !user.TwoFactorEnabled && config.AppConfig.TwoFactor.RoleRequired(user.Role)
+	token, err := jwt.GenerateToken(user.UsersId, user.Username, user.Role, sessionId, user.MustChangePassword, setupRequired)
+	if err != nil {
+		return AuthResponse{}, "", err
+	}
+
+	refreshToken, tokenHash, err := jwt.NewOpaqueToken()
+	if err != nil {
+		return AuthResponse{}, "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(jwt.RefreshTokenTTL())
+	userAgent := c.Get(fiber.HeaderUserAgent)
+
+	var sessionUserId string
+	err = q.QueryRow(ctx, `
+		INSERT INTO user_sessions (user_sessions_id, user_id, user_agent, ip_address, last_seen_at, last_seen_ip, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $4, $6, $5, $5)
+		ON CONFLICT (user_sessions_id) DO UPDATE
+		SET user_agent = EXCLUDED.user_agent, last_seen_at = EXCLUDED.last_seen_at,
+			last_seen_ip = EXCLUDED.last_seen_ip, expires_at = EXCLUDED.expires_at, updated_at = EXCLUDED.updated_at
+		RETURNING user_id
+	`, sessionId, user.UsersId, userAgent, c.IP(), now, expiresAt).Scan(&sessionUserId)
+	if err != nil {
+		return AuthResponse{}, "", err
+	}
+	if sessionUserId != user.UsersId {
+		return AuthResponse{}, "", fmt.Errorf("session %s belongs to another user", sessionId)
+	}
+
+	var refreshTokenId string
+	err = q.QueryRow(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, user_agent, ip_address, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING refresh_tokens_id
+	`, user.UsersId, sessionId, tokenHash, expiresAt, userAgent, c.IP(), now).Scan(&refreshTokenId)
+	if err != nil {
+		return AuthResponse{}, "", err
+	}
+
+	user.Password = ""
+	return AuthResponse{
+		Token:            token,
+		RefreshToken:     refreshToken,
+		User:             user,
+		ExpiresIn:        formatTTL(jwt.AccessTokenTTL()),
+		RefreshExpiresIn: formatTTL(jwt.RefreshTokenTTL()),
+	}, refreshTokenId, nil
+}
+
+// Register creates an account according to REGISTER_MODE. The role always
+// comes from the invite or from REGISTER_DEFAULT_ROLE; a role sent by the
+// client is only accepted when it matches.
 func Register(c *fiber.Ctx) error {
+	cfg := config.AppConfig.Register
+	mode := strings.ToLower(cfg.Mode)
+	if mode == "disabled" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Registration is disabled",
+		})
+	}
+
 	var req RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -42,26 +126,92 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Username == "" || req.Password == "" || req.Role == "" || req.FullName == "" {
+	if req.InviteToken == "" && mode != "open" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "An invite is required to register",
+		})
+	}
+
+	if req.Username == "" || req.Password == "" || req.FullName == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
-			"message": "Username, password, role, and full_name are required",
+			"message": "Username, password, and full_name are required",
 		})
 	}
 
-	if len(req.Password) < 6 {
+	if message := passwordPolicyMessage(req.Password, req.Username); message != "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
-			"message": "Password must be at least 6 characters",
+			"message": message,
 		})
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to register user",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	role := strings.ToUpper(cfg.DefaultRole)
+	var invite models.UserInvites
+	if req.InviteToken != "" {
+		err = tx.QueryRow(ctx, `
+			SELECT user_invites_id, role, COALESCE(email, ''), expires_at, used_at
+			FROM user_invites
+			WHERE token_hash = $1
+			FOR UPDATE
+		`, jwt.HashOpaqueToken(req.InviteToken)).Scan(
+			&invite.UserInvitesId,
+			&invite.Role,
+			&invite.Email,
+			&invite.ExpiresAt,
+			&invite.UsedAt,
+		)
+		if err != nil || invite.UsedAt != nil || time.Now().After(invite.ExpiresAt) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invite is invalid, used or expired",
+			})
+		}
+
+		if invite.Email != "" {
+			if req.Email != "" && !strings.EqualFold(req.Email, invite.Email) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error":   true,
+					"message": "Invite was issued for a different email address",
+				})
+			}
+			req.Email = invite.Email
+		}
+		role = invite.Role
+	}
+
+	if req.Role != "" && !strings.EqualFold(req.Role, role) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Registration can only create %s accounts", role),
+		})
+	}
+
+	if !roleExists(ctx, tx, role) {
+		errors.LogError("Registration role error", fmt.Errorf("role %q does not exist", role))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Registration role is not configured",
+		})
+	}
+
 	var existingUser models.Users
 	checkQuery := `SELECT users_id FROM users WHERE username = $1`
-	err := database.DB.QueryRow(ctx, checkQuery, req.Username).Scan(&existingUser.UsersId)
+	err = tx.QueryRow(ctx, checkQuery, req.Username).Scan(&existingUser.UsersId)
 	if err == nil {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"error":   true,
@@ -88,11 +238,11 @@ func Register(c *fiber.Ctx) error {
 	`
 
 	var user models.Users
-	err = database.DB.QueryRow(ctx, insertQuery,
+	err = tx.QueryRow(ctx, insertQuery,
 		userID,
 		req.Username,
 		hashedPassword,
-		req.Role,
+		role,
 		req.FullName,
 		req.Email,
 		req.Phone,
@@ -119,7 +269,21 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := jwt.GenerateToken(user.UsersId, user.Username, user.Role)
+	if invite.UserInvitesId != "" {
+		_, err = tx.Exec(ctx, `
+			UPDATE user_invites SET used_at = $1, used_by = $2, updated_at = $1
+			WHERE user_invites_id = $3
+		`, now, user.UsersId, invite.UserInvitesId)
+		if err != nil {
+			errors.LogError("Invite update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to register user",
+			})
+		}
+	}
+
+	response, _, err := issueTokens(ctx, tx, c, user, uuid.New().String())
 	if err != nil {
 		errors.LogError("Token generation error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -128,18 +292,46 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
-	user.Password = ""
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to register user",
+		})
+	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"error": false,
-		"data": AuthResponse{
-			Token:     token,
-			User:      user,
-			ExpiresIn: "24h",
-		},
+		"data":  response,
 	})
 }
 
+// failLogin counts a failed login towards the delay and lockout of the
+// username and client IP.
+func failLogin(ctx context.Context, username, ip string) {
+	if err := loginguard.Fail(ctx, username, ip); err != nil {
+		errors.LogError("Login guard record error", err)
+	}
+}
+
+// blockedLogin answers a login attempt that loginguard does not allow yet.
+func blockedLogin(c *fiber.Ctx, block *loginguard.Block) error {
+	c.Set(fiber.HeaderRetryAfter, ratelimit.RetryAfter(block.RetryAfter))
+	if block.Locked {
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"error":   true,
+			"message": "Too many failed login attempts. Account is temporarily locked",
+		})
+	}
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":   true,
+		"message": "Too many failed login attempts. Try again in " + ratelimit.RetryAfter(block.RetryAfter) + " seconds",
+	})
+}
+
+// Login checks the username and password. Users with two-factor
+// authentication get a challenge token to complete with LoginTwoFactor
+// instead of tokens.
 func Login(c *fiber.Ctx) error {
 	var req LoginRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -159,9 +351,19 @@ func Login(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	ip := c.IP()
+	block, err := loginguard.Check(ctx, req.Username, ip)
+	if err != nil {
+		// Failing open keeps logins working while the store is unreachable.
+		errors.LogError("Login guard check error", err)
+	}
+	if block != nil {
+		return blockedLogin(c, block)
+	}
+
 	var user models.Users
-	query := `SELECT users_id, username, password, role, full_name, email, phone, is_active FROM users WHERE username = $1`
-	err := database.DB.QueryRow(ctx, query, req.Username).Scan(
+	query := `SELECT users_id, username, password, role, full_name, email, phone, is_active, must_change_password, two_factor_enabled FROM users WHERE username = $1`
+	err = database.DB.QueryRow(ctx, query, req.Username).Scan(
 		&user.UsersId,
 		&user.Username,
 		&user.Password,
@@ -170,9 +372,12 @@ func Login(c *fiber.Ctx) error {
 		&user.Email,
 		&user.Phone,
 		&user.IsActive,
+		&user.MustChangePassword,
+		&user.TwoFactorEnabled,
 	)
 
 	if err != nil {
+		failLogin(ctx, req.Username, ip)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   true,
 			"message": "Invalid credentials",
@@ -187,13 +392,47 @@ func Login(c *fiber.Ctx) error {
 	}
 
 	if !password.Verify(req.Password, user.Password) {
+		failLogin(ctx, req.Username, ip)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   true,
 			"message": "Invalid credentials",
 		})
 	}
 
-	token, err := jwt.GenerateToken(user.UsersId, user.Username, user.Role)
+	// The failure count is only reset once the second factor is checked, so
+	// the password cannot be used to keep guessing codes.
+	if user.TwoFactorEnabled {
+		challengeToken, ttl, err := startLoginChallenge(ctx, c, user.UsersId)
+		if err != nil {
+			errors.LogError("Login challenge error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to start two-factor login",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"error":   false,
+			"message": "Two-factor authentication required",
+			"data": fiber.Map{
+				"two_factor_required": true,
+				"challenge_token":     challengeToken,
+				"expires_in":          formatTTL(ttl),
+			},
+		})
+	}
+
+	if err = loginguard.Succeed(ctx, req.Username); err != nil {
+		errors.LogError("Login guard reset error", err)
+	}
+
+	// Expired refresh tokens can no longer be used or replayed; drop them.
+	_, err = database.DB.Exec(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < NOW()", user.UsersId)
+	if err != nil {
+		errors.LogError("Refresh token cleanup error", err)
+	}
+
+	response, _, err := issueTokens(ctx, database.DB, c, user, uuid.New().String())
 	if err != nil {
 		errors.LogError("Token generation error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -202,27 +441,84 @@ func Login(c *fiber.Ctx) error {
 		})
 	}
 
-	user.Password = ""
-
 	return c.JSON(fiber.Map{
 		"error": false,
-		"data": AuthResponse{
-			Token:     token,
-			User:      user,
-			ExpiresIn: "24h",
-		},
+		"data":  response,
 	})
 }
 
-func GetToken(c *fiber.Ctx) error {
-	claims := c.Locals("user").(*jwt.Claims)
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. Each refresh token works once; presenting a used or revoked token
+// again means it was copied, so the whole family is revoked and the user has
+// to log in again.
+func Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "refresh_token is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to refresh token",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var stored models.RefreshTokens
+	err = tx.QueryRow(ctx, `
+		SELECT refresh_tokens_id, user_id, family_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, jwt.HashOpaqueToken(req.RefreshToken)).Scan(
+		&stored.RefreshTokensId,
+		&stored.UserId,
+		&stored.FamilyId,
+		&stored.ExpiresAt,
+		&stored.UsedAt,
+		&stored.RevokedAt,
+	)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid refresh token",
+		})
+	}
+
+	if status, message, reused := refreshTokenRejection(stored, time.Now()); status != 0 {
+		if reused {
+			if stored.RevokedAt == nil {
+				errors.LogError("Refresh token reuse detected", fmt.Errorf("token family %s of user %s", stored.FamilyId, stored.UserId))
+			}
+			if err := revokeTokenFamily(ctx, tx, stored.FamilyId, "refresh_token_reuse"); err != nil {
+				errors.LogError("Revoke token family error", err)
+			}
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
 	var user models.Users
-	query := `SELECT users_id, username, role, full_name, email, phone, is_active FROM users WHERE users_id = $1`
-	err := database.DB.QueryRow(ctx, query, claims.UserID).Scan(
+	query := `SELECT users_id, username, role, full_name, email, phone, is_active, must_change_password, two_factor_enabled FROM users WHERE users_id = $1`
+	err = tx.QueryRow(ctx, query, stored.UserId).Scan(
 		&user.UsersId,
 		&user.Username,
 		&user.Role,
@@ -230,6 +526,8 @@ func GetToken(c *fiber.Ctx) error {
 		&user.Email,
 		&user.Phone,
 		&user.IsActive,
+		&user.MustChangePassword,
+		&user.TwoFactorEnabled,
 	)
 
 	if err != nil {
@@ -240,13 +538,16 @@ func GetToken(c *fiber.Ctx) error {
 	}
 
 	if !user.IsActive {
+		if err := revokeTokenFamily(ctx, tx, stored.FamilyId, "deactivated"); err != nil {
+			errors.LogError("Revoke token family error", err)
+		}
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error":   true,
 			"message": "Account is inactive",
 		})
 	}
 
-	token, err := jwt.GenerateToken(user.UsersId, user.Username, user.Role)
+	response, replacementId, err := issueTokens(ctx, tx, c, user, stored.FamilyId)
 	if err != nil {
 		errors.LogError("Token generation error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -255,20 +556,161 @@ func GetToken(c *fiber.Ctx) error {
 		})
 	}
 
+	_, err = tx.Exec(ctx, `
+		UPDATE refresh_tokens SET used_at = $1, replaced_by = $2, updated_at = $1
+		WHERE refresh_tokens_id = $3
+	`, time.Now(), replacementId, stored.RefreshTokensId)
+	if err != nil {
+		errors.LogError("Rotate refresh token error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to refresh token",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to refresh token",
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"error": false,
-		"data": AuthResponse{
-			Token:     token,
-			User:      user,
-			ExpiresIn: "24h",
-		},
+		"data":  response,
 	})
 }
 
+// refreshTokenRejection returns the response for a refresh token that cannot
+// be exchanged at now, or a zero status when it can. reused is set for a
+// token that was used or revoked before; its whole family has to be revoked.
+func refreshTokenRejection(stored models.RefreshTokens, now time.Time) (status int, message string, reused bool) {
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		return fiber.StatusUnauthorized, "Refresh token has already been used or revoked, please log in again", true
+	}
+	if now.After(stored.ExpiresAt) {
+		return fiber.StatusUnauthorized, "Refresh token has expired, please log in again", false
+	}
+	return 0, "", false
+}
+
+// revokeTokenFamily revokes every refresh token of the family, ends its
+// session and commits tx.
+func revokeTokenFamily(ctx context.Context, tx pgx.Tx, familyId, reason string) error {
+	now := time.Now()
+	_, err := tx.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $1, updated_at = $1
+		WHERE family_id = $2 AND revoked_at IS NULL
+	`, now, familyId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = $1, revoked_reason = $2, updated_at = $1
+		WHERE user_sessions_id = $3 AND revoked_at IS NULL
+	`, now, reason, familyId)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// formatTTL prints a duration the way it is configured, e.g. 15m or 168h.
+func formatTTL(d time.Duration) string {
+	value := d.String()
+	if strings.HasSuffix(value, "m0s") {
+		value = strings.TrimSuffix(value, "0s")
+	}
+	if strings.HasSuffix(value, "h0m") {
+		value = strings.TrimSuffix(value, "0m")
+	}
+	return value
+}
+
+// Logout ends the session of the access token used for the request. Tokens
+// issued before sessions were tracked carry no session; for those the
+// access token is revoked and, when the client sends its refresh token, its
+// refresh token family.
 func Logout(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+
+	var req RefreshRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if claims.SessionID != "" {
+		if err := revocation.RevokeSession(ctx, claims.UserID, claims.SessionID, "logout"); err != nil {
+			errors.LogError("Logout revoke error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to log out",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"error":   false,
+			"message": "Logged out successfully",
+		})
+	}
+
+	if err := revocation.RevokeToken(ctx, claims, "logout"); err != nil {
+		errors.LogError("Logout revoke error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to log out",
+		})
+	}
+
+	if req.RefreshToken != "" {
+		_, err := database.DB.Exec(ctx, `
+			UPDATE refresh_tokens SET revoked_at = $1, updated_at = $1
+			WHERE revoked_at IS NULL AND user_id = $2 AND family_id = (
+				SELECT family_id FROM refresh_tokens WHERE token_hash = $3
+			)
+		`, time.Now(), claims.UserID, jwt.HashOpaqueToken(req.RefreshToken))
+		if err != nil {
+			errors.LogError("Logout revoke error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to log out",
+			})
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Logged out successfully",
 	})
 }
 
+// LogoutAll revokes every access and refresh token of the current user.
+func LogoutAll(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := revocation.RevokeUser(ctx, claims.UserID, "logout_all"); err != nil {
+		errors.LogError("Logout all revoke error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to log out",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Logged out of all sessions successfully",
+	})
+}
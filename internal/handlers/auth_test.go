@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"fleetify/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRefreshTokenRejection(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		stored     models.RefreshTokens
+		wantStatus int
+		wantReused bool
+	}{
+		{
+			name:   "unused and unexpired",
+			stored: models.RefreshTokens{ExpiresAt: now.Add(time.Hour)},
+		},
+		{
+			name:       "rotated token presented again",
+			stored:     models.RefreshTokens{ExpiresAt: now.Add(time.Hour), UsedAt: &earlier},
+			wantStatus: fiber.StatusUnauthorized,
+			wantReused: true,
+		},
+		{
+			name:       "revoked token",
+			stored:     models.RefreshTokens{ExpiresAt: now.Add(time.Hour), RevokedAt: &earlier},
+			wantStatus: fiber.StatusUnauthorized,
+			wantReused: true,
+		},
+		{
+			name:       "reuse wins over expiry",
+			stored:     models.RefreshTokens{ExpiresAt: earlier, UsedAt: &earlier},
+			wantStatus: fiber.StatusUnauthorized,
+			wantReused: true,
+		},
+		{
+			name:       "expired",
+			stored:     models.RefreshTokens{ExpiresAt: earlier},
+			wantStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:   "expires exactly now",
+			stored: models.RefreshTokens{ExpiresAt: now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message, reused := refreshTokenRejection(tt.stored, now)
+			if status != tt.wantStatus || reused != tt.wantReused {
+				t.Errorf("refreshTokenRejection = (%d, %q, %v), want (%d, _, %v)", status, message, reused, tt.wantStatus, tt.wantReused)
+			}
+			if (status == 0) != (message == "") {
+				t.Errorf("status %d with message %q", status, message)
+			}
+		})
+	}
+}
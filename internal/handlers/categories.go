@@ -0,0 +1,613 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateCategoryRequest struct {
+	ParentId        *string `json:"parent_id"`
+	Code            string  `json:"code" validate:"required"`
+	Name            string  `json:"name" validate:"required"`
+	Description     string  `json:"description"`
+	DefaultMinStock int     `json:"default_min_stock"`
+	GlAccount       string  `json:"gl_account"`
+}
+
+type UpdateCategoryRequest struct {
+	ParentId        *string `json:"parent_id"`
+	Code            *string `json:"code"`
+	Name            *string `json:"name"`
+	Description     *string `json:"description"`
+	DefaultMinStock *int    `json:"default_min_stock"`
+	GlAccount       *string `json:"gl_account"`
+}
+
+type CategoryResponse struct {
+	models.Categories
+	Path     string              `json:"path"`
+	Children []*CategoryResponse `json:"children,omitempty"`
+}
+
+const categoryColumns = `
+	c.categories_id, c.parent_id, c.code, c.name, COALESCE(c.description, ''),
+	c.default_min_stock, COALESCE(c.gl_account, ''), c.created_at, c.updated_at,
+	(
+		WITH RECURSIVE ancestors AS (
+			SELECT categories_id, parent_id, code, 0 AS depth FROM categories WHERE categories_id = c.categories_id
+			UNION ALL
+			SELECT p.categories_id, p.parent_id, p.code, a.depth + 1
+			FROM categories p
+			JOIN ancestors a ON p.categories_id = a.parent_id
+		)
+		SELECT string_agg(code, '/' ORDER BY depth DESC) FROM ancestors
+	)
+`
+
+// categoryTree lists the category matched by id or code followed by all of
+// its descendants. It expects the reference in the placeholder given.
+const categoryTree = `
+	WITH RECURSIVE tree AS (
+		SELECT categories_id, code FROM categories WHERE categories_id::text = $%[1]d OR code = LOWER(TRIM($%[1]d))
+		UNION ALL
+		SELECT c.categories_id, c.code
+		FROM categories c
+		JOIN tree t ON c.parent_id = t.categories_id
+	)
+`
+
+type categoryRef struct {
+	Id              string
+	Code            string
+	DefaultMinStock int
+}
+
+func scanCategory(row pgx.Row, category *CategoryResponse) error {
+	return row.Scan(
+		&category.CategoriesId,
+		&category.ParentId,
+		&category.Code,
+		&category.Name,
+		&category.Description,
+		&category.DefaultMinStock,
+		&category.GlAccount,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+		&category.Path,
+	)
+}
+
+// resolveCategory looks a category up by ID or by code.
+func resolveCategory(ctx context.Context, q rowQuerier, ref string) (categoryRef, bool) {
+	var category categoryRef
+	err := q.QueryRow(ctx, `
+		SELECT categories_id, code, default_min_stock
+		FROM categories
+		WHERE categories_id::text = $1 OR code = LOWER(TRIM($1))
+	`, ref).Scan(&category.Id, &category.Code, &category.DefaultMinStock)
+	return category, err == nil
+}
+
+// withCategoryFilter narrows a list query to a category and its descendants.
+// Rows whose category_id has not been mapped yet are matched by their
+// free-text code.
+func withCategoryFilter(whereClause string, args []interface{}, category, idColumn, codeColumn string) (string, []interface{}) {
+	args = append(args, category)
+	condition := fmt.Sprintf(categoryTree, len(args)) + fmt.Sprintf(
+		"SELECT 1 FROM tree WHERE tree.categories_id = %s OR (%s IS NULL AND tree.code = LOWER(TRIM(%s)))",
+		idColumn, idColumn, codeColumn,
+	)
+	condition = "EXISTS (" + condition + ")"
+
+	if whereClause == "" {
+		return "WHERE " + condition, args
+	}
+	return whereClause + " AND " + condition, args
+}
+
+func GetCategories(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+	if c.Query("sort") == "" {
+		params.Sort = "code"
+		params.SortDir = "ASC"
+	}
+
+	searchFields := []string{"c.code", "c.name", "c.description"}
+	filterFields := map[string]string{
+		"parent_id":  "c.parent_id::text",
+		"gl_account": "c.gl_account",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	if c.Query("root") == "true" {
+		if whereClause == "" {
+			whereClause = "WHERE c.parent_id IS NULL"
+		} else {
+			whereClause += " AND c.parent_id IS NULL"
+		}
+	}
+	orderClause := query.BuildOrderClause(params, "code")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := "SELECT COUNT(*) FROM categories c " + whereClause
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get categories count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count categories",
+		})
+	}
+
+	fullQuery := "SELECT " + categoryColumns + " FROM categories c " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get categories query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch categories",
+		})
+	}
+	defer rows.Close()
+
+	var categories []CategoryResponse
+	for rows.Next() {
+		var category CategoryResponse
+		if err := scanCategory(rows, &category); err != nil {
+			errors.LogError("Category scan error", err)
+			continue
+		}
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process categories",
+		})
+	}
+
+	response := query.NewPaginatedResponse(categories, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+// GetCategoryTree returns every category nested under its parent.
+func GetCategoryTree(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := database.DB.Query(ctx, "SELECT "+categoryColumns+" FROM categories c ORDER BY c.code")
+	if err != nil {
+		errors.LogError("Get category tree query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch categories",
+		})
+	}
+	defer rows.Close()
+
+	all := []*CategoryResponse{}
+	byId := map[string]*CategoryResponse{}
+	for rows.Next() {
+		category := &CategoryResponse{}
+		if err := scanCategory(rows, category); err != nil {
+			errors.LogError("Category scan error", err)
+			continue
+		}
+		all = append(all, category)
+		byId[category.CategoriesId] = category
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process categories",
+		})
+	}
+
+	roots := []*CategoryResponse{}
+	for _, category := range all {
+		if category.ParentId != nil {
+			if parent, ok := byId[*category.ParentId]; ok {
+				parent.Children = append(parent.Children, category)
+				continue
+			}
+		}
+		roots = append(roots, category)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  roots,
+		"count": len(all),
+	})
+}
+
+func GetCategoryById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var category CategoryResponse
+	err := scanCategory(database.DB.QueryRow(ctx, "SELECT "+categoryColumns+" FROM categories c WHERE c.categories_id = $1", id), &category)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category not found",
+		})
+	}
+
+	rows, err := database.DB.Query(ctx, "SELECT "+categoryColumns+" FROM categories c WHERE c.parent_id = $1 ORDER BY c.code", id)
+	if err != nil {
+		errors.LogError("Get category children query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch child categories",
+		})
+	}
+	defer rows.Close()
+
+	category.Children = []*CategoryResponse{}
+	for rows.Next() {
+		child := &CategoryResponse{}
+		if err := scanCategory(rows, child); err != nil {
+			errors.LogError("Category scan error", err)
+			continue
+		}
+		category.Children = append(category.Children, child)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  category,
+	})
+}
+
+func CreateCategory(c *fiber.Ctx) error {
+	var req CreateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Code = strings.ToLower(strings.TrimSpace(req.Code))
+	if req.Code == "" || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Code and name are required",
+		})
+	}
+
+	if req.DefaultMinStock < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Default min stock cannot be negative",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT categories_id FROM categories WHERE code = $1", req.Code).Scan(&existing)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category code already exists",
+		})
+	}
+
+	if req.ParentId != nil && *req.ParentId == "" {
+		req.ParentId = nil
+	}
+	if req.ParentId != nil {
+		err = database.DB.QueryRow(ctx, "SELECT categories_id FROM categories WHERE categories_id = $1", *req.ParentId).Scan(&existing)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Parent category not found",
+			})
+		}
+	}
+
+	var id string
+	now := time.Now()
+	err = database.DB.QueryRow(ctx, `
+		INSERT INTO categories (parent_id, code, name, description, default_min_stock, gl_account, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING categories_id
+	`, req.ParentId, req.Code, req.Name, req.Description, req.DefaultMinStock, req.GlAccount, now).Scan(&id)
+	if err != nil {
+		errors.LogError("Category creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create category",
+		})
+	}
+
+	var category CategoryResponse
+	err = scanCategory(database.DB.QueryRow(ctx, "SELECT "+categoryColumns+" FROM categories c WHERE c.categories_id = $1", id), &category)
+	if err != nil {
+		errors.LogError("Get category query error", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Category created successfully",
+		"data":    category,
+	})
+}
+
+func UpdateCategory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category ID is required",
+		})
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var existing string
+	err = tx.QueryRow(ctx, "SELECT categories_id FROM categories WHERE categories_id = $1 FOR UPDATE", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category not found",
+		})
+	}
+
+	updateFields := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	if req.ParentId != nil {
+		var parentId *string
+		if *req.ParentId != "" {
+			parentId = req.ParentId
+
+			// The new parent may not be the category itself or one of its
+			// descendants, or the tree would loop.
+			var inSubtree bool
+			err = tx.QueryRow(ctx, fmt.Sprintf(categoryTree, 1)+"SELECT EXISTS (SELECT 1 FROM tree WHERE categories_id::text = $2)", id, *parentId).Scan(&inSubtree)
+			if err != nil {
+				errors.LogError("Category tree query error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to update category",
+				})
+			}
+			if inSubtree {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "A category cannot be moved under itself or one of its descendants",
+				})
+			}
+
+			err = tx.QueryRow(ctx, "SELECT categories_id FROM categories WHERE categories_id = $1", *parentId).Scan(&existing)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Parent category not found",
+				})
+			}
+		}
+		updateFields = append(updateFields, fmt.Sprintf("parent_id = $%d", argPos))
+		args = append(args, parentId)
+		argPos++
+	}
+
+	var code string
+	if req.Code != nil {
+		code = strings.ToLower(strings.TrimSpace(*req.Code))
+		if code == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Code cannot be empty",
+			})
+		}
+
+		var duplicate string
+		err = tx.QueryRow(ctx, "SELECT categories_id FROM categories WHERE code = $1 AND categories_id <> $2", code, id).Scan(&duplicate)
+		if err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Category code already exists",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("code = $%d", argPos))
+		args = append(args, code)
+		argPos++
+	}
+
+	if req.Name != nil {
+		updateFields = append(updateFields, fmt.Sprintf("name = $%d", argPos))
+		args = append(args, *req.Name)
+		argPos++
+	}
+
+	if req.Description != nil {
+		updateFields = append(updateFields, fmt.Sprintf("description = $%d", argPos))
+		args = append(args, *req.Description)
+		argPos++
+	}
+
+	if req.DefaultMinStock != nil {
+		if *req.DefaultMinStock < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Default min stock cannot be negative",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("default_min_stock = $%d", argPos))
+		args = append(args, *req.DefaultMinStock)
+		argPos++
+	}
+
+	if req.GlAccount != nil {
+		updateFields = append(updateFields, fmt.Sprintf("gl_account = $%d", argPos))
+		args = append(args, *req.GlAccount)
+		argPos++
+	}
+
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	updateFields = append(updateFields, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	args = append(args, id)
+	updateQuery := fmt.Sprintf(`
+		UPDATE categories
+		SET %s
+		WHERE categories_id = $%d
+	`, strings.Join(updateFields, ", "), argPos)
+
+	if _, err = tx.Exec(ctx, updateQuery, args...); err != nil {
+		errors.LogError("Category update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update category",
+		})
+	}
+
+	// Keep the free-text codes on items and suppliers in step.
+	if code != "" {
+		if _, err = tx.Exec(ctx, "UPDATE items SET category = $1 WHERE category_id = $2", code, id); err == nil {
+			_, err = tx.Exec(ctx, "UPDATE suppliers SET supplier_type = $1 WHERE category_id = $2", code, id)
+		}
+		if err != nil {
+			errors.LogError("Category code sync error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update category",
+			})
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	var category CategoryResponse
+	err = scanCategory(database.DB.QueryRow(ctx, "SELECT "+categoryColumns+" FROM categories c WHERE c.categories_id = $1", id), &category)
+	if err != nil {
+		errors.LogError("Get category query error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Category updated successfully",
+		"data":    category,
+	})
+}
+
+func DeleteCategory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT categories_id FROM categories WHERE categories_id = $1", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category not found",
+		})
+	}
+
+	var children int
+	err = database.DB.QueryRow(ctx, "SELECT COUNT(*) FROM categories WHERE parent_id = $1", id).Scan(&children)
+	if err == nil && children > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category has child categories and cannot be deleted",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM categories WHERE categories_id = $1", id)
+	if err != nil {
+		errors.LogError("Category deletion error", err)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Category is used by items or suppliers and cannot be deleted",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Category deleted successfully",
+	})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ExpiringDocumentResponse struct {
+	VehicleDocumentsId string    `json:"vehicle_documents_id"`
+	VehicleId          string    `json:"vehicle_id"`
+	PlateNumber        string    `json:"plate_number"`
+	VehicleName        string    `json:"vehicle_name"`
+	DocumentType       string    `json:"document_type"`
+	DocumentNumber     string    `json:"document_number"`
+	ExpiryDate         time.Time `json:"expiry_date"`
+	DaysRemaining      int       `json:"days_remaining"`
+	Status             string    `json:"status"`
+	Blocking           bool      `json:"blocking"`
+}
+
+// today returns the current calendar date at UTC midnight, matching how
+// document dates are parsed from YYYY-MM-DD.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// blockingDocumentTypes returns the document types that prevent a vehicle
+// from being assigned to a trip once expired.
+func blockingDocumentTypes() []string {
+	types := []string{}
+	for _, t := range strings.Split(config.AppConfig.Compliance.BlockingDocumentTypes, ",") {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// expiredBlockingDocuments lists the blocking document types that the
+// vehicle has no document of, marked "(missing)", or whose latest expiry
+// date is already in the past.
+func expiredBlockingDocuments(ctx context.Context, vehicleId string) ([]string, error) {
+	types := blockingDocumentTypes()
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT t.document_type, MAX(d.expiry_date) IS NULL
+		FROM UNNEST($2::text[]) AS t(document_type)
+		LEFT JOIN vehicle_documents d ON d.vehicle_id = $1 AND d.document_type = t.document_type
+		GROUP BY t.document_type
+		HAVING MAX(d.expiry_date) IS NULL OR MAX(d.expiry_date) < $3
+		ORDER BY t.document_type
+	`
+
+	rows, err := database.DB.Query(ctx, query, vehicleId, types, today())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expired []string
+	for rows.Next() {
+		var documentType string
+		var missing bool
+		if err := rows.Scan(&documentType, &missing); err != nil {
+			return nil, err
+		}
+		if missing {
+			documentType += " (missing)"
+		}
+		expired = append(expired, documentType)
+	}
+
+	return expired, rows.Err()
+}
+
+func GetExpiringDocuments(c *fiber.Ctx) error {
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "days must be a non-negative number",
+			})
+		}
+		days = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := today()
+	until := start.AddDate(0, 0, days)
+
+	args := []interface{}{until}
+	query := `
+		SELECT d.vehicle_documents_id, d.vehicle_id, v.plate_number, v.name, d.document_type, d.document_number, d.expiry_date
+		FROM vehicle_documents d
+		JOIN vehicles v ON d.vehicle_id = v.vehicles_id
+		WHERE d.expiry_date <= $1
+		AND d.expiry_date = (
+			SELECT MAX(expiry_date)
+			FROM vehicle_documents
+			WHERE vehicle_id = d.vehicle_id AND document_type = d.document_type
+		)
+	`
+	if documentType := c.Query("document_type"); documentType != "" {
+		args = append(args, strings.ToUpper(documentType))
+		query += " AND d.document_type = $2"
+	}
+	query += " ORDER BY d.expiry_date"
+
+	rows, err := database.DB.Query(ctx, query, args...)
+	if err != nil {
+		errors.LogError("Get expiring documents query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch expiring documents",
+		})
+	}
+	defer rows.Close()
+
+	blocking := map[string]bool{}
+	for _, t := range blockingDocumentTypes() {
+		blocking[t] = true
+	}
+
+	documents := []ExpiringDocumentResponse{}
+	expiredCount := 0
+	for rows.Next() {
+		var d ExpiringDocumentResponse
+		err := rows.Scan(
+			&d.VehicleDocumentsId,
+			&d.VehicleId,
+			&d.PlateNumber,
+			&d.VehicleName,
+			&d.DocumentType,
+			&d.DocumentNumber,
+			&d.ExpiryDate,
+		)
+		if err != nil {
+			errors.LogError("Expiring document scan error", err)
+			continue
+		}
+
+		y, m, day := d.ExpiryDate.UTC().Date()
+		d.DaysRemaining = int(time.Date(y, m, day, 0, 0, 0, 0, time.UTC).Sub(start).Hours() / 24)
+		d.Status = "expiring"
+		if d.DaysRemaining < 0 {
+			d.Status = "expired"
+			d.Blocking = blocking[d.DocumentType]
+			expiredCount++
+		}
+		documents = append(documents, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process expiring documents",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":         false,
+		"data":          documents,
+		"count":         len(documents),
+		"expired_count": expiredCount,
+		"days":          days,
+	})
+}
@@ -0,0 +1,518 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateDepartmentRequest struct {
+	Code       string `json:"code" validate:"required"`
+	Name       string `json:"name" validate:"required"`
+	CostCenter string `json:"cost_center"`
+}
+
+type UpdateDepartmentRequest struct {
+	Code       *string `json:"code"`
+	Name       *string `json:"name"`
+	CostCenter *string `json:"cost_center"`
+	IsActive   *bool   `json:"is_active"`
+}
+
+type SetUserDepartmentsRequest struct {
+	DepartmentIds []string `json:"department_ids"`
+}
+
+const departmentColumns = "departments_id, code, name, COALESCE(cost_center, ''), is_active, created_at, updated_at"
+
+func scanDepartment(row interface{ Scan(...any) error }, department *models.Departments) error {
+	return row.Scan(
+		&department.DepartmentsId,
+		&department.Code,
+		&department.Name,
+		&department.CostCenter,
+		&department.IsActive,
+		&department.CreatedAt,
+		&department.UpdatedAt,
+	)
+}
+
+// resolveDepartment validates the department a purchasing is filed under and
+// returns the status to answer with when it is refused. Callers limited to
+// their own departments may only pick one of those, and default to it when
+// they belong to exactly one.
+func resolveDepartment(ctx context.Context, q rowQuerier, scope query.Scope, departmentId string) (*string, int, string) {
+	if departmentId == "" {
+		switch {
+		case scope.Global:
+			return nil, 0, ""
+		case len(scope.Departments) == 1:
+			id := scope.Departments[0]
+			return &id, 0, ""
+		case len(scope.Departments) == 0:
+			return nil, fiber.StatusForbidden, "You are not assigned to a department"
+		}
+		return nil, fiber.StatusBadRequest, "department_id is required when you belong to several departments"
+	}
+
+	if !scope.Global && !slices.Contains(scope.Departments, departmentId) {
+		return nil, fiber.StatusForbidden, "Department is outside your scope"
+	}
+
+	var isActive bool
+	err := q.QueryRow(ctx, "SELECT is_active FROM departments WHERE departments_id = $1", departmentId).Scan(&isActive)
+	if err != nil {
+		return nil, fiber.StatusBadRequest, "Department not found"
+	}
+	if !isActive {
+		return nil, fiber.StatusBadRequest, "Department is inactive"
+	}
+	return &departmentId, 0, ""
+}
+
+func GetDepartments(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"code", "name", "cost_center"}
+	filterFields := map[string]string{
+		"is_active":   "is_active",
+		"cost_center": "cost_center",
+	}
+
+	whereClause, whereArgs := query.BuildScopedWhereClause(params, searchFields, filterFields, "departments", "")
+	orderClause := query.BuildOrderClause(params, "code")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("departments", whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get departments count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count departments",
+		})
+	}
+
+	fullQuery := "SELECT " + departmentColumns + " FROM departments " + whereClause + " " + orderClause + " " + paginationClause
+
+	rows, err := database.DB.Query(ctx, fullQuery, append(whereArgs, paginationArgs...)...)
+	if err != nil {
+		errors.LogError("Get departments query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch departments",
+		})
+	}
+	defer rows.Close()
+
+	departments := []models.Departments{}
+	for rows.Next() {
+		var department models.Departments
+		if err := scanDepartment(rows, &department); err != nil {
+			errors.LogError("Department scan error", err)
+			continue
+		}
+		departments = append(departments, department)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process departments",
+		})
+	}
+
+	response := query.NewPaginatedResponse(departments, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetDepartmentById(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	whereClause, whereArgs := query.ScopeTable("WHERE departments_id = $1", []interface{}{id}, query.ScopeFrom(c), "departments", "")
+
+	var department models.Departments
+	err := scanDepartment(database.DB.QueryRow(ctx, "SELECT "+departmentColumns+" FROM departments "+whereClause, whereArgs...), &department)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Department not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  department,
+	})
+}
+
+func CreateDepartment(c *fiber.Ctx) error {
+	var req CreateDepartmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	if req.Code == "" || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Department code and name are required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT departments_id FROM departments WHERE code = $1", req.Code).Scan(&existing)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Department code already exists",
+		})
+	}
+
+	var department models.Departments
+	err = scanDepartment(database.DB.QueryRow(ctx, `
+		INSERT INTO departments (code, name, cost_center, is_active, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), true, $4, $4)
+		RETURNING `+departmentColumns,
+		req.Code, req.Name, strings.TrimSpace(req.CostCenter), time.Now(),
+	), &department)
+	if err != nil {
+		errors.LogError("Department creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create department",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Department created successfully",
+		"data":    department,
+	})
+}
+
+func UpdateDepartment(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req UpdateDepartmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT departments_id FROM departments WHERE departments_id = $1", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Department not found",
+		})
+	}
+
+	updateFields := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	if req.Code != nil {
+		code := strings.ToUpper(strings.TrimSpace(*req.Code))
+		var duplicate string
+		err = database.DB.QueryRow(ctx, "SELECT departments_id FROM departments WHERE code = $1 AND departments_id <> $2", code, id).Scan(&duplicate)
+		if err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Department code already exists",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("code = $%d", argPos))
+		args = append(args, code)
+		argPos++
+	}
+
+	if req.Name != nil {
+		updateFields = append(updateFields, fmt.Sprintf("name = $%d", argPos))
+		args = append(args, *req.Name)
+		argPos++
+	}
+
+	if req.CostCenter != nil {
+		updateFields = append(updateFields, fmt.Sprintf("cost_center = NULLIF($%d, '')", argPos))
+		args = append(args, strings.TrimSpace(*req.CostCenter))
+		argPos++
+	}
+
+	if req.IsActive != nil {
+		updateFields = append(updateFields, fmt.Sprintf("is_active = $%d", argPos))
+		args = append(args, *req.IsActive)
+		argPos++
+	}
+
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	updateFields = append(updateFields, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	args = append(args, id)
+	updateQuery := fmt.Sprintf(`
+		UPDATE departments
+		SET %s
+		WHERE departments_id = $%d
+		RETURNING %s
+	`, strings.Join(updateFields, ", "), argPos, departmentColumns)
+
+	var department models.Departments
+	if err = scanDepartment(database.DB.QueryRow(ctx, updateQuery, args...), &department); err != nil {
+		errors.LogError("Department update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update department",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Department updated successfully",
+		"data":    department,
+	})
+}
+
+// DeleteDepartment removes a department that no purchasing is filed under.
+// Its user assignments go with it.
+func DeleteDepartment(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT departments_id FROM departments WHERE departments_id = $1", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Department not found",
+		})
+	}
+
+	var inUse bool
+	err = database.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM purchasings WHERE department_id = $1)", id).Scan(&inUse)
+	if err != nil {
+		errors.LogError("Department usage check error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete department",
+		})
+	}
+
+	if inUse {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Department has purchasings; deactivate it instead",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM departments WHERE departments_id = $1", id)
+	if err != nil {
+		errors.LogError("Department deletion error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete department",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Department deleted successfully",
+	})
+}
+
+func loadUserDepartments(ctx context.Context, userId string) ([]models.Departments, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT d.departments_id, d.code, d.name, COALESCE(d.cost_center, ''), d.is_active, d.created_at, d.updated_at
+		FROM user_departments ud
+		JOIN departments d ON ud.department_id = d.departments_id
+		WHERE ud.user_id = $1
+		ORDER BY d.code
+	`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	departments := []models.Departments{}
+	for rows.Next() {
+		var department models.Departments
+		if err := scanDepartment(rows, &department); err != nil {
+			return nil, err
+		}
+		departments = append(departments, department)
+	}
+	return departments, rows.Err()
+}
+
+// GetUserDepartments lists the departments a user is assigned to.
+func GetUserDepartments(c *fiber.Ctx) error {
+	username := c.Params("uname")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	whereClause, whereArgs := query.ScopeTable("WHERE username = $1", []interface{}{username}, query.ScopeFrom(c), "users", "")
+
+	var userId string
+	err := database.DB.QueryRow(ctx, "SELECT users_id FROM users "+whereClause, whereArgs...).Scan(&userId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	departments, err := loadUserDepartments(ctx, userId)
+	if err != nil {
+		errors.LogError("Get user departments error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch user departments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  departments,
+	})
+}
+
+// SetUserDepartments replaces the departments a user is assigned to.
+func SetUserDepartments(c *fiber.Ctx) error {
+	username := c.Params("uname")
+
+	var req SetUserDepartmentsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update user departments",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var userId string
+	err = tx.QueryRow(ctx, "SELECT users_id FROM users WHERE username = $1", username).Scan(&userId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	for _, departmentId := range req.DepartmentIds {
+		var isActive bool
+		err = tx.QueryRow(ctx, "SELECT is_active FROM departments WHERE departments_id = $1", departmentId).Scan(&isActive)
+		if err != nil || !isActive {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Department %s not found or inactive", departmentId),
+			})
+		}
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM user_departments WHERE user_id = $1", userId); err != nil {
+		errors.LogError("User departments reset error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update user departments",
+		})
+	}
+
+	now := time.Now()
+	for _, departmentId := range req.DepartmentIds {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO user_departments (user_id, department_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $3)
+			ON CONFLICT (user_id, department_id) DO NOTHING
+		`, userId, departmentId, now)
+		if err != nil {
+			errors.LogError("User department assignment error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update user departments",
+			})
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update user departments",
+		})
+	}
+
+	departments, err := loadUserDepartments(ctx, userId)
+	if err != nil {
+		errors.LogError("Get user departments error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch user departments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "User departments updated successfully",
+		"data":    departments,
+	})
+}
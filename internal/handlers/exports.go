@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/spreadsheet"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	exportTimeout    = 2 * time.Minute
+	exportFlushEvery = 500
+	exportMaxRows    = 100000
+)
+
+// exportFormat returns the spreadsheet format requested with format=csv|xlsx,
+// or "" for the normal JSON response.
+func exportFormat(c *fiber.Ctx) string {
+	switch strings.ToLower(c.Query("format")) {
+	case spreadsheet.CSV:
+		return spreadsheet.CSV
+	case spreadsheet.XLSX:
+		return spreadsheet.XLSX
+	}
+	return ""
+}
+
+// streamExport sends every row of sqlQuery as a CSV or XLSX download. The
+// column names of the query become the header row. Rows are written while
+// they are read from the database, so list exports are not capped by
+// pagination. Exports of more than exportMaxRows rows are refused before
+// anything is sent, so a download is never cut short silently.
+func streamExport(c *fiber.Ctx, format, name, sqlQuery string, args []interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+
+	var total int
+	err := database.DB.QueryRow(ctx, "SELECT COUNT(*) FROM ("+sqlQuery+") export", args...).Scan(&total)
+	if err != nil {
+		cancel()
+		errors.LogError("Export "+name+" count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to export " + name,
+		})
+	}
+	if total > exportMaxRows {
+		cancel()
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Export has %d rows; narrow the filters to at most %d", total, exportMaxRows),
+		})
+	}
+
+	rows, err := database.DB.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		cancel()
+		errors.LogError("Export "+name+" query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to export " + name,
+		})
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentType, spreadsheet.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer rows.Close()
+
+		header := []string{}
+		for _, field := range rows.FieldDescriptions() {
+			header = append(header, field.Name)
+		}
+
+		writer, err := spreadsheet.NewWriter(format, w, header)
+		if err != nil {
+			errors.LogError("Export "+name+" writer error", err)
+			return
+		}
+
+		count := 0
+		for rows.Next() {
+			values, err := rows.Values()
+			if err != nil {
+				errors.LogError("Export "+name+" row error", err)
+				return
+			}
+			for i, value := range values {
+				values[i] = exportValue(value)
+			}
+			if err := writer.WriteRow(values); err != nil {
+				errors.LogError("Export "+name+" write error", err)
+				return
+			}
+
+			count++
+			if count%exportFlushEvery == 0 {
+				if err := writer.Flush(); err != nil {
+					errors.LogError("Export "+name+" write error", err)
+					return
+				}
+				if err := w.Flush(); err != nil {
+					// The client went away.
+					return
+				}
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			errors.LogError("Export "+name+" rows iteration error", err)
+			return
+		}
+		if err := writer.Close(); err != nil {
+			errors.LogError("Export "+name+" write error", err)
+		}
+	})
+
+	return nil
+}
+
+// exportValue turns pgx's generic decoded values into plain cell values.
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case pgtype.Numeric:
+		f, err := v.Float64Value()
+		if err != nil || !f.Valid {
+			return nil
+		}
+		return f.Float64
+	}
+	return value
+}
@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type StockLineRequest struct {
+	ItemId string `json:"item_id" validate:"required"`
+	// UnitId is honoured on goods receipts only; other documents count in
+	// the stock unit.
+	UnitId        string   `json:"unit_id"`
+	Qty           int      `json:"qty" validate:"required,gt=0"`
+	LotNumber     string   `json:"lot_number"`
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+type CreateGoodsReceiptRequest struct {
+	PurchasingId string             `json:"purchasing_id" validate:"required"`
+	WarehouseId  string             `json:"warehouse_id"`
+	Date         string             `json:"date" validate:"required"`
+	Notes        string             `json:"notes"`
+	Details      []StockLineRequest `json:"details" validate:"required,min=1"`
+}
+
+type GoodsReceiptDetailResponse struct {
+	models.GoodsReceiptDetails
+	ItemName      string   `json:"item_name"`
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+type GoodsReceiptResponse struct {
+	models.GoodsReceipts
+	SupplierName  string                       `json:"supplier_name"`
+	WarehouseName string                       `json:"warehouse_name"`
+	UserName      string                       `json:"user_name"`
+	Details       []GoodsReceiptDetailResponse `json:"details"`
+}
+
+// validateStockLine checks a receipt or issue line against the item's
+// tracking flags and normalizes its serial and lot numbers in place.
+func validateStockLine(line *StockLineRequest, itemName string, trackSerial, trackLot bool) string {
+	if line.Qty <= 0 {
+		return fmt.Sprintf("Quantity for item %s must be greater than 0", itemName)
+	}
+
+	line.LotNumber = strings.TrimSpace(line.LotNumber)
+	if trackLot && line.LotNumber == "" {
+		return fmt.Sprintf("Lot number is required for item %s", itemName)
+	}
+
+	if !trackSerial {
+		if len(line.SerialNumbers) > 0 {
+			return fmt.Sprintf("Item %s is not serial tracked", itemName)
+		}
+		return ""
+	}
+
+	if len(line.SerialNumbers) != line.Qty {
+		return fmt.Sprintf("Item %s needs exactly %d serial number(s)", itemName, line.Qty)
+	}
+
+	seen := map[string]bool{}
+	for i, serial := range line.SerialNumbers {
+		serial = strings.ToUpper(strings.TrimSpace(serial))
+		if serial == "" || seen[serial] {
+			return fmt.Sprintf("Serial numbers for item %s must be unique and not empty", itemName)
+		}
+		seen[serial] = true
+		line.SerialNumbers[i] = serial
+	}
+
+	return ""
+}
+
+func fetchGoodsReceipt(ctx context.Context, id string, scope query.Scope) (GoodsReceiptResponse, error) {
+	var receipt GoodsReceiptResponse
+	whereClause, whereArgs := query.ScopeTable("WHERE r.goods_receipts_id = $1", []interface{}{id}, scope, "goods_receipts", "r")
+	query := `
+		SELECT r.goods_receipts_id, r.purchasing_id, r.warehouse_id, r.date, r.user_id, r.notes, r.created_at, r.updated_at,
+		       COALESCE(s.name, ''), COALESCE(w.name, ''), COALESCE(u.full_name, '')
+		FROM goods_receipts r
+		LEFT JOIN purchasings p ON r.purchasing_id = p.purchasings_id
+		LEFT JOIN suppliers s ON p.supplier_id = s.suppliers_id
+		LEFT JOIN warehouses w ON r.warehouse_id = w.warehouses_id
+		LEFT JOIN users u ON r.user_id = u.users_id
+	` + whereClause
+	err := database.DB.QueryRow(ctx, query, whereArgs...).Scan(
+		&receipt.GoodsReceiptsId,
+		&receipt.PurchasingId,
+		&receipt.WarehouseId,
+		&receipt.Date,
+		&receipt.UserId,
+		&receipt.Notes,
+		&receipt.CreatedAt,
+		&receipt.UpdatedAt,
+		&receipt.SupplierName,
+		&receipt.WarehouseName,
+		&receipt.UserName,
+	)
+	if err != nil {
+		return receipt, err
+	}
+
+	detailsQuery := `
+		SELECT d.goods_receipt_details_id, d.goods_receipt_id, d.item_id, d.unit_id, d.unit_qty, d.factor, d.qty, d.unit_cost, d.lot_number, COALESCE(i.name, ''),
+		       COALESCE(ARRAY(
+		           SELECT serial_number FROM item_serials
+		           WHERE goods_receipt_id = d.goods_receipt_id AND item_id = d.item_id
+		           ORDER BY serial_number
+		       ), '{}')
+		FROM goods_receipt_details d
+		LEFT JOIN items i ON d.item_id = i.items_id
+		WHERE d.goods_receipt_id = $1
+	`
+	rows, err := database.DB.Query(ctx, detailsQuery, id)
+	if err != nil {
+		return receipt, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail GoodsReceiptDetailResponse
+		err := rows.Scan(
+			&detail.GoodsReceiptDetailsId,
+			&detail.GoodsReceiptId,
+			&detail.ItemId,
+			&detail.UnitId,
+			&detail.UnitQty,
+			&detail.Factor,
+			&detail.Qty,
+			&detail.UnitCost,
+			&detail.LotNumber,
+			&detail.ItemName,
+			&detail.SerialNumbers,
+		)
+		if err != nil {
+			errors.LogError("Goods receipt detail scan error", err)
+			continue
+		}
+		receipt.Details = append(receipt.Details, detail)
+	}
+
+	return receipt, rows.Err()
+}
+
+func GetGoodsReceipts(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"r.notes", "s.name", "u.full_name"}
+	filterFields := map[string]string{
+		"purchasing_id": "r.purchasing_id",
+		"warehouse_id":  "r.warehouse_id",
+		"user_id":       "r.user_id",
+	}
+
+	whereClause, whereArgs := query.BuildScopedWhereClause(params, searchFields, filterFields, "goods_receipts", "r")
+	orderClause := query.BuildOrderClause(params, "r.date")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	fromClause := `
+		FROM goods_receipts r
+		LEFT JOIN purchasings p ON r.purchasing_id = p.purchasings_id
+		LEFT JOIN suppliers s ON p.supplier_id = s.suppliers_id
+		LEFT JOIN warehouses w ON r.warehouse_id = w.warehouses_id
+		LEFT JOIN users u ON r.user_id = u.users_id
+	`
+
+	countQuery := "SELECT COUNT(*) " + fromClause + " " + whereClause
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get goods receipts count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count goods receipts",
+		})
+	}
+
+	baseQuery := `
+		SELECT r.goods_receipts_id, r.purchasing_id, r.warehouse_id, r.date, r.user_id, r.notes, r.created_at, r.updated_at,
+		       COALESCE(s.name, ''), COALESCE(w.name, ''), COALESCE(u.full_name, '')
+	` + fromClause
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get goods receipts query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch goods receipts",
+		})
+	}
+	defer rows.Close()
+
+	var receipts []GoodsReceiptResponse
+	for rows.Next() {
+		var r GoodsReceiptResponse
+		err := rows.Scan(
+			&r.GoodsReceiptsId,
+			&r.PurchasingId,
+			&r.WarehouseId,
+			&r.Date,
+			&r.UserId,
+			&r.Notes,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+			&r.SupplierName,
+			&r.WarehouseName,
+			&r.UserName,
+		)
+		if err != nil {
+			errors.LogError("Goods receipt scan error", err)
+			continue
+		}
+		receipts = append(receipts, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process goods receipts",
+		})
+	}
+
+	response := query.NewPaginatedResponse(receipts, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetGoodsReceiptById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Goods receipt ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	receipt, err := fetchGoodsReceipt(ctx, id, query.ScopeFrom(c))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Goods receipt not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  receipt,
+	})
+}
+
+// postGoodsReceipt records a receipt against an approved purchasing and
+// posts its lines to stock: serial numbers, cost layers, warehouse stock and
+// items.stock. Receipts never exceed what is still open on the purchasing.
+// A non-zero status is the response to send instead.
+func postGoodsReceipt(ctx context.Context, tx pgx.Tx, scope query.Scope, userId string, req *CreateGoodsReceiptRequest, receiptDate time.Time) (string, int, string) {
+	scopeClause, scopeArgs := query.ScopeTable("WHERE p.purchasings_id = $1", []interface{}{req.PurchasingId}, scope, "purchasings", "p")
+
+	var deliveryWarehouseId, purchasingStatus string
+	err := tx.QueryRow(ctx, "SELECT p.warehouse_id, COALESCE(p.status, '') FROM purchasings p "+scopeClause+" FOR UPDATE", scopeArgs...).Scan(&deliveryWarehouseId, &purchasingStatus)
+	if err != nil {
+		return "", fiber.StatusBadRequest, "Purchasing not found"
+	}
+
+	// Only goods that were ordered with an approved purchasing are received.
+	if !strings.EqualFold(purchasingStatus, "approved") {
+		return "", fiber.StatusConflict, "Purchasing has to be approved before goods are received"
+	}
+
+	if req.WarehouseId == "" {
+		req.WarehouseId = deliveryWarehouseId
+	}
+	warehouseId, message := resolveWarehouse(ctx, tx, req.WarehouseId)
+	if message != "" {
+		return "", fiber.StatusBadRequest, message
+	}
+
+	now := time.Now()
+	var receiptId string
+	insertQuery := `
+		INSERT INTO goods_receipts (purchasing_id, warehouse_id, date, user_id, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING goods_receipts_id
+	`
+	err = tx.QueryRow(ctx, insertQuery, req.PurchasingId, warehouseId, receiptDate, userId, req.Notes, now).Scan(&receiptId)
+	if err != nil {
+		errors.LogError("Goods receipt creation error", err)
+		return "", fiber.StatusInternalServerError, "Failed to create goods receipt"
+	}
+
+	for i := range req.Details {
+		detail := &req.Details[i]
+
+		var itemName string
+		var trackSerial, trackLot bool
+		err = tx.QueryRow(ctx, "SELECT name, track_serial, track_lot FROM items WHERE items_id = $1 FOR UPDATE", detail.ItemId).Scan(&itemName, &trackSerial, &trackLot)
+		if err != nil {
+			return "", fiber.StatusBadRequest, fmt.Sprintf("Item with ID %s not found", detail.ItemId)
+		}
+
+		quantity, message := convertToStockUnits(ctx, tx, detail.ItemId, detail.UnitId, detail.Qty)
+		if message != "" {
+			return "", fiber.StatusBadRequest, message
+		}
+		detail.Qty = quantity.Qty
+
+		if message := validateStockLine(detail, itemName, trackSerial, trackLot); message != "" {
+			return "", fiber.StatusBadRequest, message
+		}
+
+		var orderedQty int
+		var orderedSubtotal float64
+		err = tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(qty), 0), COALESCE(SUM(subtotal), 0)
+			FROM purchasing_details
+			WHERE purchasing_id = $1 AND item_id = $2
+		`, req.PurchasingId, detail.ItemId).Scan(&orderedQty, &orderedSubtotal)
+		if err != nil {
+			errors.LogError("Purchasing detail query error", err)
+			return "", fiber.StatusInternalServerError, "Failed to read purchasing details"
+		}
+
+		if orderedQty == 0 {
+			return "", fiber.StatusBadRequest, fmt.Sprintf("Item %s is not part of this purchasing", itemName)
+		}
+
+		var receivedQty int
+		err = tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(d.qty), 0)
+			FROM goods_receipt_details d
+			JOIN goods_receipts r ON d.goods_receipt_id = r.goods_receipts_id
+			WHERE r.purchasing_id = $1 AND d.item_id = $2
+		`, req.PurchasingId, detail.ItemId).Scan(&receivedQty)
+		if err != nil {
+			errors.LogError("Received quantity query error", err)
+			return "", fiber.StatusInternalServerError, "Failed to read received quantity"
+		}
+
+		if receivedQty+detail.Qty > orderedQty {
+			return "", fiber.StatusBadRequest, fmt.Sprintf("Only %d of item %s remain to be received on this purchasing", orderedQty-receivedQty, itemName)
+		}
+
+		unitCost := orderedSubtotal / float64(orderedQty)
+		_, err = tx.Exec(ctx, `
+			INSERT INTO goods_receipt_details (goods_receipt_id, item_id, unit_id, unit_qty, factor, qty, unit_cost, lot_number)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, receiptId, detail.ItemId, quantity.UnitId, quantity.UnitQty, quantity.Factor, detail.Qty, unitCost, detail.LotNumber)
+		if err != nil {
+			errors.LogError("Goods receipt detail creation error", err)
+			return "", fiber.StatusInternalServerError, "Failed to create goods receipt details"
+		}
+
+		if trackSerial {
+			var duplicate string
+			err = tx.QueryRow(ctx, "SELECT serial_number FROM item_serials WHERE item_id = $1 AND serial_number = ANY($2) LIMIT 1", detail.ItemId, detail.SerialNumbers).Scan(&duplicate)
+			if err == nil {
+				return "", fiber.StatusConflict, fmt.Sprintf("Serial number %s already exists for item %s", duplicate, itemName)
+			}
+
+			for _, serial := range detail.SerialNumbers {
+				_, err = tx.Exec(ctx, `
+					INSERT INTO item_serials (item_id, serial_number, lot_number, status, warehouse_id, goods_receipt_id, stock_issue_id, created_at, updated_at)
+					VALUES ($1, $2, $3, 'in_stock', $4, $5, NULL, $6, $6)
+				`, detail.ItemId, serial, detail.LotNumber, warehouseId, receiptId, now)
+				if err != nil {
+					errors.LogError("Item serial creation error", err)
+					return "", fiber.StatusInternalServerError, "Failed to record serial numbers"
+				}
+			}
+		}
+
+		if err = receiveCost(ctx, tx, detail.ItemId, "goods_receipt", receiptId, detail.Qty, unitCost, now); err != nil {
+			errors.LogError("Cost layer creation error", err)
+			return "", fiber.StatusInternalServerError, "Failed to update item cost"
+		}
+
+		if err = changeWarehouseStock(ctx, tx, detail.ItemId, warehouseId, detail.Qty); err != nil {
+			status, message := warehouseStockStatus(err)
+			return "", status, message
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE items SET stock = stock + $1, updated_at = $2 WHERE items_id = $3", detail.Qty, now, detail.ItemId)
+		if err != nil {
+			errors.LogError("Item stock update error", err)
+			return "", fiber.StatusInternalServerError, "Failed to update item stock"
+		}
+	}
+
+	return receiptId, 0, ""
+}
+
+func CreateGoodsReceipt(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+
+	var req CreateGoodsReceiptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.PurchasingId == "" || len(req.Details) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Purchasing ID and at least one detail item are required",
+		})
+	}
+
+	receiptDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	receiptId, status, message := postGoodsReceipt(ctx, tx, query.ScopeFrom(c), claims.UserID, &req, receiptDate)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	receipt, err := fetchGoodsReceipt(ctx, receiptId, query.ScopeFrom(c))
+	if err != nil {
+		errors.LogError("Goods receipt fetch error", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Goods receipt created successfully",
+		"data":    receipt,
+	})
+}
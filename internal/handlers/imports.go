@@ -0,0 +1,612 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/spreadsheet"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type ImportRowResult struct {
+	Row    int      `json:"row"`
+	Key    string   `json:"key"`
+	Action string   `json:"action"`
+	Id     string   `json:"id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type ImportSummary struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total_rows"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// importRow holds the cells of one data row keyed by column. Only columns
+// present in the file appear; an empty cell leaves the field unchanged on
+// update.
+type importRow struct {
+	Line   int
+	Values map[string]string
+}
+
+func (r importRow) has(column string) bool {
+	return r.Values[column] != ""
+}
+
+const maxImportRows = 5000
+
+// importColumns maps the db and json names of a model's fields to the db
+// column. Skipped columns map to "" so they are accepted but ignored, which
+// lets an exported file be imported again as is.
+func importColumns(model interface{}, skip ...string) map[string]string {
+	skipped := map[string]bool{}
+	for _, column := range skip {
+		skipped[column] = true
+	}
+
+	columns := map[string]string{}
+	t := reflect.TypeOf(model)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		column := strings.Split(field.Tag.Get("db"), ",")[0]
+		if column == "" {
+			continue
+		}
+		target := column
+		if skipped[column] {
+			target = ""
+		}
+		columns[column] = target
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			columns[name] = target
+		}
+	}
+	return columns
+}
+
+func normalizeHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	header = strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+	return header
+}
+
+// parseImportFile reads the uploaded CSV or XLSX file. The first row is the
+// header; unknown columns are rejected so typos do not silently drop data.
+func parseImportFile(c *fiber.Ctx, columns map[string]string, required ...string) ([]importRow, string) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return nil, "A CSV or XLSX file is required in the file field"
+	}
+
+	format := spreadsheet.FormatOf(file.Filename)
+	if format == "" {
+		return nil, "File must be a .csv or .xlsx file"
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		errors.LogError("Import file open error", err)
+		return nil, "Failed to read the uploaded file"
+	}
+	defer reader.Close()
+
+	rows, err := spreadsheet.ReadRows(format, reader)
+	if err != nil {
+		return nil, err.Error()
+	}
+	if len(rows) < 2 {
+		return nil, "File must have a header row and at least one data row"
+	}
+	if len(rows)-1 > maxImportRows {
+		return nil, fmt.Sprintf("At most %d rows can be imported at once", maxImportRows)
+	}
+
+	header := make([]string, len(rows[0]))
+	present := map[string]bool{}
+	for i, name := range rows[0] {
+		if name == "" {
+			continue
+		}
+		column, ok := columns[normalizeHeader(name)]
+		if !ok {
+			return nil, fmt.Sprintf("Unknown column %q", name)
+		}
+		if column == "" {
+			continue
+		}
+		if present[column] {
+			return nil, fmt.Sprintf("Column %q appears more than once", name)
+		}
+		header[i] = column
+		present[column] = true
+	}
+
+	for _, column := range required {
+		if !present[column] {
+			return nil, fmt.Sprintf("Column %q is required", column)
+		}
+	}
+
+	records := []importRow{}
+	for i, row := range rows[1:] {
+		if spreadsheet.IsBlank(row) {
+			continue
+		}
+		record := importRow{Line: i + 2, Values: map[string]string{}}
+		for j, column := range header {
+			if column == "" {
+				continue
+			}
+			value := ""
+			if j < len(row) {
+				value = row[j]
+			}
+			record.Values[column] = value
+		}
+		records = append(records, record)
+	}
+
+	return records, ""
+}
+
+func parseImportBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes", "y":
+		return true, true
+	case "false", "0", "no", "n":
+		return false, true
+	}
+	return false, false
+}
+
+// runImport applies every row inside one transaction, each row under its own
+// savepoint so a failing row does not hide errors in the rows after it. A
+// dry run, or any failed row, rolls the whole import back.
+func runImport(c *fiber.Ctx, entity string, rows []importRow, apply func(ctx context.Context, tx pgx.Tx, row importRow) ImportRowResult) error {
+	dryRun := c.Query("dry_run") == "true" || c.FormValue("dry_run") == "true"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	summary := ImportSummary{DryRun: dryRun, Total: len(rows), Rows: []ImportRowResult{}}
+	for _, row := range rows {
+		rowTx, err := tx.Begin(ctx)
+		if err != nil {
+			errors.LogError("Import savepoint error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to import rows",
+			})
+		}
+
+		result := apply(ctx, rowTx, row)
+		result.Row = row.Line
+		if len(result.Errors) == 0 {
+			err = rowTx.Commit(ctx)
+			if err != nil {
+				result.Errors = append(result.Errors, "Failed to save row")
+				errors.LogError("Import row commit error", err)
+			}
+		} else {
+			rowTx.Rollback(ctx)
+		}
+
+		switch {
+		case len(result.Errors) > 0:
+			summary.Failed++
+		case result.Action == "create":
+			summary.Created++
+		default:
+			summary.Updated++
+		}
+		summary.Rows = append(summary.Rows, result)
+	}
+
+	if dryRun {
+		return c.JSON(fiber.Map{
+			"error":   summary.Failed > 0,
+			"message": "Dry run completed; no changes were saved",
+			"data":    summary,
+		})
+	}
+
+	if summary.Failed > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("%d rows have errors; no changes were saved", summary.Failed),
+			"data":    summary,
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": entity + " imported successfully",
+		"data":    summary,
+	})
+}
+
+// ImportItems upserts items from a CSV or XLSX file whose columns are the
+// fields of models.Items. Rows match existing items by SKU, then by name.
+// Stock is only taken for new items; existing stock moves through receipts,
+// issues and counts.
+func ImportItems(c *fiber.Ctx) error {
+	columns := importColumns(models.Items{}, "items_id", "avg_cost", "unit_id", "purchase_unit_id", "category_id", "created_at", "updated_at")
+	rows, message := parseImportFile(c, columns, "name")
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	userId := ""
+	if claims, ok := c.Locals("user").(*jwt.Claims); ok {
+		userId = claims.UserID
+	}
+
+	seen := map[string]int{}
+	return runImport(c, "Items", rows, func(ctx context.Context, tx pgx.Tx, row importRow) ImportRowResult {
+		return importItemRow(ctx, tx, row, userId, seen)
+	})
+}
+
+func importItemRow(ctx context.Context, tx pgx.Tx, row importRow, userId string, seen map[string]int) ImportRowResult {
+	values := row.Values
+	name, sku := values["name"], values["sku"]
+	result := ImportRowResult{Key: name}
+	if sku != "" {
+		result.Key = sku
+	}
+
+	if name == "" && sku == "" {
+		result.Errors = append(result.Errors, "name or sku is required")
+		return result
+	}
+
+	for _, key := range []string{"sku:" + strings.ToLower(sku), "name:" + strings.ToLower(name)} {
+		if strings.HasSuffix(key, ":") {
+			continue
+		}
+		if line, ok := seen[key]; ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("duplicate of row %d", line))
+			return result
+		}
+		seen[key] = row.Line
+	}
+
+	var price float64
+	var stock, minStock int
+	var trackSerial, trackLot bool
+	var err error
+	if row.has("price") {
+		price, err = strconv.ParseFloat(values["price"], 64)
+		if err != nil || price <= 0 {
+			result.Errors = append(result.Errors, "price must be a number greater than 0")
+		}
+	}
+	if row.has("stock") {
+		stock, err = strconv.Atoi(values["stock"])
+		if err != nil || stock < 0 {
+			result.Errors = append(result.Errors, "stock must be a whole number of 0 or more")
+		}
+	}
+	if row.has("min_stock") {
+		minStock, err = strconv.Atoi(values["min_stock"])
+		if err != nil || minStock < 0 {
+			result.Errors = append(result.Errors, "min_stock must be a whole number of 0 or more")
+		}
+	}
+	if row.has("track_serial") {
+		var ok bool
+		if trackSerial, ok = parseImportBool(values["track_serial"]); !ok {
+			result.Errors = append(result.Errors, "track_serial must be true or false")
+		}
+	}
+	if row.has("track_lot") {
+		var ok bool
+		if trackLot, ok = parseImportBool(values["track_lot"]); !ok {
+			result.Errors = append(result.Errors, "track_lot must be true or false")
+		}
+	}
+
+	var category categoryRef
+	if row.has("category") {
+		var ok bool
+		if category, ok = resolveCategory(ctx, tx, values["category"]); !ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("category %s not found", values["category"]))
+		}
+	}
+
+	var existing models.Items
+	var conversions int
+	found := false
+	lookup := `
+		SELECT items_id, sku, stock, price, COALESCE(unit, ''), track_serial, track_lot,
+		       (SELECT COUNT(*) FROM item_units WHERE item_id = items.items_id)
+		FROM items
+	`
+	scan := func(r pgx.Row) error {
+		return r.Scan(&existing.ItemsId, &existing.Sku, &existing.Stock, &existing.Price, &existing.Unit, &existing.TrackSerial, &existing.TrackLot, &conversions)
+	}
+	if sku != "" {
+		err = scan(tx.QueryRow(ctx, lookup+" WHERE LOWER(sku) = LOWER($1) FOR UPDATE", sku))
+		found = err == nil
+	}
+	if !found && name != "" {
+		var matches int
+		tx.QueryRow(ctx, "SELECT COUNT(*) FROM items WHERE LOWER(name) = LOWER($1)", name).Scan(&matches)
+		if matches > 1 {
+			result.Errors = append(result.Errors, fmt.Sprintf("%d items are named %s; add a sku column to pick one", matches, name))
+			return result
+		}
+		if matches == 1 {
+			err = scan(tx.QueryRow(ctx, lookup+" WHERE LOWER(name) = LOWER($1) FOR UPDATE", name))
+			found = err == nil
+		}
+	}
+
+	if !found {
+		result.Action = "create"
+		if name == "" {
+			result.Errors = append(result.Errors, "name is required for new items")
+		}
+		if !row.has("price") {
+			result.Errors = append(result.Errors, "price is required for new items")
+		}
+		if len(result.Errors) > 0 {
+			return result
+		}
+
+		if sku == "" {
+			sku, err = nextItemSku(ctx)
+			if err != nil {
+				errors.LogError("Item SKU generation error", err)
+				result.Errors = append(result.Errors, "failed to generate sku")
+				return result
+			}
+		}
+
+		var categoryId *string
+		if category.Id != "" {
+			categoryId = &category.Id
+			if !row.has("min_stock") {
+				minStock = category.DefaultMinStock
+			}
+		}
+
+		now := time.Now()
+		err = tx.QueryRow(ctx, `
+			INSERT INTO items (name, sku, stock, price, avg_cost, category, category_id, unit, unit_id, min_stock, track_serial, track_lot, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $4, $5, $6, $7, (SELECT units_id FROM units WHERE code = LOWER(TRIM($7))), $8, $9, $10, $11, $11)
+			RETURNING items_id
+		`, name, sku, stock, price, category.Code, categoryId, values["unit"], minStock, trackSerial, trackLot, now).Scan(&result.Id)
+		if err != nil {
+			errors.LogError("Item import insert error", err)
+			result.Errors = append(result.Errors, "failed to create item; check that the sku is unique")
+		}
+		return result
+	}
+
+	result.Action = "update"
+	result.Id = existing.ItemsId
+
+	if row.has("stock") && stock != existing.Stock {
+		result.Errors = append(result.Errors, fmt.Sprintf("stock differs from the current %d; change stock through receipts, issues or stock counts", existing.Stock))
+	}
+	if existing.Stock != 0 && ((row.has("track_serial") && trackSerial != existing.TrackSerial) || (row.has("track_lot") && trackLot != existing.TrackLot)) {
+		result.Errors = append(result.Errors, "serial or lot tracking can only be changed while the item has no stock")
+	}
+	if row.has("unit") && !strings.EqualFold(values["unit"], existing.Unit) && (existing.Stock != 0 || conversions > 0) {
+		result.Errors = append(result.Errors, "stock unit cannot be changed while the item has stock or unit conversions")
+	}
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	updateFields := []string{}
+	args := []interface{}{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		updateFields = append(updateFields, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if name != "" {
+		set("name", name)
+	}
+	if sku != "" && sku != existing.Sku {
+		set("sku", sku)
+	}
+	if row.has("price") {
+		set("price", price)
+	}
+	if row.has("category") {
+		set("category", category.Code)
+		set("category_id", category.Id)
+	}
+	if row.has("unit") {
+		set("unit", values["unit"])
+		args = append(args, values["unit"])
+		updateFields = append(updateFields, fmt.Sprintf("unit_id = (SELECT units_id FROM units WHERE code = LOWER(TRIM($%d)))", len(args)))
+	}
+	if row.has("min_stock") {
+		set("min_stock", minStock)
+	}
+	if row.has("track_serial") {
+		set("track_serial", trackSerial)
+	}
+	if row.has("track_lot") {
+		set("track_lot", trackLot)
+	}
+	set("updated_at", time.Now())
+
+	args = append(args, existing.ItemsId)
+	_, err = tx.Exec(ctx, fmt.Sprintf("UPDATE items SET %s WHERE items_id = $%d", strings.Join(updateFields, ", "), len(args)), args...)
+	if err != nil {
+		errors.LogError("Item import update error", err)
+		result.Errors = append(result.Errors, "failed to update item; check that the sku is unique")
+		return result
+	}
+
+	if row.has("price") && price != existing.Price {
+		if err = recordPriceChange(ctx, tx, existing.ItemsId, existing.Price, price, userId, "import"); err != nil {
+			errors.LogError("Item price change record error", err)
+			result.Errors = append(result.Errors, "failed to record price change")
+		}
+	}
+
+	return result
+}
+
+// ImportSuppliers upserts suppliers by name from a CSV or XLSX file whose
+// columns are the fields of models.Suppliers. supplier_type (or category)
+// takes a category code or ID.
+func ImportSuppliers(c *fiber.Ctx) error {
+	columns := importColumns(models.Suppliers{}, "suppliers_id", "category_id", "created_at", "updated_at")
+	columns["category"] = "supplier_type"
+	rows, message := parseImportFile(c, columns, "name")
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	seen := map[string]int{}
+	return runImport(c, "Suppliers", rows, func(ctx context.Context, tx pgx.Tx, row importRow) ImportRowResult {
+		return importSupplierRow(ctx, tx, row, seen)
+	})
+}
+
+func importSupplierRow(ctx context.Context, tx pgx.Tx, row importRow, seen map[string]int) ImportRowResult {
+	values := row.Values
+	name := values["name"]
+	result := ImportRowResult{Key: name}
+
+	if name == "" {
+		result.Errors = append(result.Errors, "name is required")
+		return result
+	}
+
+	key := strings.ToLower(name)
+	if line, ok := seen[key]; ok {
+		result.Errors = append(result.Errors, fmt.Sprintf("duplicate of row %d", line))
+		return result
+	}
+	seen[key] = row.Line
+
+	isActive := true
+	if row.has("is_active") {
+		var ok bool
+		if isActive, ok = parseImportBool(values["is_active"]); !ok {
+			result.Errors = append(result.Errors, "is_active must be true or false")
+		}
+	}
+
+	var category categoryRef
+	if row.has("supplier_type") {
+		var ok bool
+		if category, ok = resolveCategory(ctx, tx, values["supplier_type"]); !ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("category %s not found", values["supplier_type"]))
+		}
+	}
+
+	var supplierId string
+	var matches int
+	tx.QueryRow(ctx, "SELECT COUNT(*) FROM suppliers WHERE LOWER(name) = LOWER($1)", name).Scan(&matches)
+	if matches > 1 {
+		result.Errors = append(result.Errors, fmt.Sprintf("%d suppliers are named %s", matches, name))
+	}
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	var categoryId *string
+	if category.Id != "" {
+		categoryId = &category.Id
+	}
+
+	now := time.Now()
+	if matches == 0 {
+		result.Action = "create"
+		err := tx.QueryRow(ctx, `
+			INSERT INTO suppliers (name, email, address, phone, supplier_type, category_id, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+			RETURNING suppliers_id
+		`, name, values["email"], values["address"], values["phone"], category.Code, categoryId, isActive, now).Scan(&result.Id)
+		if err != nil {
+			errors.LogError("Supplier import insert error", err)
+			result.Errors = append(result.Errors, "failed to create supplier")
+		}
+		return result
+	}
+
+	result.Action = "update"
+	err := tx.QueryRow(ctx, "SELECT suppliers_id FROM suppliers WHERE LOWER(name) = LOWER($1) FOR UPDATE", name).Scan(&supplierId)
+	if err != nil {
+		errors.LogError("Supplier import lookup error", err)
+		result.Errors = append(result.Errors, "failed to load supplier")
+		return result
+	}
+	result.Id = supplierId
+
+	updateFields := []string{"name = $1"}
+	args := []interface{}{name}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		updateFields = append(updateFields, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	for _, column := range []string{"email", "address", "phone"} {
+		if row.has(column) {
+			set(column, values[column])
+		}
+	}
+	if row.has("supplier_type") {
+		set("supplier_type", category.Code)
+		set("category_id", categoryId)
+	}
+	if row.has("is_active") {
+		set("is_active", isActive)
+	}
+	set("updated_at", now)
+
+	args = append(args, supplierId)
+	_, err = tx.Exec(ctx, fmt.Sprintf("UPDATE suppliers SET %s WHERE suppliers_id = $%d", strings.Join(updateFields, ", "), len(args)), args...)
+	if err != nil {
+		errors.LogError("Supplier import update error", err)
+		result.Errors = append(result.Errors, "failed to update supplier")
+	}
+	return result
+}
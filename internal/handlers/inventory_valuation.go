@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type InventoryValuationRow struct {
+	ItemId          string   `json:"item_id"`
+	ItemName        string   `json:"item_name"`
+	Category        string   `json:"category"`
+	Unit            string   `json:"unit"`
+	Stock           int      `json:"stock"`
+	Price           float64  `json:"price"`
+	AvgCost         float64  `json:"avg_cost"`
+	AverageValue    float64  `json:"average_value"`
+	FifoValue       float64  `json:"fifo_value"`
+	LastReceiptCost *float64 `json:"last_receipt_cost"`
+	Value           float64  `json:"value"`
+}
+
+type costLayer struct {
+	id        string
+	unitCost  float64
+	remaining int
+}
+
+// costingMethod returns the method whose cost is booked on issues and
+// adjustments. Both methods are always tracked.
+func costingMethod() string {
+	if config.AppConfig != nil && strings.EqualFold(config.AppConfig.Inventory.CostingMethod, "fifo") {
+		return "fifo"
+	}
+	return "average"
+}
+
+func roundCost(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// ensureCostLayers opens a layer for stock that predates cost tracking so
+// FIFO consumption stays in line with items.stock.
+func ensureCostLayers(ctx context.Context, tx pgx.Tx, itemId string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO cost_layers (item_id, source_type, source_id, received_at, unit_cost, qty_received, qty_remaining, created_at)
+		SELECT items_id, 'opening', NULL, NOW(), CASE WHEN avg_cost > 0 THEN avg_cost ELSE price END, stock, stock, NOW()
+		FROM items
+		WHERE items_id = $1 AND stock > 0
+		AND NOT EXISTS (SELECT 1 FROM cost_layers WHERE item_id = $1)
+	`, itemId)
+	return err
+}
+
+// receiveCost adds a cost layer and moves the item's weighted average cost.
+// It must run before items.stock is incremented.
+func receiveCost(ctx context.Context, tx pgx.Tx, itemId, sourceType, sourceId string, qty int, unitCost float64, at time.Time) error {
+	if err := ensureCostLayers(ctx, tx, itemId); err != nil {
+		return err
+	}
+
+	var stock int
+	var avgCost float64
+	err := tx.QueryRow(ctx, "SELECT stock, avg_cost FROM items WHERE items_id = $1 FOR UPDATE", itemId).Scan(&stock, &avgCost)
+	if err != nil {
+		return err
+	}
+
+	newAvg := unitCost
+	if stock > 0 && stock+qty > 0 {
+		newAvg = (float64(stock)*avgCost + float64(qty)*unitCost) / float64(stock+qty)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE items SET avg_cost = $1 WHERE items_id = $2", newAvg, itemId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO cost_layers (item_id, source_type, source_id, received_at, unit_cost, qty_received, qty_remaining, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $4)
+	`, itemId, sourceType, sourceId, at, unitCost, qty)
+	return err
+}
+
+// consumeCost takes qty out of the oldest cost layers and returns the cost
+// of that quantity under both methods. Quantity not covered by layers is
+// costed at the average.
+func consumeCost(ctx context.Context, tx pgx.Tx, itemId string, qty int) (float64, float64, error) {
+	if err := ensureCostLayers(ctx, tx, itemId); err != nil {
+		return 0, 0, err
+	}
+
+	var avgCost float64
+	err := tx.QueryRow(ctx, "SELECT avg_cost FROM items WHERE items_id = $1 FOR UPDATE", itemId).Scan(&avgCost)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT cost_layers_id, unit_cost, qty_remaining
+		FROM cost_layers
+		WHERE item_id = $1 AND qty_remaining > 0
+		ORDER BY received_at, created_at
+		FOR UPDATE
+	`, itemId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var layers []costLayer
+	for rows.Next() {
+		var layer costLayer
+		if err := rows.Scan(&layer.id, &layer.unitCost, &layer.remaining); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		layers = append(layers, layer)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	fifoCost := 0.0
+	left := qty
+	for _, layer := range layers {
+		if left == 0 {
+			break
+		}
+		take := layer.remaining
+		if take > left {
+			take = left
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE cost_layers SET qty_remaining = qty_remaining - $1, updated_timestamp = NOW() WHERE cost_layers_id = $2", take, layer.id)
+		if err != nil {
+			return 0, 0, err
+		}
+		fifoCost += float64(take) * layer.unitCost
+		left -= take
+	}
+	fifoCost += float64(left) * avgCost
+
+	return roundCost(float64(qty) * avgCost), roundCost(fifoCost), nil
+}
+
+// bookInventoryCost values a stock movement outside purchasing (issues and
+// adjustments) and records it in the cost ledger. Outflows consume cost
+// layers; inflows are layered at the current average cost. The returned
+// cost is the absolute amount under the configured method. It must run
+// before items.stock is changed.
+func bookInventoryCost(ctx context.Context, tx pgx.Tx, itemId, warehouseId, sourceType, sourceId string, qty int) (float64, error) {
+	if qty == 0 {
+		return 0, nil
+	}
+
+	var averageCost, fifoCost float64
+	if qty < 0 {
+		var err error
+		averageCost, fifoCost, err = consumeCost(ctx, tx, itemId, -qty)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		var avgCost float64
+		err := tx.QueryRow(ctx, "SELECT CASE WHEN avg_cost > 0 THEN avg_cost ELSE price END FROM items WHERE items_id = $1", itemId).Scan(&avgCost)
+		if err != nil {
+			return 0, err
+		}
+		if err = receiveCost(ctx, tx, itemId, "adjustment", sourceId, qty, avgCost, time.Now()); err != nil {
+			return 0, err
+		}
+		averageCost = roundCost(float64(qty) * avgCost)
+		fifoCost = averageCost
+	}
+
+	method := costingMethod()
+	totalCost := averageCost
+	if method == "fifo" {
+		totalCost = fifoCost
+	}
+
+	sign := 1.0
+	if qty < 0 {
+		sign = -1.0
+	}
+
+	var warehouse *string
+	if warehouseId != "" {
+		warehouse = &warehouseId
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO inventory_cost_entries (item_id, warehouse_id, source_type, source_id, qty, method, average_cost, fifo_cost, total_cost, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, itemId, warehouse, sourceType, sourceId, qty, method, sign*averageCost, sign*fifoCost, sign*totalCost, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return totalCost, nil
+}
+
+// GetInventoryValuationReport values current stock at moving weighted
+// average and at FIFO layer cost.
+func GetInventoryValuationReport(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args := []interface{}{}
+	conditions := []string{}
+	if c.Query("include_zero") != "true" {
+		conditions = append(conditions, "i.stock <> 0")
+	}
+	if search := c.Query("search"); search != "" {
+		args = append(args, "%"+search+"%")
+		conditions = append(conditions, fmt.Sprintf("i.name ILIKE $%d", len(args)))
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	if category := c.Query("category"); category != "" {
+		whereClause, args = withCategoryFilter(whereClause, args, category, "i.category_id", "i.category")
+	}
+
+	query := `
+		SELECT i.items_id, i.name, COALESCE(i.category, ''), COALESCE(i.unit, ''), i.stock, i.price,
+		       CASE WHEN i.avg_cost > 0 THEN i.avg_cost ELSE i.price END,
+		       COALESCE(l.layer_qty, 0), COALESCE(l.layer_value, 0), lr.unit_cost
+		FROM items i
+		LEFT JOIN LATERAL (
+			SELECT SUM(qty_remaining) AS layer_qty, SUM(qty_remaining * unit_cost) AS layer_value
+			FROM cost_layers
+			WHERE item_id = i.items_id AND qty_remaining > 0
+		) l ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT d.unit_cost
+			FROM goods_receipt_details d
+			JOIN goods_receipts r ON d.goods_receipt_id = r.goods_receipts_id
+			WHERE d.item_id = i.items_id
+			ORDER BY r.date DESC, r.created_at DESC
+			LIMIT 1
+		) lr ON TRUE
+		` + whereClause + `
+		ORDER BY i.name
+	`
+
+	rows, err := database.DB.Query(ctx, query, args...)
+	if err != nil {
+		errors.LogError("Inventory valuation report error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to build inventory valuation",
+		})
+	}
+	defer rows.Close()
+
+	method := costingMethod()
+	report := []InventoryValuationRow{}
+	totalAverage, totalFifo := 0.0, 0.0
+	for rows.Next() {
+		var row InventoryValuationRow
+		var layerQty int
+		var layerValue float64
+		err := rows.Scan(
+			&row.ItemId,
+			&row.ItemName,
+			&row.Category,
+			&row.Unit,
+			&row.Stock,
+			&row.Price,
+			&row.AvgCost,
+			&layerQty,
+			&layerValue,
+			&row.LastReceiptCost,
+		)
+		if err != nil {
+			errors.LogError("Inventory valuation scan error", err)
+			continue
+		}
+
+		row.AverageValue = roundCost(float64(row.Stock) * row.AvgCost)
+		row.FifoValue = layerValue
+		if row.Stock > layerQty {
+			row.FifoValue += float64(row.Stock-layerQty) * row.AvgCost
+		}
+		row.FifoValue = roundCost(row.FifoValue)
+
+		row.Value = row.AverageValue
+		if method == "fifo" {
+			row.Value = row.FifoValue
+		}
+
+		totalAverage += row.AverageValue
+		totalFifo += row.FifoValue
+		report = append(report, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process inventory valuation",
+		})
+	}
+
+	totalValue := totalAverage
+	if method == "fifo" {
+		totalValue = totalFifo
+	}
+
+	return c.JSON(fiber.Map{
+		"error":               false,
+		"data":                report,
+		"count":               len(report),
+		"method":              method,
+		"total_average_value": roundCost(totalAverage),
+		"total_fifo_value":    roundCost(totalFifo),
+		"total_value":         roundCost(totalValue),
+	})
+}
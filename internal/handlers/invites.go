@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateInviteRequest struct {
+	Role      string `json:"role" validate:"required"`
+	Email     string `json:"email"`
+	ExpiresIn string `json:"expires_in"`
+}
+
+type InviteResponse struct {
+	models.UserInvites
+	Status string `json:"status"`
+	Token  string `json:"token,omitempty"`
+}
+
+func inviteStatus(invite models.UserInvites) string {
+	switch {
+	case invite.UsedAt != nil:
+		return "used"
+	case time.Now().After(invite.ExpiresAt):
+		return "expired"
+	}
+	return "pending"
+}
+
+func GetInvites(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"email", "role"}
+	filterFields := map[string]string{
+		"role":  "role",
+		"email": "email",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	statusConditions := map[string]string{
+		"pending": "used_at IS NULL AND expires_at > NOW()",
+		"used":    "used_at IS NOT NULL",
+		"expired": "used_at IS NULL AND expires_at <= NOW()",
+	}
+	if condition, ok := statusConditions[params.Filters["status"]]; ok {
+		if whereClause == "" {
+			whereClause = "WHERE " + condition
+		} else {
+			whereClause += " AND " + condition
+		}
+	}
+	orderClause := query.BuildOrderClause(params, "created_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("user_invites", whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get invites count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count invites",
+		})
+	}
+
+	fullQuery := `
+		SELECT user_invites_id, role, COALESCE(email, ''), created_by, expires_at, used_at, used_by, created_at, updated_at
+		FROM user_invites
+	` + " " + whereClause + " " + orderClause + " " + paginationClause
+
+	rows, err := database.DB.Query(ctx, fullQuery, append(whereArgs, paginationArgs...)...)
+	if err != nil {
+		errors.LogError("Get invites query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch invites",
+		})
+	}
+	defer rows.Close()
+
+	invites := []InviteResponse{}
+	for rows.Next() {
+		var invite InviteResponse
+		err := rows.Scan(
+			&invite.UserInvitesId,
+			&invite.Role,
+			&invite.Email,
+			&invite.CreatedBy,
+			&invite.ExpiresAt,
+			&invite.UsedAt,
+			&invite.UsedBy,
+			&invite.CreatedAt,
+			&invite.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Invite scan error", err)
+			continue
+		}
+		invite.Status = inviteStatus(invite.UserInvites)
+		invites = append(invites, invite)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process invites",
+		})
+	}
+
+	response := query.NewPaginatedResponse(invites, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+// CreateInvite issues a single-use registration invite for a role. The
+// token is only returned here; the database keeps its hash.
+func CreateInvite(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req CreateInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Role == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Role is required",
+		})
+	}
+
+	expiresIn := req.ExpiresIn
+	if expiresIn == "" {
+		expiresIn = config.AppConfig.Register.InviteExpiresIn
+	}
+	ttl, err := time.ParseDuration(expiresIn)
+	if err != nil || ttl <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "expires_in must be a positive duration such as 72h",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role := strings.ToUpper(req.Role)
+	if !roleExists(ctx, database.DB, role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Role does not exist",
+		})
+	}
+	if ok, err := roleAssignable(ctx, claims, role); !ok {
+		if err != nil {
+			errors.LogError("Role permission check error", err)
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "You cannot assign a role with permissions you do not hold",
+		})
+	}
+
+	token, tokenHash, err := jwt.NewOpaqueToken()
+	if err != nil {
+		errors.LogError("Invite token generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create invite",
+		})
+	}
+
+	now := time.Now()
+	invite := InviteResponse{Token: token}
+	err = database.DB.QueryRow(ctx, `
+		INSERT INTO user_invites (token_hash, role, email, created_by, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING user_invites_id, role, COALESCE(email, ''), created_by, expires_at, used_at, used_by, created_at, updated_at
+	`, tokenHash, role, strings.TrimSpace(req.Email), claims.UserID, now.Add(ttl), now).Scan(
+		&invite.UserInvitesId,
+		&invite.Role,
+		&invite.Email,
+		&invite.CreatedBy,
+		&invite.ExpiresAt,
+		&invite.UsedAt,
+		&invite.UsedBy,
+		&invite.CreatedAt,
+		&invite.UpdatedAt,
+	)
+	if err != nil {
+		errors.LogError("Invite creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create invite",
+		})
+	}
+	invite.Status = inviteStatus(invite.UserInvites)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Invite created successfully",
+		"data":    invite,
+	})
+}
+
+// DeleteInvite withdraws an invite that has not been used yet.
+func DeleteInvite(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var usedAt *time.Time
+	err := database.DB.QueryRow(ctx, "SELECT used_at FROM user_invites WHERE user_invites_id = $1", id).Scan(&usedAt)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invite not found",
+		})
+	}
+
+	if usedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invite has already been used",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM user_invites WHERE user_invites_id = $1", id)
+	if err != nil {
+		errors.LogError("Invite deletion error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete invite",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Invite deleted successfully",
+	})
+}
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/barcode"
+	"fleetify/pkg/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateItemBarcodeRequest struct {
+	Symbology string `json:"symbology" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type LabelRequestItem struct {
+	ItemId    string `json:"item_id" validate:"required"`
+	BarcodeId string `json:"barcode_id"`
+	Copies    int    `json:"copies"`
+}
+
+type PrintLabelsRequest struct {
+	Items []LabelRequestItem `json:"items" validate:"required,min=1"`
+}
+
+const maxLabelsPerRequest = 960
+
+// nextItemSku draws ITM-nnnnnn codes from item_sku_seq, skipping any that
+// were already entered by hand.
+func nextItemSku(ctx context.Context) (string, error) {
+	for attempt := 0; attempt < 10; attempt++ {
+		var sku string
+		err := database.DB.QueryRow(ctx, "SELECT 'ITM-' || LPAD(nextval('item_sku_seq')::text, 6, '0')").Scan(&sku)
+		if err != nil {
+			return "", err
+		}
+		if !skuTaken(ctx, sku, "") {
+			return sku, nil
+		}
+	}
+	return "", fmt.Errorf("no free SKU found")
+}
+
+func skuTaken(ctx context.Context, sku, exceptItemId string) bool {
+	var exists bool
+	err := database.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM items WHERE LOWER(sku) = LOWER($1) AND items_id::text <> $2)", sku, exceptItemId).Scan(&exists)
+	return err != nil || exists
+}
+
+func fetchItemBarcodes(ctx context.Context, itemId string) ([]models.ItemBarcodes, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT item_barcodes_id, item_id, symbology, code, is_primary, created_at, updated_at
+		FROM item_barcodes
+		WHERE item_id = $1
+		ORDER BY is_primary DESC, created_at
+	`, itemId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	barcodes := []models.ItemBarcodes{}
+	for rows.Next() {
+		var bc models.ItemBarcodes
+		err := rows.Scan(
+			&bc.ItemBarcodesId,
+			&bc.ItemId,
+			&bc.Symbology,
+			&bc.Code,
+			&bc.IsPrimary,
+			&bc.CreatedAt,
+			&bc.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		barcodes = append(barcodes, bc)
+	}
+
+	return barcodes, rows.Err()
+}
+
+func GetItemBarcodes(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT items_id FROM items WHERE items_id = $1", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Item not found",
+		})
+	}
+
+	barcodes, err := fetchItemBarcodes(ctx, id)
+	if err != nil {
+		errors.LogError("Get item barcodes query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch item barcodes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  barcodes,
+		"count": len(barcodes),
+	})
+}
+
+func CreateItemBarcode(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req CreateItemBarcodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Symbology = strings.ToLower(strings.TrimSpace(req.Symbology))
+	code, err := barcode.Normalize(req.Symbology, req.Code)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var existing string
+	err = tx.QueryRow(ctx, "SELECT items_id FROM items WHERE items_id = $1 FOR UPDATE", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Item not found",
+		})
+	}
+
+	var owner string
+	err = tx.QueryRow(ctx, "SELECT item_id FROM item_barcodes WHERE code = $1", code).Scan(&owner)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Barcode %s is already assigned to item %s", code, owner),
+		})
+	}
+
+	// The first barcode of an item becomes its primary one.
+	var count int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM item_barcodes WHERE item_id = $1", id).Scan(&count)
+	if err == nil && count == 0 {
+		req.IsPrimary = true
+	}
+
+	if req.IsPrimary {
+		_, err = tx.Exec(ctx, "UPDATE item_barcodes SET is_primary = FALSE, updated_at = $1 WHERE item_id = $2 AND is_primary", time.Now(), id)
+		if err != nil {
+			errors.LogError("Item barcode primary reset error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to create item barcode",
+			})
+		}
+	}
+
+	now := time.Now()
+	var bc models.ItemBarcodes
+	err = tx.QueryRow(ctx, `
+		INSERT INTO item_barcodes (item_id, symbology, code, is_primary, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING item_barcodes_id, item_id, symbology, code, is_primary, created_at, updated_at
+	`, id, req.Symbology, code, req.IsPrimary, now).Scan(
+		&bc.ItemBarcodesId,
+		&bc.ItemId,
+		&bc.Symbology,
+		&bc.Code,
+		&bc.IsPrimary,
+		&bc.CreatedAt,
+		&bc.UpdatedAt,
+	)
+	if err != nil {
+		errors.LogError("Item barcode creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create item barcode",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Item barcode created successfully",
+		"data":    bc,
+	})
+}
+
+func DeleteItemBarcode(c *fiber.Ctx) error {
+	id := c.Params("id")
+	barcodeId := c.Params("barcode_id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := database.DB.Exec(ctx, "DELETE FROM item_barcodes WHERE item_barcodes_id = $1 AND item_id = $2", barcodeId, id)
+	if err != nil || result.RowsAffected() == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Item barcode not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Item barcode deleted successfully",
+	})
+}
+
+// LookupItem resolves a scanned barcode, or a SKU typed in its place, to
+// the item it belongs to.
+func LookupItem(c *fiber.Ctx) error {
+	code := strings.TrimSpace(c.Query("barcode"))
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "barcode query parameter is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var itemId string
+	var matched *models.ItemBarcodes
+	var bc models.ItemBarcodes
+	err := database.DB.QueryRow(ctx, `
+		SELECT item_barcodes_id, item_id, symbology, code, is_primary, created_at, updated_at
+		FROM item_barcodes
+		WHERE code = $1
+	`, code).Scan(
+		&bc.ItemBarcodesId,
+		&bc.ItemId,
+		&bc.Symbology,
+		&bc.Code,
+		&bc.IsPrimary,
+		&bc.CreatedAt,
+		&bc.UpdatedAt,
+	)
+	if err == nil {
+		itemId = bc.ItemId
+		matched = &bc
+	} else {
+		err = database.DB.QueryRow(ctx, "SELECT items_id FROM items WHERE LOWER(sku) = LOWER($1)", code).Scan(&itemId)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   true,
+				"message": "No item found for this barcode",
+			})
+		}
+	}
+
+	var item ItemResponse
+	err = database.DB.QueryRow(ctx, `
+		SELECT items_id, name, sku, stock, price, avg_cost, category, category_id, unit, unit_id, purchase_unit_id, min_stock, track_serial, track_lot, created_at, updated_at
+		FROM items
+		WHERE items_id = $1
+	`, itemId).Scan(
+		&item.ItemsId,
+		&item.Name,
+		&item.Sku,
+		&item.Stock,
+		&item.Price,
+		&item.AvgCost,
+		&item.Category,
+		&item.CategoryId,
+		&item.Unit,
+		&item.UnitId,
+		&item.PurchaseUnitId,
+		&item.MinStock,
+		&item.TrackSerial,
+		&item.TrackLot,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		errors.LogError("Item lookup query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch item",
+		})
+	}
+
+	locations, inTransit, err := loadItemLocations(ctx, []string{item.ItemsId})
+	if err != nil {
+		errors.LogError("Item locations query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch item locations",
+		})
+	}
+	item.Locations = locations[item.ItemsId]
+	item.InTransit = inTransit[item.ItemsId]
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"data":    item,
+		"barcode": matched,
+	})
+}
+
+// itemLabel builds the label for an item. Without a barcode ID it prints
+// the primary barcode, or the SKU as Code128 when the item has none.
+func itemLabel(ctx context.Context, itemId, barcodeId string) (barcode.Label, error) {
+	var label barcode.Label
+	var sku, unit string
+	var price float64
+	err := database.DB.QueryRow(ctx, "SELECT name, sku, COALESCE(unit, ''), price FROM items WHERE items_id = $1", itemId).Scan(&label.Title, &sku, &unit, &price)
+	if err != nil {
+		return label, fmt.Errorf("item %s not found", itemId)
+	}
+
+	label.Subtitle = fmt.Sprintf("SKU %s", sku)
+	if unit != "" {
+		label.Subtitle += fmt.Sprintf("  |  %.0f / %s", price, unit)
+	}
+
+	query := "SELECT symbology, code FROM item_barcodes WHERE item_id = $1 ORDER BY is_primary DESC, created_at LIMIT 1"
+	args := []interface{}{itemId}
+	if barcodeId != "" {
+		query = "SELECT symbology, code FROM item_barcodes WHERE item_id = $1 AND item_barcodes_id = $2"
+		args = append(args, barcodeId)
+	}
+
+	err = database.DB.QueryRow(ctx, query, args...).Scan(&label.Symbology, &label.Code)
+	if err != nil {
+		if barcodeId != "" {
+			return label, fmt.Errorf("barcode %s not found for item %s", barcodeId, itemId)
+		}
+		label.Symbology = barcode.Code128
+		label.Code = sku
+	}
+
+	return label, nil
+}
+
+// GetItemLabel renders an item's barcode as a PNG image, or as a printable
+// PDF label sheet with format=pdf.
+func GetItemLabel(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	label, err := itemLabel(ctx, id, c.Query("barcode_id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	var buf bytes.Buffer
+	if c.Query("format") == "pdf" {
+		copies, _ := strconv.Atoi(c.Query("copies", "1"))
+		if copies < 1 || copies > maxLabelsPerRequest {
+			copies = 1
+		}
+		labels := make([]barcode.Label, copies)
+		for i := range labels {
+			labels[i] = label
+		}
+
+		if err = barcode.WriteLabelsPDF(&buf, labels); err != nil {
+			errors.LogError("Item label PDF error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to generate label",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="label-%s.pdf"`, id))
+		return c.Send(buf.Bytes())
+	}
+
+	width, _ := strconv.Atoi(c.Query("width", "400"))
+	height, _ := strconv.Atoi(c.Query("height", "150"))
+	if width < 50 || width > 2000 {
+		width = 400
+	}
+	if height < 50 || height > 2000 {
+		height = 150
+	}
+
+	if err = barcode.WritePNG(&buf, label.Symbology, label.Code, width, height); err != nil {
+		errors.LogError("Item label image error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate label",
+		})
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Send(buf.Bytes())
+}
+
+// PrintItemLabels renders shelf labels for several items into one PDF.
+func PrintItemLabels(c *fiber.Ctx) error {
+	var req PrintLabelsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if len(req.Items) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "At least one item is required",
+		})
+	}
+
+	if len(req.Items) > maxLabelsPerRequest {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("At most %d labels can be printed at once", maxLabelsPerRequest),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	labels := []barcode.Label{}
+	for _, entry := range req.Items {
+		label, err := itemLabel(ctx, entry.ItemId, entry.BarcodeId)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": err.Error(),
+			})
+		}
+
+		copies := entry.Copies
+		if copies < 1 {
+			copies = 1
+		}
+		// Checked before appending, so a huge copies value is never allocated.
+		if copies > maxLabelsPerRequest-len(labels) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("At most %d labels can be printed at once", maxLabelsPerRequest),
+			})
+		}
+		for i := 0; i < copies; i++ {
+			labels = append(labels, label)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := barcode.WriteLabelsPDF(&buf, labels); err != nil {
+		errors.LogError("Item labels PDF error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate labels",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="labels.pdf"`)
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type ItemPriceChangeResponse struct {
+	models.ItemPriceChanges
+	Username string `json:"username"`
+}
+
+type PriceChangeReportRow struct {
+	ItemId         string    `json:"item_id"`
+	ItemName       string    `json:"item_name"`
+	Sku            string    `json:"sku"`
+	Category       string    `json:"category"`
+	StartPrice     float64   `json:"start_price"`
+	EndPrice       float64   `json:"end_price"`
+	ChangePct      float64   `json:"change_pct"`
+	Changes        int       `json:"changes"`
+	LargestStepPct *float64  `json:"largest_step_pct"`
+	LastChangedAt  time.Time `json:"last_changed_at"`
+}
+
+// recordPriceChange appends to an item's price trail. It runs in the
+// transaction that changes items.price.
+func recordPriceChange(ctx context.Context, tx pgx.Tx, itemId string, oldPrice, newPrice float64, userId, reason string) error {
+	var changePct *float64
+	if oldPrice != 0 {
+		pct := roundCost((newPrice - oldPrice) / oldPrice * 100)
+		changePct = &pct
+	}
+
+	var user *string
+	if userId != "" {
+		user = &userId
+	}
+
+	now := time.Now()
+	_, err := tx.Exec(ctx, `
+		INSERT INTO item_price_changes (item_id, old_price, new_price, change_pct, user_id, reason, changed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $7)
+	`, itemId, oldPrice, newPrice, changePct, user, reason, now)
+	return err
+}
+
+// parsePeriod reads from/to dates (YYYY-MM-DD, to inclusive) and falls back
+// to the last defaultDays days.
+func parsePeriod(c *fiber.Ctx, defaultDays int) (time.Time, time.Time, error) {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	if value := c.Query("to"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date, use YYYY-MM-DD")
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -defaultDays)
+	if value := c.Query("from"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from date, use YYYY-MM-DD")
+		}
+		from = date
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from date must be before to date")
+	}
+	return from, to, nil
+}
+
+func GetItemPriceHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT items_id FROM items WHERE items_id = $1", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Item not found",
+		})
+	}
+
+	params := query.ParseQueryParams(c)
+
+	args := []interface{}{id}
+	conditions := []string{"pc.item_id = $1"}
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid from date, use YYYY-MM-DD",
+			})
+		}
+		args = append(args, from)
+		conditions = append(conditions, fmt.Sprintf("pc.changed_at >= $%d", len(args)))
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid to date, use YYYY-MM-DD",
+			})
+		}
+		args = append(args, to.AddDate(0, 0, 1))
+		conditions = append(conditions, fmt.Sprintf("pc.changed_at < $%d", len(args)))
+	}
+
+	fromClause := "FROM item_price_changes pc LEFT JOIN users u ON pc.user_id = u.users_id"
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(args)+1)
+
+	var totalCount int
+	err = database.DB.QueryRow(ctx, "SELECT COUNT(*) "+fromClause+" "+whereClause, args...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get item price history count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count price changes",
+		})
+	}
+
+	fullQuery := `
+		SELECT pc.item_price_changes_id, pc.item_id, pc.old_price, pc.new_price, pc.change_pct, pc.user_id,
+		       COALESCE(pc.reason, ''), pc.changed_at, pc.created_at, pc.updated_at, COALESCE(u.username, '')
+		` + fromClause + " " + whereClause + " ORDER BY pc.changed_at DESC " + paginationClause
+
+	rows, err := database.DB.Query(ctx, fullQuery, append(args, paginationArgs...)...)
+	if err != nil {
+		errors.LogError("Get item price history query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch price history",
+		})
+	}
+	defer rows.Close()
+
+	changes := []ItemPriceChangeResponse{}
+	for rows.Next() {
+		var change ItemPriceChangeResponse
+		err := rows.Scan(
+			&change.ItemPriceChangesId,
+			&change.ItemId,
+			&change.OldPrice,
+			&change.NewPrice,
+			&change.ChangePct,
+			&change.UserId,
+			&change.Reason,
+			&change.ChangedAt,
+			&change.CreatedAt,
+			&change.UpdatedAt,
+			&change.Username,
+		)
+		if err != nil {
+			errors.LogError("Price change scan error", err)
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process price history",
+		})
+	}
+
+	response := query.NewPaginatedResponse(changes, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+// GetPriceChangeReport lists items whose price moved by at least threshold
+// percent between the first and last change in the period (30 days by
+// default). direction=increase keeps only price rises.
+func GetPriceChangeReport(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	from, to, err := parsePeriod(c, 30)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	threshold := 10.0
+	if value := c.Query("threshold"); value != "" {
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || threshold < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Threshold must be a non-negative number",
+			})
+		}
+	}
+
+	changeExpr := "(l.new_price - f.old_price) / f.old_price * 100"
+	args := []interface{}{from, to, threshold}
+	whereClause := fmt.Sprintf("WHERE f.old_price > 0 AND ABS(%s) >= $3", changeExpr)
+	if c.Query("direction") == "increase" {
+		whereClause += " AND l.new_price > f.old_price"
+	}
+	if category := c.Query("category"); category != "" {
+		whereClause, args = withCategoryFilter(whereClause, args, category, "i.category_id", "i.category")
+	}
+
+	reportQuery := `
+		WITH changes AS (
+			SELECT item_id, old_price, new_price, change_pct, changed_at,
+			       ROW_NUMBER() OVER (PARTITION BY item_id ORDER BY changed_at, created_at) AS first_rank,
+			       ROW_NUMBER() OVER (PARTITION BY item_id ORDER BY changed_at DESC, created_at DESC) AS last_rank
+			FROM item_price_changes
+			WHERE changed_at >= $1 AND changed_at < $2
+		), totals AS (
+			SELECT item_id, COUNT(*) AS changes, MAX(ABS(change_pct)) AS largest_step
+			FROM changes
+			GROUP BY item_id
+		)
+		SELECT i.items_id, i.name, i.sku, COALESCE(i.category, ''), f.old_price, l.new_price,
+		       ROUND((` + changeExpr + `)::numeric, 2), t.changes, t.largest_step, l.changed_at
+		FROM items i
+		JOIN changes f ON f.item_id = i.items_id AND f.first_rank = 1
+		JOIN changes l ON l.item_id = i.items_id AND l.last_rank = 1
+		JOIN totals t ON t.item_id = i.items_id
+		` + whereClause + `
+		ORDER BY ABS(` + changeExpr + `) DESC, i.name
+	`
+
+	rows, err := database.DB.Query(ctx, reportQuery, args...)
+	if err != nil {
+		errors.LogError("Price change report error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to build price change report",
+		})
+	}
+	defer rows.Close()
+
+	report := []PriceChangeReportRow{}
+	for rows.Next() {
+		var row PriceChangeReportRow
+		err := rows.Scan(
+			&row.ItemId,
+			&row.ItemName,
+			&row.Sku,
+			&row.Category,
+			&row.StartPrice,
+			&row.EndPrice,
+			&row.ChangePct,
+			&row.Changes,
+			&row.LargestStepPct,
+			&row.LastChangedAt,
+		)
+		if err != nil {
+			errors.LogError("Price change report scan error", err)
+			continue
+		}
+		report = append(report, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process price change report",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":     false,
+		"data":      report,
+		"count":     len(report),
+		"from":      from.Format("2006-01-02"),
+		"to":        to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"threshold": threshold,
+	})
+}
@@ -10,25 +10,41 @@ import (
 	"fleetify/internal/database"
 	"fleetify/internal/models"
 	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
 	"fleetify/pkg/query"
 )
 
 type CreateItemRequest struct {
-	Name     string  `json:"name" validate:"required"`
-	Stock    int     `json:"stock"`
-	Price    float64 `json:"price" validate:"required,gt=0"`
-	Category string  `json:"category"`
-	Unit     string  `json:"unit"`
-	MinStock int     `json:"min_stock"`
+	Name        string  `json:"name" validate:"required"`
+	Sku         string  `json:"sku"`
+	Stock       int     `json:"stock"`
+	Price       float64 `json:"price" validate:"required,gt=0"`
+	Category    string  `json:"category"`
+	CategoryId  string  `json:"category_id"`
+	Unit        string  `json:"unit"`
+	MinStock    int     `json:"min_stock"`
+	TrackSerial bool    `json:"track_serial"`
+	TrackLot    bool    `json:"track_lot"`
 }
 
 type UpdateItemRequest struct {
-	Name     *string  `json:"name"`
-	Stock    *int     `json:"stock"`
-	Price    *float64 `json:"price"`
-	Category *string  `json:"category"`
-	Unit     *string  `json:"unit"`
-	MinStock *int     `json:"min_stock"`
+	Name        *string  `json:"name"`
+	Sku         *string  `json:"sku"`
+	Stock       *int     `json:"stock"`
+	Price       *float64 `json:"price"`
+	PriceReason *string  `json:"price_reason"`
+	Category    *string  `json:"category"`
+	CategoryId  *string  `json:"category_id"`
+	Unit        *string  `json:"unit"`
+	MinStock    *int     `json:"min_stock"`
+	TrackSerial *bool    `json:"track_serial"`
+	TrackLot    *bool    `json:"track_lot"`
+}
+
+type ItemResponse struct {
+	models.Items
+	InTransit int                 `json:"in_transit"`
+	Locations []ItemLocationStock `json:"locations"`
 }
 
 func GetItems(c *fiber.Ctx) error {
@@ -37,16 +53,28 @@ func GetItems(c *fiber.Ctx) error {
 
 	params := query.ParseQueryParams(c)
 	
-	searchFields := []string{"name", "category", "unit"}
+	searchFields := []string{"name", "sku", "category", "unit"}
 	filterFields := map[string]string{
-		"category": "category",
-		"unit":     "unit",
+		"sku":  "sku",
+		"unit": "unit",
 	}
 
 	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	if category := params.Filters["category"]; category != "" {
+		whereClause, whereArgs = withCategoryFilter(whereClause, whereArgs, category, "items.category_id", "items.category")
+	}
 	orderClause := query.BuildOrderClause(params, "created_at")
 	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
 
+	baseQuery := `
+		SELECT items_id, name, sku, stock, price, avg_cost, category, category_id, unit, unit_id, purchase_unit_id, min_stock, track_serial, track_lot, created_at, updated_at
+		FROM items
+	`
+
+	if format := exportFormat(c); format != "" {
+		return streamExport(c, format, "items", baseQuery+" "+whereClause+" "+orderClause, whereArgs)
+	}
+
 	countQuery := query.BuildCountQuery("items", whereClause)
 
 	var totalCount int
@@ -59,11 +87,6 @@ func GetItems(c *fiber.Ctx) error {
 		})
 	}
 
-	baseQuery := `
-		SELECT items_id, name, stock, price, category, unit, min_stock, created_at, updated_at
-		FROM items
-	`
-	
 	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
 	allArgs := append(whereArgs, paginationArgs...)
 
@@ -77,17 +100,25 @@ func GetItems(c *fiber.Ctx) error {
 	}
 	defer rows.Close()
 
-	var items []models.Items
+	var items []ItemResponse
+	itemIds := []string{}
 	for rows.Next() {
-		var item models.Items
+		var item ItemResponse
 		err := rows.Scan(
 			&item.ItemsId,
 			&item.Name,
+			&item.Sku,
 			&item.Stock,
 			&item.Price,
+			&item.AvgCost,
 			&item.Category,
+			&item.CategoryId,
 			&item.Unit,
+			&item.UnitId,
+			&item.PurchaseUnitId,
 			&item.MinStock,
+			&item.TrackSerial,
+			&item.TrackLot,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 		)
@@ -96,6 +127,7 @@ func GetItems(c *fiber.Ctx) error {
 			continue
 		}
 		items = append(items, item)
+		itemIds = append(itemIds, item.ItemsId)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -106,6 +138,19 @@ func GetItems(c *fiber.Ctx) error {
 		})
 	}
 
+	locations, inTransit, err := loadItemLocations(ctx, itemIds)
+	if err != nil {
+		errors.LogError("Item locations query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch item locations",
+		})
+	}
+	for i := range items {
+		items[i].Locations = locations[items[i].ItemsId]
+		items[i].InTransit = inTransit[items[i].ItemsId]
+	}
+
 	response := query.NewPaginatedResponse(items, totalCount, params.Page, params.Limit)
 	return c.JSON(fiber.Map{
 		"error": false,
@@ -130,9 +175,9 @@ func GetItemById(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var item models.Items
+	var item ItemResponse
 	query := `
-		SELECT items_id, name, stock, price, category, unit, min_stock, created_at, updated_at
+		SELECT items_id, name, sku, stock, price, avg_cost, category, category_id, unit, unit_id, purchase_unit_id, min_stock, track_serial, track_lot, created_at, updated_at
 		FROM items
 		WHERE items_id = $1
 	`
@@ -140,11 +185,18 @@ func GetItemById(c *fiber.Ctx) error {
 	err := database.DB.QueryRow(ctx, query, id).Scan(
 		&item.ItemsId,
 		&item.Name,
+		&item.Sku,
 		&item.Stock,
 		&item.Price,
+		&item.AvgCost,
 		&item.Category,
+		&item.CategoryId,
 		&item.Unit,
+		&item.UnitId,
+		&item.PurchaseUnitId,
 		&item.MinStock,
+		&item.TrackSerial,
+		&item.TrackLot,
 		&item.CreatedAt,
 		&item.UpdatedAt,
 	)
@@ -156,6 +208,17 @@ func GetItemById(c *fiber.Ctx) error {
 		})
 	}
 
+	locations, inTransit, err := loadItemLocations(ctx, []string{item.ItemsId})
+	if err != nil {
+		errors.LogError("Item locations query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch item locations",
+		})
+	}
+	item.Locations = locations[item.ItemsId]
+	item.InTransit = inTransit[item.ItemsId]
+
 	return c.JSON(fiber.Map{
 		"error": false,
 		"data":  item,
@@ -188,11 +251,49 @@ func CreateItem(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	var categoryId *string
+	categoryKey := req.CategoryId
+	if categoryKey == "" {
+		categoryKey = req.Category
+	}
+	if categoryKey != "" {
+		category, ok := resolveCategory(ctx, database.DB, categoryKey)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Category not found",
+			})
+		}
+		categoryId = &category.Id
+		req.Category = category.Code
+		if req.MinStock == 0 {
+			req.MinStock = category.DefaultMinStock
+		}
+	}
+
+	req.Sku = strings.TrimSpace(req.Sku)
+	if req.Sku == "" {
+		sku, err := nextItemSku(ctx)
+		if err != nil {
+			errors.LogError("Item SKU generation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to generate SKU",
+			})
+		}
+		req.Sku = sku
+	} else if skuTaken(ctx, req.Sku, "") {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "SKU already exists",
+		})
+	}
+
 	now := time.Now()
 	query := `
-		INSERT INTO items (name, stock, price, category, unit, min_stock, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING items_id, name, stock, price, category, unit, min_stock, created_at, updated_at
+		INSERT INTO items (name, sku, stock, price, avg_cost, category, category_id, unit, unit_id, min_stock, track_serial, track_lot, created_at, updated_at)
+		VALUES ($1, $12, $2, $3, $3, $4, $11, $5, (SELECT units_id FROM units WHERE code = LOWER(TRIM($5))), $6, $7, $8, $9, $10)
+		RETURNING items_id, name, sku, stock, price, avg_cost, category, category_id, unit, unit_id, purchase_unit_id, min_stock, track_serial, track_lot, created_at, updated_at
 	`
 
 	var item models.Items
@@ -203,16 +304,27 @@ func CreateItem(c *fiber.Ctx) error {
 		req.Category,
 		req.Unit,
 		req.MinStock,
+		req.TrackSerial,
+		req.TrackLot,
 		now,
 		now,
+		categoryId,
+		req.Sku,
 	).Scan(
 		&item.ItemsId,
 		&item.Name,
+		&item.Sku,
 		&item.Stock,
 		&item.Price,
+		&item.AvgCost,
 		&item.Category,
+		&item.CategoryId,
 		&item.Unit,
+		&item.UnitId,
+		&item.PurchaseUnitId,
 		&item.MinStock,
+		&item.TrackSerial,
+		&item.TrackLot,
 		&item.CreatedAt,
 		&item.UpdatedAt,
 	)
@@ -253,8 +365,8 @@ func UpdateItem(c *fiber.Ctx) error {
 	defer cancel()
 
 	var existingItem models.Items
-	checkQuery := `SELECT items_id FROM items WHERE items_id = $1`
-	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingItem.ItemsId)
+	checkQuery := `SELECT items_id, stock, track_serial, track_lot FROM items WHERE items_id = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingItem.ItemsId, &existingItem.Stock, &existingItem.TrackSerial, &existingItem.TrackLot)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
@@ -262,6 +374,15 @@ func UpdateItem(c *fiber.Ctx) error {
 		})
 	}
 
+	trackingChanged := (req.TrackSerial != nil && *req.TrackSerial != existingItem.TrackSerial) ||
+		(req.TrackLot != nil && *req.TrackLot != existingItem.TrackLot)
+	if trackingChanged && existingItem.Stock != 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Serial or lot tracking can only be changed while the item has no stock",
+		})
+	}
+
 	updateFields := []string{}
 	args := []interface{}{}
 	argPos := 1
@@ -272,12 +393,34 @@ func UpdateItem(c *fiber.Ctx) error {
 		argPos++
 	}
 
-	if req.Stock != nil {
-		updateFields = append(updateFields, fmt.Sprintf("stock = $%d", argPos))
-		args = append(args, *req.Stock)
+	if req.Sku != nil {
+		sku := strings.TrimSpace(*req.Sku)
+		if sku == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "SKU cannot be empty",
+			})
+		}
+		if skuTaken(ctx, sku, id) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "SKU already exists",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("sku = $%d", argPos))
+		args = append(args, sku)
 		argPos++
 	}
 
+	if req.Stock != nil {
+		if *req.Stock < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Stock cannot be negative",
+			})
+		}
+	}
+
 	if req.Price != nil {
 		if *req.Price <= 0 {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -290,14 +433,43 @@ func UpdateItem(c *fiber.Ctx) error {
 		argPos++
 	}
 
-	if req.Category != nil {
-		updateFields = append(updateFields, fmt.Sprintf("category = $%d", argPos))
-		args = append(args, *req.Category)
-		argPos++
+	if req.CategoryId != nil || req.Category != nil {
+		categoryKey := req.CategoryId
+		if categoryKey == nil {
+			categoryKey = req.Category
+		}
+
+		var categoryId *string
+		code := ""
+		if *categoryKey != "" {
+			category, ok := resolveCategory(ctx, database.DB, *categoryKey)
+			if !ok {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Category not found",
+				})
+			}
+			categoryId = &category.Id
+			code = category.Code
+		}
+
+		updateFields = append(updateFields, fmt.Sprintf("category = $%d, category_id = $%d", argPos, argPos+1))
+		args = append(args, code, categoryId)
+		argPos += 2
 	}
 
 	if req.Unit != nil {
-		updateFields = append(updateFields, fmt.Sprintf("unit = $%d", argPos))
+		var currentUnit string
+		var conversions int
+		err = database.DB.QueryRow(ctx, "SELECT COALESCE(unit, ''), (SELECT COUNT(*) FROM item_units WHERE item_id = $1) FROM items WHERE items_id = $1", id).Scan(&currentUnit, &conversions)
+		if err == nil && !strings.EqualFold(currentUnit, *req.Unit) && (existingItem.Stock != 0 || conversions > 0) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Stock unit cannot be changed while the item has stock or unit conversions",
+			})
+		}
+
+		updateFields = append(updateFields, fmt.Sprintf("unit = $%d, unit_id = (SELECT units_id FROM units WHERE code = LOWER(TRIM($%d)))", argPos, argPos))
 		args = append(args, *req.Unit)
 		argPos++
 	}
@@ -308,7 +480,19 @@ func UpdateItem(c *fiber.Ctx) error {
 		argPos++
 	}
 
-	if len(updateFields) == 0 {
+	if req.TrackSerial != nil {
+		updateFields = append(updateFields, fmt.Sprintf("track_serial = $%d", argPos))
+		args = append(args, *req.TrackSerial)
+		argPos++
+	}
+
+	if req.TrackLot != nil {
+		updateFields = append(updateFields, fmt.Sprintf("track_lot = $%d", argPos))
+		args = append(args, *req.TrackLot)
+		argPos++
+	}
+
+	if len(updateFields) == 0 && req.Stock == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
 			"message": "No fields to update",
@@ -325,18 +509,98 @@ func UpdateItem(c *fiber.Ctx) error {
 		UPDATE items
 		SET %s
 		WHERE items_id = $%d
-		RETURNING items_id, name, stock, price, category, unit, min_stock, created_at, updated_at
+		RETURNING items_id, name, sku, stock, price, avg_cost, category, category_id, unit, unit_id, purchase_unit_id, min_stock, track_serial, track_lot, created_at, updated_at
 	`, strings.Join(updateFields, ", "), argPos)
 
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	// Stock movements posted since the item was read above change stock, so
+	// the correction is worked out from the locked row.
+	var oldPrice float64
+	var currentStock int
+	err = tx.QueryRow(ctx, "SELECT price, stock FROM items WHERE items_id = $1 FOR UPDATE", id).Scan(&oldPrice, &currentStock)
+	if err != nil {
+		errors.LogError("Item lock query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update item",
+		})
+	}
+
+	if trackingChanged && currentStock != 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Serial or lot tracking can only be changed while the item has no stock",
+		})
+	}
+
+	// A direct stock correction is booked against the default warehouse.
+	if req.Stock != nil && *req.Stock != currentStock {
+		delta := *req.Stock - currentStock
+		warehouseId, err := defaultWarehouseId(ctx, tx)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "No default warehouse is configured",
+			})
+		}
+
+		available, err := warehouseStock(ctx, tx, id, warehouseId)
+		if err == nil && available+delta < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Only %d can be removed from the default warehouse; transfer stock back first", available),
+			})
+		}
+		if err == nil {
+			err = changeWarehouseStock(ctx, tx, id, warehouseId, delta)
+		}
+		if err != nil {
+			return warehouseStockError(c, err)
+		}
+
+		if _, err = bookInventoryCost(ctx, tx, id, warehouseId, "item_update", id, delta); err != nil {
+			errors.LogError("Inventory cost booking error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to cost stock adjustment",
+			})
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE items SET stock = stock + $1 WHERE items_id = $2", delta, id)
+		if err != nil {
+			errors.LogError("Item stock update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update item stock",
+			})
+		}
+	}
+
 	var item models.Items
-	err = database.DB.QueryRow(ctx, query, args...).Scan(
+	err = tx.QueryRow(ctx, query, args...).Scan(
 		&item.ItemsId,
 		&item.Name,
+		&item.Sku,
 		&item.Stock,
 		&item.Price,
+		&item.AvgCost,
 		&item.Category,
+		&item.CategoryId,
 		&item.Unit,
+		&item.UnitId,
+		&item.PurchaseUnitId,
 		&item.MinStock,
+		&item.TrackSerial,
+		&item.TrackLot,
 		&item.CreatedAt,
 		&item.UpdatedAt,
 	)
@@ -349,6 +613,33 @@ func UpdateItem(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Price != nil && item.Price != oldPrice {
+		userId := ""
+		if claims, ok := c.Locals("user").(*jwt.Claims); ok {
+			userId = claims.UserID
+		}
+		reason := ""
+		if req.PriceReason != nil {
+			reason = *req.PriceReason
+		}
+
+		if err = recordPriceChange(ctx, tx, id, oldPrice, item.Price, userId, reason); err != nil {
+			errors.LogError("Item price change record error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to record price change",
+			})
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Item updated successfully",
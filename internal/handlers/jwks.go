@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"fleetify/pkg/jwt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS publishes the public keys access tokens are signed with, in the
+// standard JWKS format rather than the usual response envelope, so other
+// services can verify our tokens. Keys appear here before they start signing
+// and stay for the grace period after they were replaced.
+func GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=60")
+	return c.JSON(jwt.PublicKeys())
+}
@@ -0,0 +1,654 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"sync"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/internal/permissions"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/oidc"
+	"fleetify/pkg/password"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type OIDCCallbackRequest struct {
+	Code             string `json:"code"`
+	State            string `json:"state"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// oidcProvider is discovered on first use and kept, so a provider that is
+// down while the server starts does not disable single sign-on.
+var oidcProvider struct {
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func oidcEnabled() bool {
+	return config.AppConfig.OIDC.Issuer != ""
+}
+
+func getOIDCProvider(ctx context.Context) (*oidc.Provider, error) {
+	oidcProvider.mu.Lock()
+	defer oidcProvider.mu.Unlock()
+
+	if oidcProvider.provider != nil {
+		return oidcProvider.provider, nil
+	}
+
+	cfg := config.AppConfig.OIDC
+	provider, err := oidc.Discover(ctx, oidc.Config{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       strings.Fields(cfg.Scopes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	oidcProvider.provider = provider
+	return provider, nil
+}
+
+func oidcStateTTL() time.Duration {
+	ttl, err := time.ParseDuration(config.AppConfig.OIDC.StateExpiresIn)
+	if err != nil || ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return ttl
+}
+
+// oidcStateCookie carries the state to the callback as well, so a code and
+// state captured from another browser cannot complete a login in this one.
+const oidcStateCookie = "oidc_state"
+
+func setOIDCStateCookie(c *fiber.Ctx, state string, ttl time.Duration) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/v1/auth/oidc",
+		MaxAge:   int(ttl.Seconds()),
+		Secure:   config.AppConfig.Server.Env == "production",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func clearOIDCStateCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/api/v1/auth/oidc",
+		Expires:  time.Unix(0, 0),
+		Secure:   config.AppConfig.Server.Env == "production",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// oidcRole maps the user's groups to a role with the OIDC_ROLE_MAPPING
+// rules, in the order they are configured. It returns "" when no rule
+// matches and there is no default role.
+func oidcRole(groups []string) string {
+	member := map[string]bool{}
+	for _, group := range groups {
+		member[group] = true
+	}
+
+	for _, rule := range strings.Split(config.AppConfig.OIDC.RoleMapping, ",") {
+		group, role, ok := strings.Cut(strings.TrimSpace(rule), "=")
+		if !ok {
+			continue
+		}
+		if member[strings.TrimSpace(group)] {
+			return strings.ToUpper(strings.TrimSpace(role))
+		}
+	}
+	return strings.ToUpper(strings.TrimSpace(config.AppConfig.OIDC.DefaultRole))
+}
+
+// OIDCLogin starts a single sign-on login. The client sends the user's
+// browser to the returned authorization_url; the provider redirects back to
+// OIDC_REDIRECT_URL with a code and state for OIDCCallback. The state is
+// also set as a cookie, which the callback has to come with.
+func OIDCLogin(c *fiber.Ctx) error {
+	if !oidcEnabled() {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Single sign-on is not configured",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := getOIDCProvider(ctx)
+	if err != nil {
+		errors.LogError("OIDC discovery error", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   true,
+			"message": "Identity provider is unavailable",
+		})
+	}
+
+	state, stateHash, err := jwt.NewOpaqueToken()
+	if err != nil {
+		errors.LogError("OIDC state generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start single sign-on",
+		})
+	}
+	nonce, _, err := jwt.NewOpaqueToken()
+	if err != nil {
+		errors.LogError("OIDC nonce generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start single sign-on",
+		})
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		errors.LogError("OIDC PKCE generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start single sign-on",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM oidc_login_states WHERE expires_at < NOW()")
+	if err != nil {
+		errors.LogError("OIDC state cleanup error", err)
+	}
+
+	ttl := oidcStateTTL()
+	now := time.Now()
+	_, err = database.DB.Exec(ctx, `
+		INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, expires_at, ip_address, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+	`, stateHash, nonce, verifier, now.Add(ttl), c.IP(), now)
+	if err != nil {
+		errors.LogError("OIDC state insert error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start single sign-on",
+		})
+	}
+
+	setOIDCStateCookie(c, state, ttl)
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"authorization_url": provider.AuthCodeURL(state, nonce, challenge),
+			"expires_in":        formatTTL(ttl),
+		},
+	})
+}
+
+// OIDCCallback completes a single sign-on login with the code and state the
+// provider returned. The identity is matched to a local user by its issuer
+// and subject; a new identity is linked to the one unlinked user with the
+// same verified email unless that user has to link it through OIDCLink, or
+// provisioned when OIDC_AUTO_PROVISION is on. The response is the same as
+// Login's.
+func OIDCCallback(c *fiber.Ctx) error {
+	if !oidcEnabled() {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Single sign-on is not configured",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, claims, status, message := oidcIdentity(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to complete single sign-on",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	user, status, message := oidcUser(ctx, tx, provider.Issuer, claims)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	if !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Account is inactive",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to complete single sign-on",
+		})
+	}
+
+	if user.TwoFactorEnabled {
+		challengeToken, ttl, err := startLoginChallenge(ctx, c, user.UsersId)
+		if err != nil {
+			errors.LogError("Login challenge error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to start two-factor login",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"error":   false,
+			"message": "Two-factor authentication required",
+			"data": fiber.Map{
+				"two_factor_required": true,
+				"challenge_token":     challengeToken,
+				"expires_in":          formatTTL(ttl),
+			},
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < NOW()", user.UsersId)
+	if err != nil {
+		errors.LogError("Refresh token cleanup error", err)
+	}
+
+	response, _, err := issueTokens(ctx, database.DB, c, user, uuid.New().String())
+	if err != nil {
+		errors.LogError("Token generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate token",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  response,
+	})
+}
+
+// OIDCLink links a single sign-on identity to the signed-in user. The
+// client starts with OIDCLogin as for a login and posts the code and state
+// here instead of to OIDCCallback. This is how accounts that are not linked
+// by email, such as administrators, start using single sign-on.
+func OIDCLink(c *fiber.Ctx) error {
+	if !oidcEnabled() {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Single sign-on is not configured",
+		})
+	}
+
+	user, ok := c.Locals("user").(*jwt.Claims)
+	if !ok || user.APIKeyID != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, claims, status, message := oidcIdentity(ctx, c)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+	subject := claims.String("sub")
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to link single sign-on identity",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var linkedSubject string
+	err = tx.QueryRow(ctx, "SELECT oidc_subject FROM users WHERE users_id = $1 FOR UPDATE", user.UserID).Scan(&linkedSubject)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+	if linkedSubject != "" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Your account is already linked to a single sign-on identity",
+		})
+	}
+
+	var owner string
+	err = tx.QueryRow(ctx, "SELECT users_id FROM users WHERE oidc_issuer = $1 AND oidc_subject = $2", provider.Issuer, subject).Scan(&owner)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "This identity is already linked to another account",
+		})
+	}
+	if err != pgx.ErrNoRows {
+		errors.LogError("OIDC identity lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to link single sign-on identity",
+		})
+	}
+
+	if err := linkOIDCIdentity(ctx, tx, user.UserID, provider.Issuer, subject); err != nil {
+		errors.LogError("OIDC link error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to link single sign-on identity",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to link single sign-on identity",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Single sign-on identity linked to your account",
+	})
+}
+
+// oidcIdentity checks the code and state the provider returned against the
+// login started in this browser and returns the verified ID token claims. A
+// non-zero status is the response to send instead.
+func oidcIdentity(ctx context.Context, c *fiber.Ctx) (*oidc.Provider, oidc.Claims, int, string) {
+	var req OIDCCallbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, nil, fiber.StatusBadRequest, "Invalid request body"
+	}
+
+	if req.Error != "" {
+		message := "Identity provider refused the login: " + req.Error
+		if req.ErrorDescription != "" {
+			message += " (" + req.ErrorDescription + ")"
+		}
+		return nil, nil, fiber.StatusUnauthorized, message
+	}
+
+	if req.Code == "" || req.State == "" {
+		return nil, nil, fiber.StatusBadRequest, "Code and state are required"
+	}
+
+	cookie := c.Cookies(oidcStateCookie)
+	clearOIDCStateCookie(c)
+	if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(req.State)) != 1 {
+		return nil, nil, fiber.StatusBadRequest, "Login state does not belong to this browser. Start the login again"
+	}
+
+	// A state is only good for one attempt, whatever its outcome.
+	var loginState models.OidcLoginStates
+	err := database.DB.QueryRow(ctx, `
+		DELETE FROM oidc_login_states WHERE state_hash = $1
+		RETURNING nonce, code_verifier, expires_at
+	`, jwt.HashOpaqueToken(req.State)).Scan(
+		&loginState.Nonce,
+		&loginState.CodeVerifier,
+		&loginState.ExpiresAt,
+	)
+	if err != nil || time.Now().After(loginState.ExpiresAt) {
+		return nil, nil, fiber.StatusBadRequest, "Invalid or expired login state. Start the login again"
+	}
+
+	provider, err := getOIDCProvider(ctx)
+	if err != nil {
+		errors.LogError("OIDC discovery error", err)
+		return nil, nil, fiber.StatusBadGateway, "Identity provider is unavailable"
+	}
+
+	idToken, err := provider.Exchange(ctx, req.Code, loginState.CodeVerifier)
+	if err != nil {
+		errors.LogError("OIDC code exchange error", err)
+		return nil, nil, fiber.StatusUnauthorized, "Single sign-on failed"
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, idToken, loginState.Nonce)
+	if err != nil {
+		errors.LogError("OIDC ID token error", err)
+		return nil, nil, fiber.StatusUnauthorized, "Single sign-on failed"
+	}
+
+	return provider, claims, 0, ""
+}
+
+const oidcUserColumns = `users_id, username, role, full_name, email, phone, is_active, must_change_password, two_factor_enabled`
+
+func scanOIDCUser(row pgx.Row, user *models.Users) error {
+	return row.Scan(
+		&user.UsersId,
+		&user.Username,
+		&user.Role,
+		&user.FullName,
+		&user.Email,
+		&user.Phone,
+		&user.IsActive,
+		&user.MustChangePassword,
+		&user.TwoFactorEnabled,
+	)
+}
+
+// oidcUser finds, links or provisions the local user for a verified
+// identity and applies the role its groups map to. A non-zero status is
+// the response to send instead. With OIDC_SYNC_ROLES a changed role takes
+// effect for tokens issued from now on, including refreshes of existing
+// sessions.
+func oidcUser(ctx context.Context, tx pgx.Tx, issuer string, claims oidc.Claims) (models.Users, int, string) {
+	cfg := config.AppConfig.OIDC
+	subject := claims.String("sub")
+	email := strings.TrimSpace(claims.String("email"))
+	role := oidcRole(claims.Strings(cfg.GroupsClaim))
+	now := time.Now()
+
+	var user models.Users
+	err := scanOIDCUser(tx.QueryRow(ctx, `
+		SELECT `+oidcUserColumns+` FROM users
+		WHERE oidc_issuer = $1 AND oidc_subject = $2
+		FOR UPDATE
+	`, issuer, subject), &user)
+
+	if err == pgx.ErrNoRows && email != "" && claims.Bool("email_verified") {
+		var candidates int
+		candidates, err = oidcUserByEmail(ctx, tx, email, &user)
+		if err == nil && candidates == 0 {
+			err = pgx.ErrNoRows
+		}
+		if err == nil && candidates > 1 {
+			return user, fiber.StatusConflict, "Several accounts use this email address. Sign in with your password and link your account"
+		}
+		if err == nil {
+			linkable, linkErr := oidcLinkableByEmail(ctx, user)
+			if linkErr != nil {
+				errors.LogError("OIDC link check error", linkErr)
+				return user, fiber.StatusInternalServerError, "Failed to complete single sign-on"
+			}
+			if !linkable {
+				return user, fiber.StatusForbidden, "This account has to be linked explicitly. Sign in with your password and link your account"
+			}
+			err = linkOIDCIdentity(ctx, tx, user.UsersId, issuer, subject)
+		}
+	}
+
+	if err == pgx.ErrNoRows {
+		if !cfg.AutoProvision {
+			return user, fiber.StatusForbidden, "No account is linked to this identity"
+		}
+		if role == "" {
+			return user, fiber.StatusForbidden, "Your groups do not grant access to this application"
+		}
+		return oidcProvision(ctx, tx, issuer, subject, email, role, claims)
+	}
+	if err != nil {
+		errors.LogError("OIDC user lookup error", err)
+		return user, fiber.StatusInternalServerError, "Failed to complete single sign-on"
+	}
+
+	if cfg.SyncRoles && user.Role != role {
+		if role == "" {
+			return user, fiber.StatusForbidden, "Your groups no longer grant access to this application"
+		}
+		if !roleExists(ctx, tx, role) {
+			errors.LogErrorf("OIDC role mapping error", "mapped role %s does not exist", role)
+			return user, fiber.StatusForbidden, "Your groups map to an unknown role"
+		}
+		_, err = tx.Exec(ctx, "UPDATE users SET role = $1, updated_at = $2 WHERE users_id = $3", role, now, user.UsersId)
+		if err != nil {
+			errors.LogError("OIDC role sync error", err)
+			return user, fiber.StatusInternalServerError, "Failed to complete single sign-on"
+		}
+		user.Role = role
+	}
+
+	return user, 0, ""
+}
+
+// oidcUserByEmail finds the unlinked user with the email address and
+// returns how many there are (at most two).
+func oidcUserByEmail(ctx context.Context, tx pgx.Tx, email string, user *models.Users) (int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+oidcUserColumns+` FROM users
+		WHERE LOWER(email) = LOWER($1) AND oidc_subject = ''
+		LIMIT 2
+		FOR UPDATE
+	`, email)
+	if err != nil {
+		return 0, err
+	}
+
+	found := 0
+	for rows.Next() {
+		if err := scanOIDCUser(rows, user); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		found++
+	}
+	rows.Close()
+	return found, rows.Err()
+}
+
+// oidcLinkableByEmail reports whether a matching email is enough to link an
+// identity to user. Accounts with two-factor authentication or with
+// administrative permissions are only linked by their owner after signing in,
+// since whoever controls the email at the provider would otherwise take them
+// over, and with OIDC_SYNC_ROLES replace their role.
+func oidcLinkableByEmail(ctx context.Context, user models.Users) (bool, error) {
+	if user.TwoFactorEnabled || user.Role == permissions.SuperRole || config.AppConfig.TwoFactor.RoleRequired(user.Role) {
+		return false, nil
+	}
+	for _, permission := range []string{permissions.UsersManage, permissions.RolesManage, permissions.DepartmentsAll, permissions.APIKeysAdmin} {
+		granted, err := permissions.Has(ctx, user.Role, permission)
+		if err != nil || granted {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func linkOIDCIdentity(ctx context.Context, q execer, userId, issuer, subject string) error {
+	_, err := q.Exec(ctx, `
+		UPDATE users SET oidc_issuer = $1, oidc_subject = $2, updated_at = $3
+		WHERE users_id = $4
+	`, issuer, subject, time.Now(), userId)
+	return err
+}
+
+// oidcProvision creates a user for a new identity. The user gets a random
+// password nobody knows and signs in through the provider.
+func oidcProvision(ctx context.Context, tx pgx.Tx, issuer, subject, email, role string, claims oidc.Claims) (models.Users, int, string) {
+	var user models.Users
+
+	username := strings.TrimSpace(claims.String(config.AppConfig.OIDC.UsernameClaim))
+	if username == "" {
+		username = email
+	}
+	if username == "" {
+		return user, fiber.StatusBadRequest, "Identity provider did not send a username"
+	}
+
+	fullName := strings.TrimSpace(claims.String("name"))
+	if fullName == "" {
+		fullName = username
+	}
+
+	if !roleExists(ctx, tx, role) {
+		errors.LogErrorf("OIDC role mapping error", "mapped role %s does not exist", role)
+		return user, fiber.StatusForbidden, "Your groups map to an unknown role"
+	}
+
+	var existing string
+	err := tx.QueryRow(ctx, "SELECT users_id FROM users WHERE username = $1", username).Scan(&existing)
+	if err == nil {
+		return user, fiber.StatusConflict, "Username " + username + " is already taken by another account"
+	}
+	if err != pgx.ErrNoRows {
+		errors.LogError("OIDC username lookup error", err)
+		return user, fiber.StatusInternalServerError, "Failed to complete single sign-on"
+	}
+
+	secret, _, err := jwt.NewOpaqueToken()
+	if err != nil {
+		errors.LogError("OIDC password generation error", err)
+		return user, fiber.StatusInternalServerError, "Failed to complete single sign-on"
+	}
+	hashedPassword, err := password.Hash(secret)
+	if err != nil {
+		errors.LogError("Password hashing error", err)
+		return user, fiber.StatusInternalServerError, "Failed to complete single sign-on"
+	}
+
+	now := time.Now()
+	err = scanOIDCUser(tx.QueryRow(ctx, `
+		INSERT INTO users (users_id, username, password, role, full_name, email, phone, is_active,
+			oidc_issuer, oidc_subject, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, '', TRUE, $7, $8, $9, $9)
+		RETURNING `+oidcUserColumns,
+		uuid.New().String(), username, hashedPassword, role, fullName, email, issuer, subject, now), &user)
+	if err != nil {
+		errors.LogError("OIDC user provisioning error", err)
+		return user, fiber.StatusInternalServerError, "Failed to complete single sign-on"
+	}
+
+	return user, 0, ""
+}
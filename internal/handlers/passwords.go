@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/internal/loginguard"
+	"fleetify/internal/revocation"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/notify"
+	"fleetify/pkg/password"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ForgotPasswordRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ChangeOwnPasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// passwordPolicyMessage checks a new password of username against the
+// password policy and returns why it is refused, or "" when it is accepted.
+func passwordPolicyMessage(newPassword, username string) string {
+	if err := password.CurrentPolicy().Validate(newPassword, username); err != nil {
+		message := err.Error()
+		return strings.ToUpper(message[:1]) + message[1:]
+	}
+	return ""
+}
+
+// passwordReuseMessage refuses a new password that matches the user's current
+// password or one it recently replaced.
+func passwordReuseMessage(ctx context.Context, userId, newPassword string) (string, error) {
+	history := password.CurrentPolicy().History
+	if history <= 0 {
+		return "", nil
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT hash FROM (
+			SELECT password AS hash, NOW() AS changed_at FROM users WHERE users_id = $1
+			UNION ALL
+			SELECT password_hash, created_at FROM password_histories WHERE user_id = $1
+		) h
+		ORDER BY changed_at DESC
+		LIMIT $2
+	`, userId, history)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	hashes := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return "", err
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if password.MatchesAny(newPassword, hashes) {
+		if history == 1 {
+			return "New password must differ from the current password", nil
+		}
+		return fmt.Sprintf("Password must not be one of your last %d passwords", history), nil
+	}
+	return "", nil
+}
+
+// archivePassword keeps the user's current password hash in the history
+// before it is replaced, trimmed to what the policy still needs.
+func archivePassword(ctx context.Context, q execer, userId string) error {
+	keep := password.CurrentPolicy().History - 1
+	if keep <= 0 {
+		_, err := q.Exec(ctx, "DELETE FROM password_histories WHERE user_id = $1", userId)
+		return err
+	}
+
+	_, err := q.Exec(ctx, `
+		INSERT INTO password_histories (user_id, password_hash, created_at, updated_at)
+		SELECT users_id, password, $2, $2 FROM users WHERE users_id = $1
+	`, userId, time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(ctx, `
+		DELETE FROM password_histories
+		WHERE user_id = $1 AND password_histories_id NOT IN (
+			SELECT password_histories_id FROM password_histories
+			WHERE user_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		)
+	`, userId, keep)
+	return err
+}
+
+// setPassword replaces the user's password hash, archiving the old one.
+func setPassword(ctx context.Context, userId, hashedPassword string, mustChange bool) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := archivePassword(ctx, tx, userId); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET password = $1, must_change_password = $2, updated_at = $3
+		WHERE users_id = $4
+	`, hashedPassword, mustChange, time.Now(), userId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// passwordResetTTL is how long a reset token can be used.
+func passwordResetTTL() time.Duration {
+	ttl, err := time.ParseDuration(config.AppConfig.Password.ResetExpiresIn)
+	if err != nil || ttl <= 0 {
+		ttl = time.Hour
+	}
+	return ttl
+}
+
+func passwordResetMessage(email, username, token string, ttl time.Duration) notify.Message {
+	var body strings.Builder
+	body.WriteString("Hello " + username + ",\n\n")
+	body.WriteString("A password reset was requested for your Fleetify account.\n\n")
+	if url := config.AppConfig.Password.ResetURL; url != "" {
+		body.WriteString("Open this link to choose a new password:\n" + strings.ReplaceAll(url, "{token}", token) + "\n\n")
+	} else {
+		body.WriteString("Use this token to choose a new password:\n" + token + "\n\n")
+	}
+	body.WriteString("It expires in " + formatTTL(ttl) + " and works once. If you did not ask for this, ignore this mail.\n")
+
+	return notify.Message{
+		To:      email,
+		Subject: "Reset your Fleetify password",
+		Body:    body.String(),
+	}
+}
+
+// ForgotPassword mails a single-use reset token to the accounts matching the
+// username or email. It answers the same whether or not an account matched,
+// so it cannot be used to find out which accounts exist.
+func ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Username == "" && req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Username or email is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT users_id, username, email
+		FROM users
+		WHERE is_active AND COALESCE(email, '') <> ''
+		AND (username = $1 OR ($2 <> '' AND LOWER(email) = LOWER($2)))
+	`, req.Username, req.Email)
+	if err != nil {
+		errors.LogError("Password reset lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to request password reset",
+		})
+	}
+
+	type recipient struct {
+		userId, username, email string
+	}
+	recipients := []recipient{}
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.userId, &r.username, &r.email); err != nil {
+			rows.Close()
+			errors.LogError("Password reset lookup error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to request password reset",
+			})
+		}
+		recipients = append(recipients, r)
+	}
+	rows.Close()
+
+	ttl := passwordResetTTL()
+	now := time.Now()
+	for _, r := range recipients {
+		token, tokenHash, err := jwt.NewOpaqueToken()
+		if err != nil {
+			errors.LogError("Password reset token generation error", err)
+			continue
+		}
+
+		// Only the latest reset link of a user works.
+		_, err = database.DB.Exec(ctx, "DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL", r.userId)
+		if err == nil {
+			_, err = database.DB.Exec(ctx, `
+				INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, ip_address, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $5)
+			`, r.userId, tokenHash, now.Add(ttl), c.IP(), now)
+		}
+		if err != nil {
+			errors.LogError("Password reset token creation error", err)
+			continue
+		}
+
+		// Mail is sent in the background so the response time does not tell
+		// whether an account matched.
+		msg := passwordResetMessage(r.email, r.username, token, ttl)
+		go func() {
+			sendCtx, sendCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer sendCancel()
+			if err := notify.Default.Send(sendCtx, msg); err != nil {
+				errors.LogError("Password reset mail error", err)
+			}
+		}()
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "If the account exists, password reset instructions have been sent",
+	})
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. The
+// token works once; every session of the user is logged out.
+func ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Token is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset password",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var tokenId, userId, username string
+	var expiresAt time.Time
+	var usedAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT t.password_reset_tokens_id, t.user_id, u.username, t.expires_at, t.used_at
+		FROM password_reset_tokens t
+		JOIN users u ON t.user_id = u.users_id
+		WHERE t.token_hash = $1 AND u.is_active
+		FOR UPDATE OF t
+	`, jwt.HashOpaqueToken(req.Token)).Scan(&tokenId, &userId, &username, &expiresAt, &usedAt)
+	if err != nil || usedAt != nil || time.Now().After(expiresAt) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired reset token",
+		})
+	}
+
+	if message := passwordPolicyMessage(req.NewPassword, username); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	message, err := passwordReuseMessage(ctx, userId, req.NewPassword)
+	if err != nil {
+		errors.LogError("Password history lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset password",
+		})
+	}
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	hashedPassword, err := password.Hash(req.NewPassword)
+	if err != nil {
+		errors.LogError("Password hashing error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process password",
+		})
+	}
+
+	if err = archivePassword(ctx, tx, userId); err != nil {
+		errors.LogError("Password history update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset password",
+		})
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(ctx, `
+		UPDATE users SET password = $1, must_change_password = false, updated_at = $2
+		WHERE users_id = $3
+	`, hashedPassword, now, userId)
+	if err != nil {
+		errors.LogError("Password update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset password",
+		})
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE password_reset_tokens SET used_at = $1, updated_at = $1 WHERE password_reset_tokens_id = $2", now, tokenId)
+	if err != nil {
+		errors.LogError("Password reset token update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset password",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset password",
+		})
+	}
+
+	if err := revocation.RevokeUser(ctx, userId, "password_reset"); err != nil {
+		errors.LogError("Password reset revoke error", err)
+	}
+	if err := loginguard.Unlock(ctx, username); err != nil {
+		errors.LogError("User unlock error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Password has been reset. Please log in again",
+	})
+}
+
+// ChangeOwnPassword lets the caller change their password by proving the
+// current one. A wrong current password counts as a failed login. It also
+// clears must_change_password; all sessions, including the current one, are
+// logged out.
+func ChangeOwnPassword(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req ChangeOwnPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "New password must differ from the current password",
+		})
+	}
+
+	if message := passwordPolicyMessage(req.NewPassword, claims.Username); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ip := c.IP()
+	block, err := loginguard.Check(ctx, claims.Username, ip)
+	if err != nil {
+		errors.LogError("Login guard check error", err)
+	}
+	if block != nil {
+		return blockedLogin(c, block)
+	}
+
+	var currentHash string
+	err = database.DB.QueryRow(ctx, "SELECT password FROM users WHERE users_id = $1 AND is_active", claims.UserID).Scan(&currentHash)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if !password.Verify(req.CurrentPassword, currentHash) {
+		failLogin(ctx, claims.Username, ip)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Current password is incorrect",
+		})
+	}
+
+	message, err := passwordReuseMessage(ctx, claims.UserID, req.NewPassword)
+	if err != nil {
+		errors.LogError("Password history lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update password",
+		})
+	}
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	hashedPassword, err := password.Hash(req.NewPassword)
+	if err != nil {
+		errors.LogError("Password hashing error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process password",
+		})
+	}
+
+	if err = setPassword(ctx, claims.UserID, hashedPassword, false); err != nil {
+		errors.LogError("Password update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update password",
+		})
+	}
+
+	if err := revocation.RevokeUser(ctx, claims.UserID, "password_changed"); err != nil {
+		errors.LogError("Password change revoke error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Password changed successfully. Please log in again",
+	})
+}
@@ -16,11 +16,13 @@ import (
 type CreatePurchasingDetailRequest struct {
 	PurchasingId string `json:"purchasing_id" validate:"required"`
 	ItemId       string `json:"item_id" validate:"required"`
+	UnitId       string `json:"unit_id"`
 	Qty          int    `json:"qty" validate:"required,gt=0"`
 }
 
 type UpdatePurchasingDetailRequest struct {
 	ItemId *string `json:"item_id"`
+	UnitId *string `json:"unit_id"`
 	Qty    *int    `json:"qty"`
 }
 
@@ -36,7 +38,7 @@ func GetPurchasingDetails(c *fiber.Ctx) error {
 		"item_id":        "item_id",
 	}
 
-	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	whereClause, whereArgs := query.BuildScopedWhereClause(params, searchFields, filterFields, "purchasing_details", "")
 	orderClause := query.BuildOrderClause(params, "purchasing_details_id")
 	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
 
@@ -53,7 +55,7 @@ func GetPurchasingDetails(c *fiber.Ctx) error {
 	}
 
 	baseQuery := `
-		SELECT purchasing_details_id, purchasing_id, item_id, qty, subtotal
+		SELECT purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal
 		FROM purchasing_details
 	`
 	
@@ -77,6 +79,9 @@ func GetPurchasingDetails(c *fiber.Ctx) error {
 			&detail.PurchasingDetailsId,
 			&detail.PurchasingId,
 			&detail.ItemId,
+			&detail.UnitId,
+			&detail.UnitQty,
+			&detail.Factor,
 			&detail.Qty,
 			&detail.Subtotal,
 		)
@@ -119,14 +124,13 @@ func GetPurchasingDetailsByPurchasingId(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	whereClause, whereArgs := query.ScopeTable("WHERE purchasing_id = $1", []interface{}{purchasingId}, query.ScopeFrom(c), "purchasing_details", "")
 	query := `
-		SELECT purchasing_details_id, purchasing_id, item_id, qty, subtotal
+		SELECT purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal
 		FROM purchasing_details
-		WHERE purchasing_id = $1
-		ORDER BY purchasing_details_id
-	`
+	` + whereClause + " ORDER BY purchasing_details_id"
 
-	rows, err := database.DB.Query(ctx, query, purchasingId)
+	rows, err := database.DB.Query(ctx, query, whereArgs...)
 	if err != nil {
 		errors.LogError("Get purchasing details query error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -143,6 +147,9 @@ func GetPurchasingDetailsByPurchasingId(c *fiber.Ctx) error {
 			&detail.PurchasingDetailsId,
 			&detail.PurchasingId,
 			&detail.ItemId,
+			&detail.UnitId,
+			&detail.UnitQty,
+			&detail.Factor,
 			&detail.Qty,
 			&detail.Subtotal,
 		)
@@ -172,17 +179,21 @@ func GetPurchasingDetailById(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	whereClause, whereArgs := query.ScopeTable("WHERE purchasing_details_id = $1", []interface{}{id}, query.ScopeFrom(c), "purchasing_details", "")
+
 	var detail models.PurchasingDetails
 	query := `
-		SELECT purchasing_details_id, purchasing_id, item_id, qty, subtotal
+		SELECT purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal
 		FROM purchasing_details
-		WHERE purchasing_details_id = $1
-	`
+	` + whereClause
 
-	err := database.DB.QueryRow(ctx, query, id).Scan(
+	err := database.DB.QueryRow(ctx, query, whereArgs...).Scan(
 		&detail.PurchasingDetailsId,
 		&detail.PurchasingId,
 		&detail.ItemId,
+		&detail.UnitId,
+		&detail.UnitQty,
+		&detail.Factor,
 		&detail.Qty,
 		&detail.Subtotal,
 	)
@@ -213,7 +224,8 @@ func CreatePurchasingDetail(c *fiber.Ctx) error {
 	defer cancel()
 
 	var purchasingExists string
-	err := database.DB.QueryRow(ctx, "SELECT purchasings_id FROM purchasings WHERE purchasings_id = $1", req.PurchasingId).Scan(&purchasingExists)
+	scopeClause, scopeArgs := query.ScopeTable("WHERE p.purchasings_id = $1", []interface{}{req.PurchasingId}, query.ScopeFrom(c), "purchasings", "p")
+	err := database.DB.QueryRow(ctx, "SELECT purchasings_id FROM purchasings p "+scopeClause, scopeArgs...).Scan(&purchasingExists)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
@@ -230,24 +242,38 @@ func CreatePurchasingDetail(c *fiber.Ctx) error {
 		})
 	}
 
-	subtotal := itemPrice * float64(req.Qty)
+	quantity, message := convertToStockUnits(ctx, database.DB, req.ItemId, req.UnitId, req.Qty)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	subtotal := itemPrice * float64(quantity.Qty)
 
 	query := `
-		INSERT INTO purchasing_details (purchasing_id, item_id, qty, subtotal)
-		VALUES ($1, $2, $3, $4)
-		RETURNING purchasing_details_id, purchasing_id, item_id, qty, subtotal
+		INSERT INTO purchasing_details (purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal
 	`
 
 	var detail models.PurchasingDetails
 	err = database.DB.QueryRow(ctx, query,
 		req.PurchasingId,
 		req.ItemId,
-		req.Qty,
+		quantity.UnitId,
+		quantity.UnitQty,
+		quantity.Factor,
+		quantity.Qty,
 		subtotal,
 	).Scan(
 		&detail.PurchasingDetailsId,
 		&detail.PurchasingId,
 		&detail.ItemId,
+		&detail.UnitId,
+		&detail.UnitQty,
+		&detail.Factor,
 		&detail.Qty,
 		&detail.Subtotal,
 	)
@@ -302,12 +328,14 @@ func UpdatePurchasingDetail(c *fiber.Ctx) error {
 	defer cancel()
 
 	var existingDetail models.PurchasingDetails
-	checkQuery := `SELECT purchasing_details_id, purchasing_id, item_id, qty FROM purchasing_details WHERE purchasing_details_id = $1`
-	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(
+	scopeClause, scopeArgs := query.ScopeTable("WHERE purchasing_details_id = $1", []interface{}{id}, query.ScopeFrom(c), "purchasing_details", "")
+	checkQuery := `SELECT purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty FROM purchasing_details ` + scopeClause
+	err := database.DB.QueryRow(ctx, checkQuery, scopeArgs...).Scan(
 		&existingDetail.PurchasingDetailsId,
 		&existingDetail.PurchasingId,
 		&existingDetail.ItemId,
-		&existingDetail.Qty,
+		&existingDetail.UnitId,
+		&existingDetail.UnitQty,
 	)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -317,7 +345,11 @@ func UpdatePurchasingDetail(c *fiber.Ctx) error {
 	}
 
 	itemId := existingDetail.ItemId
-	qty := existingDetail.Qty
+	qty := existingDetail.UnitQty
+	unitId := ""
+	if existingDetail.UnitId != nil {
+		unitId = *existingDetail.UnitId
+	}
 
 	if req.ItemId != nil {
 		var itemExists string
@@ -328,9 +360,16 @@ func UpdatePurchasingDetail(c *fiber.Ctx) error {
 				"message": "Item not found",
 			})
 		}
+		if *req.ItemId != itemId {
+			unitId = ""
+		}
 		itemId = *req.ItemId
 	}
 
+	if req.UnitId != nil {
+		unitId = *req.UnitId
+	}
+
 	if req.Qty != nil {
 		if *req.Qty <= 0 {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -350,7 +389,15 @@ func UpdatePurchasingDetail(c *fiber.Ctx) error {
 		})
 	}
 
-	subtotal := itemPrice * float64(qty)
+	quantity, message := convertToStockUnits(ctx, database.DB, itemId, unitId, qty)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	subtotal := itemPrice * float64(quantity.Qty)
 
 	updateFields := []string{}
 	args := []interface{}{}
@@ -362,19 +409,29 @@ func UpdatePurchasingDetail(c *fiber.Ctx) error {
 		argPos++
 	}
 
-	if req.Qty != nil {
-		updateFields = append(updateFields, fmt.Sprintf("qty = $%d", argPos))
-		args = append(args, qty)
-		argPos++
-	}
-
-	if len(updateFields) == 0 {
+	if len(updateFields) == 0 && req.Qty == nil && req.UnitId == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
 			"message": "No fields to update",
 		})
 	}
 
+	updateFields = append(updateFields, fmt.Sprintf("unit_id = $%d", argPos))
+	args = append(args, quantity.UnitId)
+	argPos++
+
+	updateFields = append(updateFields, fmt.Sprintf("unit_qty = $%d", argPos))
+	args = append(args, quantity.UnitQty)
+	argPos++
+
+	updateFields = append(updateFields, fmt.Sprintf("factor = $%d", argPos))
+	args = append(args, quantity.Factor)
+	argPos++
+
+	updateFields = append(updateFields, fmt.Sprintf("qty = $%d", argPos))
+	args = append(args, quantity.Qty)
+	argPos++
+
 	updateFields = append(updateFields, fmt.Sprintf("subtotal = $%d", argPos))
 	args = append(args, subtotal)
 
@@ -383,7 +440,7 @@ func UpdatePurchasingDetail(c *fiber.Ctx) error {
 		UPDATE purchasing_details
 		SET %s
 		WHERE purchasing_details_id = $%d
-		RETURNING purchasing_details_id, purchasing_id, item_id, qty, subtotal
+		RETURNING purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal
 	`, strings.Join(updateFields, ", "), len(args))
 
 	var detail models.PurchasingDetails
@@ -391,6 +448,9 @@ func UpdatePurchasingDetail(c *fiber.Ctx) error {
 		&detail.PurchasingDetailsId,
 		&detail.PurchasingId,
 		&detail.ItemId,
+		&detail.UnitId,
+		&detail.UnitQty,
+		&detail.Factor,
 		&detail.Qty,
 		&detail.Subtotal,
 	)
@@ -437,8 +497,9 @@ func DeletePurchasingDetail(c *fiber.Ctx) error {
 	defer cancel()
 
 	var existingDetail models.PurchasingDetails
-	checkQuery := `SELECT purchasing_details_id, purchasing_id FROM purchasing_details WHERE purchasing_details_id = $1`
-	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingDetail.PurchasingDetailsId, &existingDetail.PurchasingId)
+	scopeClause, scopeArgs := query.ScopeTable("WHERE purchasing_details_id = $1", []interface{}{id}, query.ScopeFrom(c), "purchasing_details", "")
+	checkQuery := `SELECT purchasing_details_id, purchasing_id FROM purchasing_details ` + scopeClause
+	err := database.DB.QueryRow(ctx, checkQuery, scopeArgs...).Scan(&existingDetail.PurchasingDetailsId, &existingDetail.PurchasingId)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
@@ -11,38 +11,59 @@ import (
 	"fleetify/internal/config"
 	"fleetify/internal/database"
 	"fleetify/internal/models"
+	"fleetify/internal/permissions"
 	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
 	"fleetify/pkg/query"
 	"fleetify/pkg/webhook"
 )
 
 type PurchasingDetailRequest struct {
 	ItemId string `json:"item_id" validate:"required"`
+	UnitId string `json:"unit_id"`
 	Qty    int    `json:"qty" validate:"required,gt=0"`
 }
 
 type CreatePurchasingRequest struct {
-	Date       string                 `json:"date" validate:"required"`
-	SupplierId string                `json:"supplier_id" validate:"required"`
-	UserId     string                `json:"user_id" validate:"required"`
-	Status     string                 `json:"status"`
-	Notes      string                 `json:"notes"`
-	Details    []PurchasingDetailRequest `json:"details" validate:"required,min=1"`
+	Date         string                    `json:"date" validate:"required"`
+	SupplierId   string                    `json:"supplier_id" validate:"required"`
+	WarehouseId  string                    `json:"warehouse_id"`
+	UserId       string                    `json:"user_id" validate:"required"`
+	DepartmentId string                    `json:"department_id"`
+	Status       string                    `json:"status"`
+	Notes        string                    `json:"notes"`
+	Details      []PurchasingDetailRequest `json:"details" validate:"required,min=1"`
 }
 
 type UpdatePurchasingRequest struct {
-	Date       *string `json:"date"`
-	SupplierId *string `json:"supplier_id"`
-	UserId     *string `json:"user_id"`
-	Status     *string `json:"status"`
-	Notes      *string `json:"notes"`
+	Date         *string `json:"date"`
+	SupplierId   *string `json:"supplier_id"`
+	WarehouseId  *string `json:"warehouse_id"`
+	UserId       *string `json:"user_id"`
+	DepartmentId *string `json:"department_id"`
+	Status       *string `json:"status"`
+	Notes        *string `json:"notes"`
 }
 
 type PurchasingResponse struct {
 	models.Purchasings
-	SupplierName string `json:"supplier_name"`
-	UserName     string `json:"user_name"`
-	Details      []models.PurchasingDetails `json:"details"`
+	SupplierName  string                     `json:"supplier_name"`
+	WarehouseName string                     `json:"warehouse_name"`
+	UserName      string                     `json:"user_name"`
+	Details       []models.PurchasingDetails `json:"details"`
+}
+
+// purchasingStatusAllowed reports whether the caller may set status.
+// Approving a purchasing needs the purchasings:approve permission.
+func purchasingStatusAllowed(ctx context.Context, c *fiber.Ctx, status string) (bool, error) {
+	if !strings.EqualFold(status, "approved") {
+		return true, nil
+	}
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return false, nil
+	}
+	return permissions.Granted(ctx, claims, permissions.PurchasingsApprove)
 }
 
 func GetPurchasings(c *fiber.Ctx) error {
@@ -53,19 +74,35 @@ func GetPurchasings(c *fiber.Ctx) error {
 	
 	searchFields := []string{"p.status", "p.notes", "s.name", "u.full_name"}
 	filterFields := map[string]string{
-		"status":      "p.status",
-		"supplier_id": "p.supplier_id",
-		"user_id":     "p.user_id",
+		"status":        "p.status",
+		"supplier_id":   "p.supplier_id",
+		"warehouse_id":  "p.warehouse_id",
+		"user_id":       "p.user_id",
+		"department_id": "p.department_id",
 	}
 
-	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	whereClause, whereArgs := query.BuildScopedWhereClause(params, searchFields, filterFields, "purchasings", "p")
 	orderClause := query.BuildOrderClause(params, "p.created_at")
 	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
 
+	baseQuery := `
+		SELECT p.purchasings_id, p.date, p.supplier_id, p.warehouse_id, p.user_id, p.department_id, p.grand_total, p.status, p.notes, p.created_at,
+		       s.name as supplier_name, w.name as warehouse_name, u.full_name as user_name
+		FROM purchasings p
+		LEFT JOIN suppliers s ON p.supplier_id = s.suppliers_id
+		LEFT JOIN warehouses w ON p.warehouse_id = w.warehouses_id
+		LEFT JOIN users u ON p.user_id = u.users_id
+	`
+
+	if format := exportFormat(c); format != "" {
+		return streamExport(c, format, "purchasings", baseQuery+" "+whereClause+" "+orderClause, whereArgs)
+	}
+
 	countQuery := fmt.Sprintf(`
 		SELECT COUNT(*)
 		FROM purchasings p
 		LEFT JOIN suppliers s ON p.supplier_id = s.suppliers_id
+		LEFT JOIN warehouses w ON p.warehouse_id = w.warehouses_id
 		LEFT JOIN users u ON p.user_id = u.users_id
 		%s
 	`, whereClause)
@@ -80,14 +117,6 @@ func GetPurchasings(c *fiber.Ctx) error {
 		})
 	}
 
-	baseQuery := `
-		SELECT p.purchasings_id, p.date, p.supplier_id, p.user_id, p.grand_total, p.status, p.notes, p.created_at,
-		       s.name as supplier_name, u.full_name as user_name
-		FROM purchasings p
-		LEFT JOIN suppliers s ON p.supplier_id = s.suppliers_id
-		LEFT JOIN users u ON p.user_id = u.users_id
-	`
-	
 	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
 	allArgs := append(whereArgs, paginationArgs...)
 
@@ -105,17 +134,21 @@ func GetPurchasings(c *fiber.Ctx) error {
 	for rows.Next() {
 		var p PurchasingResponse
 		var supplierName sql.NullString
+		var warehouseName sql.NullString
 		var userName sql.NullString
 		err := rows.Scan(
 			&p.PurchasingsId,
 			&p.Date,
 			&p.SupplierId,
+			&p.WarehouseId,
 			&p.UserId,
+			&p.DepartmentId,
 			&p.GrandTotal,
 			&p.Status,
 			&p.Notes,
 			&p.CreatedAt,
 			&supplierName,
+			&warehouseName,
 			&userName,
 		)
 		if err != nil {
@@ -123,10 +156,11 @@ func GetPurchasings(c *fiber.Ctx) error {
 			continue
 		}
 		p.SupplierName = supplierName.String
+		p.WarehouseName = warehouseName.String
 		p.UserName = userName.String
 
 		detailsQuery := `
-			SELECT purchasing_details_id, purchasing_id, item_id, qty, subtotal
+			SELECT purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal
 			FROM purchasing_details
 			WHERE purchasing_id = $1
 		`
@@ -139,6 +173,9 @@ func GetPurchasings(c *fiber.Ctx) error {
 					&detail.PurchasingDetailsId,
 					&detail.PurchasingId,
 					&detail.ItemId,
+					&detail.UnitId,
+					&detail.UnitQty,
+					&detail.Factor,
 					&detail.Qty,
 					&detail.Subtotal,
 				)
@@ -181,28 +218,34 @@ func GetPurchasingById(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	whereClause, whereArgs := query.ScopeTable("WHERE p.purchasings_id = $1", []interface{}{id}, query.ScopeFrom(c), "purchasings", "p")
+
 	var p PurchasingResponse
 	var supplierName sql.NullString
+	var warehouseName sql.NullString
 	var userName sql.NullString
 	query := `
-		SELECT p.purchasings_id, p.date, p.supplier_id, p.user_id, p.grand_total, p.status, p.notes, p.created_at,
-		       s.name as supplier_name, u.full_name as user_name
+		SELECT p.purchasings_id, p.date, p.supplier_id, p.warehouse_id, p.user_id, p.department_id, p.grand_total, p.status, p.notes, p.created_at,
+		       s.name as supplier_name, w.name as warehouse_name, u.full_name as user_name
 		FROM purchasings p
 		LEFT JOIN suppliers s ON p.supplier_id = s.suppliers_id
+		LEFT JOIN warehouses w ON p.warehouse_id = w.warehouses_id
 		LEFT JOIN users u ON p.user_id = u.users_id
-		WHERE p.purchasings_id = $1
-	`
+	` + whereClause
 
-	err := database.DB.QueryRow(ctx, query, id).Scan(
+	err := database.DB.QueryRow(ctx, query, whereArgs...).Scan(
 		&p.PurchasingsId,
 		&p.Date,
 		&p.SupplierId,
+		&p.WarehouseId,
 		&p.UserId,
+		&p.DepartmentId,
 		&p.GrandTotal,
 		&p.Status,
 		&p.Notes,
 		&p.CreatedAt,
 		&supplierName,
+		&warehouseName,
 		&userName,
 	)
 
@@ -213,10 +256,11 @@ func GetPurchasingById(c *fiber.Ctx) error {
 		})
 	}
 	p.SupplierName = supplierName.String
+	p.WarehouseName = warehouseName.String
 	p.UserName = userName.String
 
 	detailsQuery := `
-		SELECT purchasing_details_id, purchasing_id, item_id, qty, subtotal
+		SELECT purchasing_details_id, purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal
 		FROM purchasing_details
 		WHERE purchasing_id = $1
 	`
@@ -229,6 +273,9 @@ func GetPurchasingById(c *fiber.Ctx) error {
 				&detail.PurchasingDetailsId,
 				&detail.PurchasingId,
 				&detail.ItemId,
+				&detail.UnitId,
+				&detail.UnitQty,
+				&detail.Factor,
 				&detail.Qty,
 				&detail.Subtotal,
 			)
@@ -297,8 +344,25 @@ func CreatePurchasing(c *fiber.Ctx) error {
 		})
 	}
 
+	warehouseId, message := resolveWarehouse(ctx, tx, req.WarehouseId)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	departmentId, code, message := resolveDepartment(ctx, tx, query.ScopeFrom(c), req.DepartmentId)
+	if message != "" {
+		return c.Status(code).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
 	grandTotal := 0.0
-	for _, detail := range req.Details {
+	quantities := make([]unitQuantity, len(req.Details))
+	for i, detail := range req.Details {
 		var itemPrice float64
 		err = tx.QueryRow(ctx, "SELECT price FROM items WHERE items_id = $1", detail.ItemId).Scan(&itemPrice)
 		if err != nil {
@@ -307,7 +371,18 @@ func CreatePurchasing(c *fiber.Ctx) error {
 				"message": fmt.Sprintf("Item with ID %s not found", detail.ItemId),
 			})
 		}
-		subtotal := itemPrice * float64(detail.Qty)
+
+		// Prices are per stock unit, so lines are converted before pricing.
+		quantity, message := convertToStockUnits(ctx, tx, detail.ItemId, detail.UnitId, detail.Qty)
+		if message != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+		quantities[i] = quantity
+
+		subtotal := itemPrice * float64(quantity.Qty)
 		grandTotal += subtotal
 	}
 
@@ -316,18 +391,35 @@ func CreatePurchasing(c *fiber.Ctx) error {
 		status = req.Status
 	}
 
+	allowed, err := purchasingStatusAllowed(ctx, c, status)
+	if err != nil {
+		errors.LogError("Permission lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check permissions",
+		})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Access denied. Missing permission " + permissions.PurchasingsApprove,
+		})
+	}
+
 	now := time.Now()
 	var purchasingId string
 	insertQuery := `
-		INSERT INTO purchasings (date, supplier_id, user_id, grand_total, status, notes, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO purchasings (date, supplier_id, warehouse_id, user_id, department_id, grand_total, status, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING purchasings_id
 	`
 
 	err = tx.QueryRow(ctx, insertQuery,
 		purchasingDate,
 		req.SupplierId,
+		warehouseId,
 		req.UserId,
+		departmentId,
 		grandTotal,
 		status,
 		req.Notes,
@@ -342,7 +434,7 @@ func CreatePurchasing(c *fiber.Ctx) error {
 		})
 	}
 
-	for _, detail := range req.Details {
+	for i, detail := range req.Details {
 		var itemPrice float64
 		err = tx.QueryRow(ctx, "SELECT price FROM items WHERE items_id = $1", detail.ItemId).Scan(&itemPrice)
 		if err != nil {
@@ -352,12 +444,13 @@ func CreatePurchasing(c *fiber.Ctx) error {
 				"message": fmt.Sprintf("Failed to get price for item ID %s", detail.ItemId),
 			})
 		}
-		subtotal := itemPrice * float64(detail.Qty)
+		quantity := quantities[i]
+		subtotal := itemPrice * float64(quantity.Qty)
 		detailQuery := `
-			INSERT INTO purchasing_details (purchasing_id, item_id, qty, subtotal)
-			VALUES ($1, $2, $3, $4)
+			INSERT INTO purchasing_details (purchasing_id, item_id, unit_id, unit_qty, factor, qty, subtotal)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
 		`
-		_, err = tx.Exec(ctx, detailQuery, purchasingId, detail.ItemId, detail.Qty, subtotal)
+		_, err = tx.Exec(ctx, detailQuery, purchasingId, detail.ItemId, quantity.UnitId, quantity.UnitQty, quantity.Factor, quantity.Qty, subtotal)
 		if err != nil {
 			errors.LogError("Purchasing detail creation error", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -377,7 +470,7 @@ func CreatePurchasing(c *fiber.Ctx) error {
 
 	var purchasing models.Purchasings
 	getQuery := `
-		SELECT purchasings_id, date, supplier_id, user_id, grand_total, status, notes, created_at
+		SELECT purchasings_id, date, supplier_id, warehouse_id, user_id, department_id, grand_total, status, notes, created_at
 		FROM purchasings
 		WHERE purchasings_id = $1
 	`
@@ -385,7 +478,9 @@ func CreatePurchasing(c *fiber.Ctx) error {
 		&purchasing.PurchasingsId,
 		&purchasing.Date,
 		&purchasing.SupplierId,
+		&purchasing.WarehouseId,
 		&purchasing.UserId,
+		&purchasing.DepartmentId,
 		&purchasing.GrandTotal,
 		&purchasing.Status,
 		&purchasing.Notes,
@@ -398,7 +493,9 @@ func CreatePurchasing(c *fiber.Ctx) error {
 			"purchasing_id": purchasing.PurchasingsId,
 			"date":          purchasing.Date,
 			"supplier_id":   purchasing.SupplierId,
+			"warehouse_id":  purchasing.WarehouseId,
 			"user_id":       purchasing.UserId,
+			"department_id": purchasing.DepartmentId,
 			"grand_total":   purchasing.GrandTotal,
 			"status":        purchasing.Status,
 			"notes":         purchasing.Notes,
@@ -435,8 +532,9 @@ func UpdatePurchasing(c *fiber.Ctx) error {
 	defer cancel()
 
 	var existingPurchasing models.Purchasings
-	checkQuery := `SELECT purchasings_id FROM purchasings WHERE purchasings_id = $1`
-	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingPurchasing.PurchasingsId)
+	scopeClause, scopeArgs := query.ScopeTable("WHERE p.purchasings_id = $1", []interface{}{id}, query.ScopeFrom(c), "purchasings", "p")
+	checkQuery := `SELECT purchasings_id FROM purchasings p ` + scopeClause
+	err := database.DB.QueryRow(ctx, checkQuery, scopeArgs...).Scan(&existingPurchasing.PurchasingsId)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
@@ -475,6 +573,20 @@ func UpdatePurchasing(c *fiber.Ctx) error {
 		argPos++
 	}
 
+	if req.WarehouseId != nil {
+		var isActive bool
+		err = database.DB.QueryRow(ctx, "SELECT is_active FROM warehouses WHERE warehouses_id = $1", *req.WarehouseId).Scan(&isActive)
+		if err != nil || !isActive {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Warehouse not found or inactive",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("warehouse_id = $%d", argPos))
+		args = append(args, *req.WarehouseId)
+		argPos++
+	}
+
 	if req.UserId != nil {
 		var userExists string
 		err = database.DB.QueryRow(ctx, "SELECT users_id FROM users WHERE users_id = $1", *req.UserId).Scan(&userExists)
@@ -489,7 +601,34 @@ func UpdatePurchasing(c *fiber.Ctx) error {
 		argPos++
 	}
 
+	if req.DepartmentId != nil {
+		departmentId, code, message := resolveDepartment(ctx, database.DB, query.ScopeFrom(c), *req.DepartmentId)
+		if message != "" {
+			return c.Status(code).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("department_id = $%d", argPos))
+		args = append(args, departmentId)
+		argPos++
+	}
+
 	if req.Status != nil {
+		allowed, err := purchasingStatusAllowed(ctx, c, *req.Status)
+		if err != nil {
+			errors.LogError("Permission lookup error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to check permissions",
+			})
+		}
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Access denied. Missing permission " + permissions.PurchasingsApprove,
+			})
+		}
 		updateFields = append(updateFields, fmt.Sprintf("status = $%d", argPos))
 		args = append(args, *req.Status)
 		argPos++
@@ -513,7 +652,7 @@ func UpdatePurchasing(c *fiber.Ctx) error {
 		UPDATE purchasings
 		SET %s
 		WHERE purchasings_id = $%d
-		RETURNING purchasings_id, date, supplier_id, user_id, grand_total, status, notes, created_at
+		RETURNING purchasings_id, date, supplier_id, warehouse_id, user_id, department_id, grand_total, status, notes, created_at
 	`, strings.Join(updateFields, ", "), argPos)
 
 	var purchasing models.Purchasings
@@ -521,7 +660,9 @@ func UpdatePurchasing(c *fiber.Ctx) error {
 		&purchasing.PurchasingsId,
 		&purchasing.Date,
 		&purchasing.SupplierId,
+		&purchasing.WarehouseId,
 		&purchasing.UserId,
+		&purchasing.DepartmentId,
 		&purchasing.GrandTotal,
 		&purchasing.Status,
 		&purchasing.Notes,
@@ -556,8 +697,9 @@ func DeletePurchasing(c *fiber.Ctx) error {
 	defer cancel()
 
 	var existingPurchasing models.Purchasings
-	checkQuery := `SELECT purchasings_id FROM purchasings WHERE purchasings_id = $1`
-	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingPurchasing.PurchasingsId)
+	scopeClause, scopeArgs := query.ScopeTable("WHERE p.purchasings_id = $1", []interface{}{id}, query.ScopeFrom(c), "purchasings", "p")
+	checkQuery := `SELECT purchasings_id FROM purchasings p ` + scopeClause
+	err := database.DB.QueryRow(ctx, checkQuery, scopeArgs...).Scan(&existingPurchasing.PurchasingsId)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
@@ -8,21 +8,125 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"fleetify/internal/database"
 	"fleetify/internal/models"
+	"fleetify/internal/permissions"
 	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
 	"fleetify/pkg/query"
 )
 
 type CreateRoleRequest struct {
-	RoleOID         string `json:"role_oid" validate:"required"`
-	RoleName        string `json:"role_name" validate:"required"`
-	RoleDescription string `json:"role_description"`
+	RoleOID         string   `json:"role_oid" validate:"required"`
+	RoleName        string   `json:"role_name" validate:"required"`
+	RoleDescription string   `json:"role_description"`
+	Permissions     []string `json:"permissions"`
 }
 
 type UpdateRoleRequest struct {
-	RoleName        string `json:"role_name"`
-	RoleDescription string `json:"role_description"`
+	RoleName        string    `json:"role_name"`
+	RoleDescription string    `json:"role_description"`
+	Permissions     *[]string `json:"permissions"`
+}
+
+type RoleResponse struct {
+	models.Roles
+	Permissions []string `json:"permissions"`
+}
+
+// rolePermissionsColumn lists a role's permissions; ADMIN holds all of them.
+const rolePermissionsColumn = `
+	CASE WHEN role_oid = 'ADMIN'
+		THEN COALESCE((SELECT array_agg(code ORDER BY code) FROM permissions), '{}')
+		ELSE COALESCE((SELECT array_agg(permission ORDER BY permission) FROM role_permissions rp WHERE rp.role_oid = roles.role_oid), '{}')
+	END
+`
+
+// setRolePermissions replaces the permissions granted to a role. Unknown
+// permission codes are reported back as an error message.
+func setRolePermissions(ctx context.Context, tx pgx.Tx, roleOID string, codes []string) (string, error) {
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		unique = append(unique, code)
+	}
+
+	var known int
+	err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM permissions WHERE code = ANY($1)", unique).Scan(&known)
+	if err != nil {
+		return "", err
+	}
+	if known != len(unique) {
+		return "Unknown permission; see GET /roles/permissions", nil
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM role_permissions WHERE role_oid = $1", roleOID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	for _, code := range unique {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO role_permissions (role_oid, permission, created_at, updated_at)
+			VALUES ($1, $2, $3, $3)
+		`, roleOID, code, now)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// roleExists reports whether role is a role_oid in the roles table.
+func roleExists(ctx context.Context, q rowQuerier, role string) bool {
+	var rolesId string
+	err := q.QueryRow(ctx, "SELECT roles_id FROM roles WHERE role_oid = $1", role).Scan(&rolesId)
+	return err == nil
+}
+
+// roleAssignable reports whether the caller may give role to a user. Only
+// ADMIN hands out ADMIN; everyone else is limited to roles whose permissions
+// they hold themselves, so users:manage cannot be used to gain more.
+func roleAssignable(ctx context.Context, claims *jwt.Claims, role string) (bool, error) {
+	if claims.Role == permissions.SuperRole && claims.APIKeyID == "" {
+		return true, nil
+	}
+	if role == permissions.SuperRole {
+		return false, nil
+	}
+
+	rows, err := database.DB.Query(ctx, "SELECT permission FROM role_permissions WHERE role_oid = $1", role)
+	if err != nil {
+		return false, err
+	}
+	granted := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			rows.Close()
+			return false, err
+		}
+		granted = append(granted, permission)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, permission := range granted {
+		held, err := permissions.Granted(ctx, claims, permission)
+		if err != nil || !held {
+			return false, err
+		}
+	}
+	return true, nil
 }
 
 func GetRoles(c *fiber.Ctx) error {
@@ -53,7 +157,7 @@ func GetRoles(c *fiber.Ctx) error {
 	}
 
 	baseQuery := `
-		SELECT roles_id, role_oid, role_name, role_description, created_timestamp, updated_timestamp
+		SELECT roles_id, role_oid, role_name, role_description, created_timestamp, updated_timestamp, ` + rolePermissionsColumn + `
 		FROM roles
 	`
 	
@@ -70,9 +174,9 @@ func GetRoles(c *fiber.Ctx) error {
 	}
 	defer rows.Close()
 
-	var roles []models.Roles
+	var roles []RoleResponse
 	for rows.Next() {
-		var role models.Roles
+		var role RoleResponse
 		err := rows.Scan(
 			&role.RolesId,
 			&role.RoleOID,
@@ -80,6 +184,7 @@ func GetRoles(c *fiber.Ctx) error {
 			&role.RoleDescription,
 			&role.CreatedTimestamp,
 			&role.UpdatedTimestamp,
+			&role.Permissions,
 		)
 		if err != nil {
 			errors.LogError("Role scan error", err)
@@ -120,20 +225,21 @@ func GetRoleByOID(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var role models.Roles
+	var role RoleResponse
 	query := `
-		SELECT roles_id, role_oid, role_name, role_description, created_timestamp, updated_timestamp
+		SELECT roles_id, role_oid, role_name, role_description, created_timestamp, updated_timestamp, ` + rolePermissionsColumn + `
 		FROM roles
 		WHERE role_oid = $1
 	`
 
-	err := database.DB.QueryRow(ctx, query, roleOID).Scan(
+	err := database.DB.QueryRow(ctx, query, strings.ToUpper(roleOID)).Scan(
 		&role.RolesId,
 		&role.RoleOID,
 		&role.RoleName,
 		&role.RoleDescription,
 		&role.CreatedTimestamp,
 		&role.UpdatedTimestamp,
+		&role.Permissions,
 	)
 
 	if err != nil {
@@ -185,6 +291,16 @@ func CreateRole(c *fiber.Ctx) error {
 		})
 	}
 
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create role",
+		})
+	}
+	defer tx.Rollback(ctx)
+
 	roleID := uuid.New().String()
 	now := time.Now()
 
@@ -194,8 +310,8 @@ func CreateRole(c *fiber.Ctx) error {
 		RETURNING roles_id, role_oid, role_name, role_description, created_timestamp, updated_timestamp
 	`
 
-	var role models.Roles
-	err = database.DB.QueryRow(ctx, insertQuery,
+	var role RoleResponse
+	err = tx.QueryRow(ctx, insertQuery,
 		roleID,
 		strings.ToUpper(req.RoleOID),
 		req.RoleName,
@@ -219,6 +335,39 @@ func CreateRole(c *fiber.Ctx) error {
 		})
 	}
 
+	message, err := setRolePermissions(ctx, tx, role.RoleOID, req.Permissions)
+	if err != nil {
+		errors.LogError("Role permissions error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create role",
+		})
+	}
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	err = tx.QueryRow(ctx, "SELECT "+rolePermissionsColumn+" FROM roles WHERE role_oid = $1", role.RoleOID).Scan(&role.Permissions)
+	if err != nil {
+		errors.LogError("Role permissions error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create role",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create role",
+		})
+	}
+	permissions.Invalidate(role.RoleOID)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"error":   false,
 		"message": "Role created successfully",
@@ -279,7 +428,7 @@ func UpdateRole(c *fiber.Ctx) error {
 		argPos++
 	}
 
-	if len(updateFields) == 0 {
+	if len(updateFields) == 0 && req.Permissions == nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
 			"message": "No fields to update",
@@ -296,17 +445,45 @@ func UpdateRole(c *fiber.Ctx) error {
 		UPDATE roles
 		SET %s
 		WHERE role_oid = $%d
-		RETURNING roles_id, role_oid, role_name, role_description, created_timestamp, updated_timestamp
-	`, strings.Join(updateFields, ", "), argPos)
+		RETURNING roles_id, role_oid, role_name, role_description, created_timestamp, updated_timestamp, %s
+	`, strings.Join(updateFields, ", "), argPos, rolePermissionsColumn)
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update role",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	if req.Permissions != nil {
+		message, err := setRolePermissions(ctx, tx, strings.ToUpper(roleOID), *req.Permissions)
+		if err != nil {
+			errors.LogError("Role permissions error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update role",
+			})
+		}
+		if message != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+	}
 
-	var role models.Roles
-	err = database.DB.QueryRow(ctx, updateQuery, args...).Scan(
+	var role RoleResponse
+	err = tx.QueryRow(ctx, updateQuery, args...).Scan(
 		&role.RolesId,
 		&role.RoleOID,
 		&role.RoleName,
 		&role.RoleDescription,
 		&role.CreatedTimestamp,
 		&role.UpdatedTimestamp,
+		&role.Permissions,
 	)
 
 	if err != nil {
@@ -317,6 +494,15 @@ func UpdateRole(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update role",
+		})
+	}
+	permissions.Invalidate(role.RoleOID)
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Role updated successfully",
@@ -353,6 +539,18 @@ func DeleteRole(c *fiber.Ctx) error {
 		})
 	}
 
+	var assigned int
+	err = database.DB.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM users WHERE role = $1) +
+		       (SELECT COUNT(*) FROM user_invites WHERE role = $1 AND used_at IS NULL)
+	`, strings.ToUpper(roleOID)).Scan(&assigned)
+	if err == nil && assigned > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Role is assigned to users or pending invites and cannot be deleted",
+		})
+	}
+
 	deleteQuery := `DELETE FROM roles WHERE role_oid = $1`
 	_, err = database.DB.Exec(ctx, deleteQuery, strings.ToUpper(roleOID))
 	if err != nil {
@@ -363,9 +561,62 @@ func DeleteRole(c *fiber.Ctx) error {
 		})
 	}
 
+	permissions.Invalidate(strings.ToUpper(roleOID))
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Role deleted successfully",
 	})
 }
 
+// GetPermissions lists every permission that can be granted to a role.
+func GetPermissions(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT permissions_id, code, COALESCE(description, ''), created_at, updated_at
+		FROM permissions
+		ORDER BY code
+	`)
+	if err != nil {
+		errors.LogError("Get permissions query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch permissions",
+		})
+	}
+	defer rows.Close()
+
+	list := []models.Permissions{}
+	for rows.Next() {
+		var permission models.Permissions
+		err := rows.Scan(
+			&permission.PermissionsId,
+			&permission.Code,
+			&permission.Description,
+			&permission.CreatedAt,
+			&permission.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Permission scan error", err)
+			continue
+		}
+		list = append(list, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process permissions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  list,
+		"count": len(list),
+	})
+}
+
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fleetify/pkg/query"
+)
+
+// purchasingInScope selects the purchasings of the caller's departments; the
+// documents that hang off a purchasing are scoped through it.
+const purchasingInScope = "SELECT purchasings_id FROM purchasings WHERE department_id = ANY(%[2]s)"
+
+// receiptInScope selects the goods receipts of those purchasings.
+const receiptInScope = `SELECT gr.goods_receipts_id FROM goods_receipts gr
+	JOIN purchasings gp ON gr.purchasing_id = gp.purchasings_id
+	WHERE gp.department_id = ANY(%[2]s)`
+
+// The department scope of every table whose rows belong to departments; see
+// middleware.DepartmentScope.
+func init() {
+	query.RegisterScope("departments", "%[1]s.departments_id = ANY(%[2]s)")
+
+	// Users without a department, the caller included when unassigned, stay
+	// visible to everyone.
+	query.RegisterScope("users", `(EXISTS (SELECT 1 FROM user_departments ud WHERE ud.user_id = %[1]s.users_id AND ud.department_id = ANY(%[2]s))
+		OR NOT EXISTS (SELECT 1 FROM user_departments ud WHERE ud.user_id = %[1]s.users_id))`)
+
+	query.RegisterScope("purchasings", "%[1]s.department_id = ANY(%[2]s)")
+	query.RegisterScope("purchasing_details", "%[1]s.purchasing_id IN ("+purchasingInScope+")")
+	query.RegisterScope("goods_receipts", "%[1]s.purchasing_id IN ("+purchasingInScope+")")
+	query.RegisterScope("goods_receipt_details", "%[1]s.goods_receipt_id IN ("+receiptInScope+")")
+	query.RegisterScope("item_serials", "%[1]s.goods_receipt_id IN ("+receiptInScope+")")
+	query.RegisterScope("tires", "%[1]s.purchasing_id IN ("+purchasingInScope+")")
+
+	// Issues carry no department; an issued lot is visible to whoever may
+	// see a receipt of it.
+	query.RegisterScope("stock_issue_details", `EXISTS (SELECT 1 FROM goods_receipt_details grd
+		WHERE grd.item_id = %[1]s.item_id AND grd.lot_number = %[1]s.lot_number
+		AND grd.goods_receipt_id IN (`+receiptInScope+`))`)
+}
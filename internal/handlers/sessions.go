@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/internal/revocation"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type SessionResponse struct {
+	models.UserSessions
+	Status  string `json:"status"`
+	Current bool   `json:"current"`
+}
+
+const sessionColumns = `user_sessions_id, user_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), last_seen_at,
+	COALESCE(last_seen_ip, ''), expires_at, revoked_at, COALESCE(revoked_reason, ''), created_at, updated_at`
+
+func scanSession(row pgx.Row, session *SessionResponse) error {
+	err := row.Scan(
+		&session.UserSessionsId,
+		&session.UserId,
+		&session.UserAgent,
+		&session.IpAddress,
+		&session.LastSeenAt,
+		&session.LastSeenIp,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.RevokedReason,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+	if err == nil {
+		session.Status = sessionStatus(session.UserSessions)
+	}
+	return err
+}
+
+func sessionStatus(session models.UserSessions) string {
+	switch {
+	case session.RevokedAt != nil:
+		return "revoked"
+	case time.Now().After(session.ExpiresAt):
+		return "expired"
+	}
+	return "active"
+}
+
+// listSessions answers with the sessions of userId, marking currentSessionId.
+func listSessions(c *fiber.Ctx, userId, currentSessionId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"user_agent", "ip_address", "last_seen_ip"}
+	filterFields := map[string]string{}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	whereArgs = append(whereArgs, userId)
+	conditions := fmt.Sprintf("user_id = $%d", len(whereArgs))
+	statusConditions := map[string]string{
+		"active":  "revoked_at IS NULL AND expires_at > NOW()",
+		"expired": "revoked_at IS NULL AND expires_at <= NOW()",
+		"revoked": "revoked_at IS NOT NULL",
+	}
+	if condition, ok := statusConditions[params.Filters["status"]]; ok {
+		conditions += " AND " + condition
+	}
+	if whereClause == "" {
+		whereClause = "WHERE " + conditions
+	} else {
+		whereClause += " AND " + conditions
+	}
+	orderClause := query.BuildOrderClause(params, "last_seen_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("user_sessions", whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get sessions count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count sessions",
+		})
+	}
+
+	fullQuery := "SELECT " + sessionColumns + " FROM user_sessions " + whereClause + " " + orderClause + " " + paginationClause
+
+	rows, err := database.DB.Query(ctx, fullQuery, append(whereArgs, paginationArgs...)...)
+	if err != nil {
+		errors.LogError("Get sessions query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch sessions",
+		})
+	}
+	defer rows.Close()
+
+	sessions := []SessionResponse{}
+	for rows.Next() {
+		var session SessionResponse
+		if err := scanSession(rows, &session); err != nil {
+			errors.LogError("Session scan error", err)
+			continue
+		}
+		session.Current = currentSessionId != "" && session.UserSessionsId == currentSessionId
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process sessions",
+		})
+	}
+
+	response := query.NewPaginatedResponse(sessions, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+// revokeSession ends session id of userId, answering 404 when the user has
+// no such session.
+func revokeSession(c *fiber.Ctx, userId, id, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var revokedAt *time.Time
+	err := database.DB.QueryRow(ctx, `
+		SELECT revoked_at FROM user_sessions
+		WHERE user_sessions_id::text = $1 AND user_id = $2
+	`, id, userId).Scan(&revokedAt)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Session not found",
+		})
+	}
+
+	if revokedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Session has already been revoked",
+		})
+	}
+
+	if err := revocation.RevokeSession(ctx, userId, id, reason); err != nil {
+		errors.LogError("Session revoke error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke session",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Session revoked successfully",
+	})
+}
+
+// GetSessions lists where the current user is logged in. The session of the
+// request's token is marked current.
+func GetSessions(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	return listSessions(c, claims.UserID, claims.SessionID)
+}
+
+// RevokeOwnSession logs the current user out of one of their sessions. Its
+// access tokens stop working right away and its refresh token can no longer
+// be used.
+func RevokeOwnSession(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	return revokeSession(c, claims.UserID, c.Params("id"), "revoked_by_user")
+}
+
+// userIdByUsername resolves the :uname parameter of the admin routes.
+func userIdByUsername(c *fiber.Ctx) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var userId string
+	err := database.DB.QueryRow(ctx, "SELECT users_id FROM users WHERE username = $1", c.Params("uname")).Scan(&userId)
+	return userId, err
+}
+
+// GetUserSessions lists the sessions of a user for admins.
+func GetUserSessions(c *fiber.Ctx) error {
+	userId, err := userIdByUsername(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	return listSessions(c, userId, "")
+}
+
+// RevokeUserSession ends one session of a user for admins.
+func RevokeUserSession(c *fiber.Ctx) error {
+	userId, err := userIdByUsername(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	return revokeSession(c, userId, c.Params("id"), "revoked_by_admin")
+}
@@ -0,0 +1,834 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateStockCountRequest struct {
+	WarehouseId     string   `json:"warehouse_id"`
+	FreezeMovements bool     `json:"freeze_movements"`
+	ItemIds         []string `json:"item_ids"`
+	Notes           string   `json:"notes"`
+}
+
+type StockCountEntry struct {
+	ItemId     string `json:"item_id" validate:"required"`
+	CountedQty *int   `json:"counted_qty"`
+	Notes      string `json:"notes"`
+}
+
+type RecordStockCountsRequest struct {
+	Counts []StockCountEntry `json:"counts" validate:"required,min=1"`
+}
+
+type ReviewStockCountRequest struct {
+	Notes string `json:"notes"`
+}
+
+type StockCountDetailResponse struct {
+	models.StockCountDetails
+	ItemName      string   `json:"item_name"`
+	Unit          string   `json:"unit"`
+	MovementQty   *int     `json:"movement_qty"`
+	VarianceQty   *int     `json:"variance_qty"`
+	VarianceValue *float64 `json:"variance_value"`
+}
+
+type StockCountResponse struct {
+	models.StockCounts
+	WarehouseName string                     `json:"warehouse_name"`
+	UserName      string                     `json:"user_name"`
+	TotalItems    int                        `json:"total_items"`
+	CountedItems  int                        `json:"counted_items"`
+	VarianceQty   int                        `json:"variance_qty"`
+	SurplusValue  float64                    `json:"surplus_value"`
+	ShortageValue float64                    `json:"shortage_value"`
+	VarianceValue float64                    `json:"variance_value"`
+	Details       []StockCountDetailResponse `json:"details,omitempty"`
+}
+
+const stockCountFromClause = `
+	FROM stock_counts sc
+	LEFT JOIN warehouses w ON sc.warehouse_id = w.warehouses_id
+	LEFT JOIN users u ON sc.user_id = u.users_id
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS total_items,
+		       COUNT(d.counted_qty) AS counted_items,
+		       COALESCE(SUM(d.counted_qty - d.system_qty), 0) AS variance_qty,
+		       COALESCE(SUM(GREATEST(d.counted_qty - d.system_qty, 0) * d.unit_cost), 0) AS surplus_value,
+		       COALESCE(SUM(GREATEST(d.system_qty - d.counted_qty, 0) * d.unit_cost), 0) AS shortage_value
+		FROM stock_count_details d
+		WHERE d.stock_count_id = sc.stock_counts_id
+	) t ON TRUE
+`
+
+const stockCountColumns = `
+	SELECT sc.stock_counts_id, sc.warehouse_id, sc.status, sc.freeze_movements, sc.started_at, sc.submitted_at,
+	       sc.approved_at, sc.user_id, sc.approved_by, sc.notes, sc.review_notes, sc.created_at, sc.updated_at,
+	       COALESCE(w.name, ''), COALESCE(u.full_name, ''),
+	       t.total_items, t.counted_items, t.variance_qty, t.surplus_value, t.shortage_value
+`
+
+func scanStockCount(row pgx.Row, sc *StockCountResponse) error {
+	err := row.Scan(
+		&sc.StockCountsId,
+		&sc.WarehouseId,
+		&sc.Status,
+		&sc.FreezeMovements,
+		&sc.StartedAt,
+		&sc.SubmittedAt,
+		&sc.ApprovedAt,
+		&sc.UserId,
+		&sc.ApprovedBy,
+		&sc.Notes,
+		&sc.ReviewNotes,
+		&sc.CreatedAt,
+		&sc.UpdatedAt,
+		&sc.WarehouseName,
+		&sc.UserName,
+		&sc.TotalItems,
+		&sc.CountedItems,
+		&sc.VarianceQty,
+		&sc.SurplusValue,
+		&sc.ShortageValue,
+	)
+	sc.VarianceValue = sc.SurplusValue - sc.ShortageValue
+	return err
+}
+
+func fetchStockCount(ctx context.Context, id string) (StockCountResponse, error) {
+	var count StockCountResponse
+	err := scanStockCount(database.DB.QueryRow(ctx, stockCountColumns+stockCountFromClause+" WHERE sc.stock_counts_id = $1", id), &count)
+	if err != nil {
+		return count, err
+	}
+
+	detailsQuery := `
+		SELECT d.stock_count_details_id, d.stock_count_id, d.item_id, d.expected_qty, d.system_qty, d.counted_qty,
+		       d.unit_cost, d.notes, d.counted_by, d.counted_at, COALESCE(i.name, ''), COALESCE(i.unit, '')
+		FROM stock_count_details d
+		LEFT JOIN items i ON d.item_id = i.items_id
+		WHERE d.stock_count_id = $1
+		ORDER BY i.name
+	`
+	rows, err := database.DB.Query(ctx, detailsQuery, id)
+	if err != nil {
+		return count, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail StockCountDetailResponse
+		err := rows.Scan(
+			&detail.StockCountDetailsId,
+			&detail.StockCountId,
+			&detail.ItemId,
+			&detail.ExpectedQty,
+			&detail.SystemQty,
+			&detail.CountedQty,
+			&detail.UnitCost,
+			&detail.Notes,
+			&detail.CountedBy,
+			&detail.CountedAt,
+			&detail.ItemName,
+			&detail.Unit,
+		)
+		if err != nil {
+			errors.LogError("Stock count detail scan error", err)
+			continue
+		}
+
+		if detail.CountedQty != nil && detail.SystemQty != nil {
+			movement := *detail.SystemQty - detail.ExpectedQty
+			variance := *detail.CountedQty - *detail.SystemQty
+			value := float64(variance) * detail.UnitCost
+			detail.MovementQty = &movement
+			detail.VarianceQty = &variance
+			detail.VarianceValue = &value
+		}
+		count.Details = append(count.Details, detail)
+	}
+
+	return count, rows.Err()
+}
+
+// lockStockCount locks the session row and returns its warehouse and status.
+func lockStockCount(ctx context.Context, tx pgx.Tx, id string) (string, string, error) {
+	var warehouseId, status string
+	err := tx.QueryRow(ctx, "SELECT warehouse_id, status FROM stock_counts WHERE stock_counts_id = $1 FOR UPDATE", id).Scan(&warehouseId, &status)
+	return warehouseId, status, err
+}
+
+func GetStockCounts(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"sc.notes", "w.name"}
+	filterFields := map[string]string{
+		"status":       "sc.status",
+		"warehouse_id": "sc.warehouse_id",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "sc.started_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := "SELECT COUNT(*) " + stockCountFromClause + " " + whereClause
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get stock counts count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count stock counts",
+		})
+	}
+
+	fullQuery := stockCountColumns + stockCountFromClause + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get stock counts query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch stock counts",
+		})
+	}
+	defer rows.Close()
+
+	var counts []StockCountResponse
+	for rows.Next() {
+		var sc StockCountResponse
+		if err := scanStockCount(rows, &sc); err != nil {
+			errors.LogError("Stock count scan error", err)
+			continue
+		}
+		counts = append(counts, sc)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process stock counts",
+		})
+	}
+
+	response := query.NewPaginatedResponse(counts, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetStockCountById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock count ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := fetchStockCount(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock count not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  count,
+	})
+}
+
+// CreateStockCount opens a count session and snapshots the expected balance
+// of every item (or of the listed items only) at the warehouse.
+func CreateStockCount(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+
+	var req CreateStockCountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	warehouseId, message := resolveWarehouse(ctx, tx, req.WarehouseId)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	var openCount bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM stock_counts WHERE warehouse_id = $1 AND status IN ('counting', 'submitted'))", warehouseId).Scan(&openCount)
+	if err != nil {
+		errors.LogError("Open stock count check error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check open stock counts",
+		})
+	}
+	if openCount {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "A stock count is already open for this warehouse",
+		})
+	}
+
+	now := time.Now()
+	var countId string
+	insertQuery := `
+		INSERT INTO stock_counts (warehouse_id, status, freeze_movements, started_at, submitted_at, approved_at, user_id, approved_by, notes, review_notes, created_at, updated_at)
+		VALUES ($1, 'counting', $2, $3, NULL, NULL, $4, NULL, $5, '', $3, $3)
+		RETURNING stock_counts_id
+	`
+	err = tx.QueryRow(ctx, insertQuery, warehouseId, req.FreezeMovements, now, claims.UserID, req.Notes).Scan(&countId)
+	if err != nil {
+		errors.LogError("Stock count creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create stock count",
+		})
+	}
+
+	// Items without balance rows still hold their legacy stock in the
+	// default warehouse.
+	snapshotQuery := `
+		INSERT INTO stock_count_details (stock_count_id, item_id, expected_qty, system_qty, counted_qty, unit_cost, notes)
+		SELECT $1, i.items_id,
+		       COALESCE(s.qty, CASE WHEN w.is_default AND NOT EXISTS (SELECT 1 FROM item_stocks x WHERE x.item_id = i.items_id) THEN i.stock ELSE 0 END),
+		       NULL, NULL, CASE WHEN i.avg_cost > 0 THEN i.avg_cost ELSE i.price END, ''
+		FROM items i
+		JOIN warehouses w ON w.warehouses_id = $2
+		LEFT JOIN item_stocks s ON s.item_id = i.items_id AND s.warehouse_id = w.warehouses_id
+	`
+	args := []interface{}{countId, warehouseId}
+	if len(req.ItemIds) > 0 {
+		snapshotQuery += " WHERE i.items_id = ANY($3)"
+		args = append(args, req.ItemIds)
+	}
+
+	tag, err := tx.Exec(ctx, snapshotQuery, args...)
+	if err != nil {
+		errors.LogError("Stock count snapshot error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to snapshot expected stock",
+		})
+	}
+
+	if tag.RowsAffected() == 0 || (len(req.ItemIds) > 0 && int(tag.RowsAffected()) != len(req.ItemIds)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "One or more items were not found",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	count, err := fetchStockCount(ctx, countId)
+	if err != nil {
+		errors.LogError("Stock count fetch error", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Stock count created successfully",
+		"data":    count,
+	})
+}
+
+// RecordStockCounts stores counted quantities in bulk. The warehouse balance
+// at the time of counting is kept alongside, so movements made after the
+// snapshot are not mistaken for variances.
+func RecordStockCounts(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req RecordStockCountsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if len(req.Counts) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "At least one count is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	warehouseId, status, err := lockStockCount(ctx, tx, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock count not found",
+		})
+	}
+
+	if status != "counting" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Stock count is %s and can no longer be edited", status),
+		})
+	}
+
+	now := time.Now()
+	for _, entry := range req.Counts {
+		var systemQty *int
+		var countedAt *time.Time
+		var countedBy *string
+		if entry.CountedQty != nil {
+			if *entry.CountedQty < 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": fmt.Sprintf("Counted quantity for item %s cannot be negative", entry.ItemId),
+				})
+			}
+
+			qty, err := warehouseStock(ctx, tx, entry.ItemId, warehouseId)
+			if err != nil {
+				errors.LogError("Warehouse stock query error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to read warehouse stock",
+				})
+			}
+			systemQty = &qty
+			countedAt = &now
+			countedBy = &claims.UserID
+		}
+
+		tag, err := tx.Exec(ctx, `
+			UPDATE stock_count_details
+			SET counted_qty = $1, system_qty = $2, notes = $3, counted_by = $4, counted_at = $5, updated_timestamp = NOW()
+			WHERE stock_count_id = $6 AND item_id = $7
+		`, entry.CountedQty, systemQty, entry.Notes, countedBy, countedAt, id, entry.ItemId)
+		if err != nil {
+			errors.LogError("Stock count detail update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to record counts",
+			})
+		}
+
+		if tag.RowsAffected() == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Item with ID %s is not part of this stock count", entry.ItemId),
+			})
+		}
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE stock_counts SET updated_at = $1 WHERE stock_counts_id = $2", now, id)
+	if err != nil {
+		errors.LogError("Stock count update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update stock count",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	count, err := fetchStockCount(ctx, id)
+	if err != nil {
+		errors.LogError("Stock count fetch error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": fmt.Sprintf("%d counts recorded successfully", len(req.Counts)),
+		"data":    count,
+	})
+}
+
+// SubmitStockCount hands a fully counted session over for approval.
+func SubmitStockCount(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	_, status, err := lockStockCount(ctx, tx, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock count not found",
+		})
+	}
+
+	if status != "counting" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Stock count is already %s", status),
+		})
+	}
+
+	var uncounted int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM stock_count_details WHERE stock_count_id = $1 AND counted_qty IS NULL", id).Scan(&uncounted)
+	if err != nil {
+		errors.LogError("Stock count detail query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to read stock count details",
+		})
+	}
+
+	if uncounted > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("%d items have not been counted yet", uncounted),
+		})
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(ctx, "UPDATE stock_counts SET status = 'submitted', submitted_at = $1, updated_at = $1 WHERE stock_counts_id = $2", now, id)
+	if err != nil {
+		errors.LogError("Stock count update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to submit stock count",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	count, err := fetchStockCount(ctx, id)
+	if err != nil {
+		errors.LogError("Stock count fetch error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Stock count submitted for approval",
+		"data":    count,
+	})
+}
+
+// ApproveStockCount posts each line's variance to the warehouse balance and
+// items.stock. Variances are applied as deltas, so stock moved after an
+// item was counted is preserved.
+func ApproveStockCount(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req ReviewStockCountRequest
+	if err := c.BodyParser(&req); err != nil && len(c.Body()) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	warehouseId, status, err := lockStockCount(ctx, tx, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock count not found",
+		})
+	}
+
+	if status != "submitted" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Only submitted stock counts can be approved",
+		})
+	}
+
+	// Closing the session first lifts its freeze so the adjustments can post.
+	now := time.Now()
+	_, err = tx.Exec(ctx, `
+		UPDATE stock_counts
+		SET status = 'approved', approved_at = $1, approved_by = $2, review_notes = $3, updated_at = $1
+		WHERE stock_counts_id = $4
+	`, now, claims.UserID, req.Notes, id)
+	if err != nil {
+		errors.LogError("Stock count update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to approve stock count",
+		})
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT d.item_id, COALESCE(i.name, ''), d.counted_qty - d.system_qty
+		FROM stock_count_details d
+		LEFT JOIN items i ON d.item_id = i.items_id
+		WHERE d.stock_count_id = $1 AND d.counted_qty <> d.system_qty
+	`, id)
+	if err != nil {
+		errors.LogError("Stock count detail query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to read stock count details",
+		})
+	}
+
+	type adjustment struct {
+		itemId   string
+		itemName string
+		delta    int
+	}
+	var adjustments []adjustment
+	for rows.Next() {
+		var a adjustment
+		if err := rows.Scan(&a.itemId, &a.itemName, &a.delta); err != nil {
+			rows.Close()
+			errors.LogError("Stock count detail scan error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to read stock count details",
+			})
+		}
+		adjustments = append(adjustments, a)
+	}
+	rows.Close()
+
+	for _, a := range adjustments {
+		available, err := warehouseStock(ctx, tx, a.itemId, warehouseId)
+		if err != nil {
+			errors.LogError("Warehouse stock query error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to read warehouse stock",
+			})
+		}
+
+		if available+a.delta < 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Stock of item %s has moved since it was counted; reject the count and recount it", a.itemName),
+			})
+		}
+
+		if err = changeWarehouseStock(ctx, tx, a.itemId, warehouseId, a.delta); err != nil {
+			return warehouseStockError(c, err)
+		}
+
+		if _, err = bookInventoryCost(ctx, tx, a.itemId, warehouseId, "stock_count", id, a.delta); err != nil {
+			errors.LogError("Inventory cost booking error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to cost stock adjustment",
+			})
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE items SET stock = stock + $1, updated_at = $2 WHERE items_id = $3", a.delta, now, a.itemId)
+		if err != nil {
+			errors.LogError("Item stock update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update item stock",
+			})
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	count, err := fetchStockCount(ctx, id)
+	if err != nil {
+		errors.LogError("Stock count fetch error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": fmt.Sprintf("Stock count approved, %d adjustments posted", len(adjustments)),
+		"data":    count,
+	})
+}
+
+// reviewStockCount moves a session out of review without posting anything:
+// rejected counts go back to counting, cancelled counts are closed.
+func reviewStockCount(c *fiber.Ctx, status string) error {
+	id := c.Params("id")
+
+	var req ReviewStockCountRequest
+	if err := c.BodyParser(&req); err != nil && len(c.Body()) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	_, currentStatus, err := lockStockCount(ctx, tx, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock count not found",
+		})
+	}
+
+	allowed := currentStatus == "submitted"
+	if status == "cancelled" {
+		allowed = currentStatus == "counting" || currentStatus == "submitted"
+	}
+	if !allowed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Stock count is %s", currentStatus),
+		})
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE stock_counts
+		SET status = $1, submitted_at = NULL, review_notes = $2, updated_at = $3
+		WHERE stock_counts_id = $4
+	`, status, req.Notes, time.Now(), id)
+	if err != nil {
+		errors.LogError("Stock count update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update stock count",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	count, err := fetchStockCount(ctx, id)
+	if err != nil {
+		errors.LogError("Stock count fetch error", err)
+	}
+
+	message := "Stock count returned for recount"
+	if status == "cancelled" {
+		message = "Stock count cancelled successfully"
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": message,
+		"data":    count,
+	})
+}
+
+func RejectStockCount(c *fiber.Ctx) error {
+	return reviewStockCount(c, "counting")
+}
+
+func CancelStockCount(c *fiber.Ctx) error {
+	return reviewStockCount(c, "cancelled")
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateStockIssueRequest struct {
+	VehicleId   *string            `json:"vehicle_id"`
+	WarehouseId string             `json:"warehouse_id"`
+	Date        string             `json:"date" validate:"required"`
+	Notes       string             `json:"notes"`
+	Details     []StockLineRequest `json:"details" validate:"required,min=1"`
+}
+
+type StockIssueDetailResponse struct {
+	models.StockIssueDetails
+	ItemName      string   `json:"item_name"`
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+type StockIssueResponse struct {
+	models.StockIssues
+	PlateNumber   string                     `json:"plate_number"`
+	WarehouseName string                     `json:"warehouse_name"`
+	UserName      string                     `json:"user_name"`
+	TotalCost     float64                    `json:"total_cost"`
+	Details       []StockIssueDetailResponse `json:"details"`
+}
+
+func fetchStockIssue(ctx context.Context, id string) (StockIssueResponse, error) {
+	var issue StockIssueResponse
+	query := `
+		SELECT si.stock_issues_id, si.vehicle_id, si.warehouse_id, si.date, si.user_id, si.notes, si.created_at, si.updated_at,
+		       COALESCE(v.plate_number, ''), COALESCE(w.name, ''), COALESCE(u.full_name, '')
+		FROM stock_issues si
+		LEFT JOIN vehicles v ON si.vehicle_id = v.vehicles_id
+		LEFT JOIN warehouses w ON si.warehouse_id = w.warehouses_id
+		LEFT JOIN users u ON si.user_id = u.users_id
+		WHERE si.stock_issues_id = $1
+	`
+	err := database.DB.QueryRow(ctx, query, id).Scan(
+		&issue.StockIssuesId,
+		&issue.VehicleId,
+		&issue.WarehouseId,
+		&issue.Date,
+		&issue.UserId,
+		&issue.Notes,
+		&issue.CreatedAt,
+		&issue.UpdatedAt,
+		&issue.PlateNumber,
+		&issue.WarehouseName,
+		&issue.UserName,
+	)
+	if err != nil {
+		return issue, err
+	}
+
+	detailsQuery := `
+		SELECT d.stock_issue_details_id, d.stock_issue_id, d.item_id, d.qty, d.lot_number, d.unit_cost, d.total_cost, COALESCE(i.name, ''),
+		       COALESCE(ARRAY(
+		           SELECT serial_number FROM item_serials
+		           WHERE stock_issue_id = d.stock_issue_id AND item_id = d.item_id
+		           ORDER BY serial_number
+		       ), '{}')
+		FROM stock_issue_details d
+		LEFT JOIN items i ON d.item_id = i.items_id
+		WHERE d.stock_issue_id = $1
+	`
+	rows, err := database.DB.Query(ctx, detailsQuery, id)
+	if err != nil {
+		return issue, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail StockIssueDetailResponse
+		err := rows.Scan(
+			&detail.StockIssueDetailsId,
+			&detail.StockIssueId,
+			&detail.ItemId,
+			&detail.Qty,
+			&detail.LotNumber,
+			&detail.UnitCost,
+			&detail.TotalCost,
+			&detail.ItemName,
+			&detail.SerialNumbers,
+		)
+		if err != nil {
+			errors.LogError("Stock issue detail scan error", err)
+			continue
+		}
+		issue.TotalCost += detail.TotalCost
+		issue.Details = append(issue.Details, detail)
+	}
+
+	return issue, rows.Err()
+}
+
+func GetStockIssues(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"si.notes", "v.plate_number", "u.full_name"}
+	filterFields := map[string]string{
+		"vehicle_id":   "si.vehicle_id",
+		"warehouse_id": "si.warehouse_id",
+		"user_id":      "si.user_id",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "si.date")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	fromClause := `
+		FROM stock_issues si
+		LEFT JOIN vehicles v ON si.vehicle_id = v.vehicles_id
+		LEFT JOIN warehouses w ON si.warehouse_id = w.warehouses_id
+		LEFT JOIN users u ON si.user_id = u.users_id
+	`
+
+	countQuery := "SELECT COUNT(*) " + fromClause + " " + whereClause
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get stock issues count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count stock issues",
+		})
+	}
+
+	baseQuery := `
+		SELECT si.stock_issues_id, si.vehicle_id, si.warehouse_id, si.date, si.user_id, si.notes, si.created_at, si.updated_at,
+		       COALESCE(v.plate_number, ''), COALESCE(w.name, ''), COALESCE(u.full_name, '')
+	` + fromClause
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get stock issues query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch stock issues",
+		})
+	}
+	defer rows.Close()
+
+	var issues []StockIssueResponse
+	for rows.Next() {
+		var si StockIssueResponse
+		err := rows.Scan(
+			&si.StockIssuesId,
+			&si.VehicleId,
+			&si.WarehouseId,
+			&si.Date,
+			&si.UserId,
+			&si.Notes,
+			&si.CreatedAt,
+			&si.UpdatedAt,
+			&si.PlateNumber,
+			&si.WarehouseName,
+			&si.UserName,
+		)
+		if err != nil {
+			errors.LogError("Stock issue scan error", err)
+			continue
+		}
+		issues = append(issues, si)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process stock issues",
+		})
+	}
+
+	response := query.NewPaginatedResponse(issues, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetStockIssueById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock issue ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	issue, err := fetchStockIssue(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock issue not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  issue,
+	})
+}
+
+func CreateStockIssue(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+
+	var req CreateStockIssueRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if len(req.Details) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "At least one detail item is required",
+		})
+	}
+
+	issueDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	if req.VehicleId != nil && *req.VehicleId == "" {
+		req.VehicleId = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	if req.VehicleId != nil {
+		var vehicleExists string
+		err = tx.QueryRow(ctx, "SELECT vehicles_id FROM vehicles WHERE vehicles_id = $1", *req.VehicleId).Scan(&vehicleExists)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Vehicle not found",
+			})
+		}
+	}
+
+	warehouseId, message := resolveWarehouse(ctx, tx, req.WarehouseId)
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	now := time.Now()
+	var issueId string
+	insertQuery := `
+		INSERT INTO stock_issues (vehicle_id, warehouse_id, date, user_id, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING stock_issues_id
+	`
+	err = tx.QueryRow(ctx, insertQuery, req.VehicleId, warehouseId, issueDate, claims.UserID, req.Notes, now).Scan(&issueId)
+	if err != nil {
+		errors.LogError("Stock issue creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create stock issue",
+		})
+	}
+
+	for i := range req.Details {
+		detail := &req.Details[i]
+
+		var itemName string
+		var trackSerial, trackLot bool
+		err = tx.QueryRow(ctx, "SELECT name, track_serial, track_lot FROM items WHERE items_id = $1 FOR UPDATE", detail.ItemId).Scan(&itemName, &trackSerial, &trackLot)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Item with ID %s not found", detail.ItemId),
+			})
+		}
+
+		if message := validateStockLine(detail, itemName, trackSerial, trackLot); message != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+
+		stock, err := warehouseStock(ctx, tx, detail.ItemId, warehouseId)
+		if err != nil {
+			errors.LogError("Warehouse stock query error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to read warehouse stock",
+			})
+		}
+
+		if stock < detail.Qty {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Insufficient stock for item %s in the warehouse: %d available", itemName, stock),
+			})
+		}
+
+		if trackLot {
+			var lotBalance int
+			err = tx.QueryRow(ctx, `
+				SELECT
+					COALESCE((SELECT SUM(qty) FROM goods_receipt_details WHERE item_id = $1 AND lot_number = $2), 0) -
+					COALESCE((SELECT SUM(qty) FROM stock_issue_details WHERE item_id = $1 AND lot_number = $2), 0)
+			`, detail.ItemId, detail.LotNumber).Scan(&lotBalance)
+			if err != nil {
+				errors.LogError("Lot balance query error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to read lot balance",
+				})
+			}
+
+			if lotBalance < detail.Qty {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": fmt.Sprintf("Insufficient quantity in lot %s of item %s: %d available", detail.LotNumber, itemName, lotBalance),
+				})
+			}
+		}
+
+		totalCost, err := bookInventoryCost(ctx, tx, detail.ItemId, warehouseId, "stock_issue", issueId, -detail.Qty)
+		if err != nil {
+			errors.LogError("Inventory cost booking error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to cost issued stock",
+			})
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO stock_issue_details (stock_issue_id, item_id, qty, lot_number, unit_cost, total_cost)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, issueId, detail.ItemId, detail.Qty, detail.LotNumber, totalCost/float64(detail.Qty), totalCost)
+		if err != nil {
+			errors.LogError("Stock issue detail creation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to create stock issue details",
+			})
+		}
+
+		if trackSerial {
+			rows, err := tx.Query(ctx, `
+				UPDATE item_serials
+				SET status = 'issued', stock_issue_id = $1, updated_at = $2
+				WHERE item_id = $3 AND serial_number = ANY($4) AND status = 'in_stock' AND warehouse_id = $5
+				RETURNING serial_number
+			`, issueId, now, detail.ItemId, detail.SerialNumbers, warehouseId)
+			if err != nil {
+				errors.LogError("Item serial issue error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to issue serial numbers",
+				})
+			}
+
+			issued := map[string]bool{}
+			for rows.Next() {
+				var serial string
+				if err := rows.Scan(&serial); err == nil {
+					issued[serial] = true
+				}
+			}
+			rows.Close()
+
+			missing := []string{}
+			for _, serial := range detail.SerialNumbers {
+				if !issued[serial] {
+					missing = append(missing, serial)
+				}
+			}
+			if len(missing) > 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": fmt.Sprintf("Serial numbers not in stock at the warehouse for item %s: %s", itemName, strings.Join(missing, ", ")),
+				})
+			}
+		}
+
+		if err = changeWarehouseStock(ctx, tx, detail.ItemId, warehouseId, -detail.Qty); err != nil {
+			return warehouseStockError(c, err)
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE items SET stock = stock - $1, updated_at = $2 WHERE items_id = $3", detail.Qty, now, detail.ItemId)
+		if err != nil {
+			errors.LogError("Item stock update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update item stock",
+			})
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	issue, err := fetchStockIssue(ctx, issueId)
+	if err != nil {
+		errors.LogError("Stock issue fetch error", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Stock issue created successfully",
+		"data":    issue,
+	})
+}
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateStockTransferRequest struct {
+	FromWarehouseId string             `json:"from_warehouse_id" validate:"required"`
+	ToWarehouseId   string             `json:"to_warehouse_id" validate:"required"`
+	Date            string             `json:"date" validate:"required"`
+	Notes           string             `json:"notes"`
+	Details         []StockLineRequest `json:"details" validate:"required,min=1"`
+}
+
+type StockTransferDetailResponse struct {
+	models.StockTransferDetails
+	ItemName string `json:"item_name"`
+}
+
+type StockTransferResponse struct {
+	models.StockTransfers
+	FromWarehouseName string                        `json:"from_warehouse_name"`
+	ToWarehouseName   string                        `json:"to_warehouse_name"`
+	UserName          string                        `json:"user_name"`
+	Details           []StockTransferDetailResponse `json:"details"`
+}
+
+const stockTransferFromClause = `
+	FROM stock_transfers t
+	LEFT JOIN warehouses fw ON t.from_warehouse_id = fw.warehouses_id
+	LEFT JOIN warehouses tw ON t.to_warehouse_id = tw.warehouses_id
+	LEFT JOIN users u ON t.user_id = u.users_id
+`
+
+const stockTransferColumns = `
+	SELECT t.stock_transfers_id, t.from_warehouse_id, t.to_warehouse_id, t.status, t.shipped_at, t.received_at,
+	       t.user_id, t.received_by, t.notes, t.created_at, t.updated_at,
+	       COALESCE(fw.name, ''), COALESCE(tw.name, ''), COALESCE(u.full_name, '')
+`
+
+func scanStockTransfer(row pgx.Row, t *StockTransferResponse) error {
+	return row.Scan(
+		&t.StockTransfersId,
+		&t.FromWarehouseId,
+		&t.ToWarehouseId,
+		&t.Status,
+		&t.ShippedAt,
+		&t.ReceivedAt,
+		&t.UserId,
+		&t.ReceivedBy,
+		&t.Notes,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.FromWarehouseName,
+		&t.ToWarehouseName,
+		&t.UserName,
+	)
+}
+
+func fetchStockTransfer(ctx context.Context, id string) (StockTransferResponse, error) {
+	var transfer StockTransferResponse
+	err := scanStockTransfer(database.DB.QueryRow(ctx, stockTransferColumns+stockTransferFromClause+" WHERE t.stock_transfers_id = $1", id), &transfer)
+	if err != nil {
+		return transfer, err
+	}
+
+	detailsQuery := `
+		SELECT d.stock_transfer_details_id, d.stock_transfer_id, d.item_id, d.qty, d.lot_number, d.serial_numbers, COALESCE(i.name, '')
+		FROM stock_transfer_details d
+		LEFT JOIN items i ON d.item_id = i.items_id
+		WHERE d.stock_transfer_id = $1
+	`
+	rows, err := database.DB.Query(ctx, detailsQuery, id)
+	if err != nil {
+		return transfer, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail StockTransferDetailResponse
+		err := rows.Scan(
+			&detail.StockTransferDetailsId,
+			&detail.StockTransferId,
+			&detail.ItemId,
+			&detail.Qty,
+			&detail.LotNumber,
+			&detail.SerialNumbers,
+			&detail.ItemName,
+		)
+		if err != nil {
+			errors.LogError("Stock transfer detail scan error", err)
+			continue
+		}
+		transfer.Details = append(transfer.Details, detail)
+	}
+
+	return transfer, rows.Err()
+}
+
+func GetStockTransfers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"t.notes", "fw.name", "tw.name"}
+	filterFields := map[string]string{
+		"status":            "t.status",
+		"from_warehouse_id": "t.from_warehouse_id",
+		"to_warehouse_id":   "t.to_warehouse_id",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "t.shipped_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := "SELECT COUNT(*) " + stockTransferFromClause + " " + whereClause
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get stock transfers count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count stock transfers",
+		})
+	}
+
+	fullQuery := stockTransferColumns + stockTransferFromClause + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get stock transfers query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch stock transfers",
+		})
+	}
+	defer rows.Close()
+
+	var transfers []StockTransferResponse
+	for rows.Next() {
+		var t StockTransferResponse
+		if err := scanStockTransfer(rows, &t); err != nil {
+			errors.LogError("Stock transfer scan error", err)
+			continue
+		}
+		transfers = append(transfers, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process stock transfers",
+		})
+	}
+
+	response := query.NewPaginatedResponse(transfers, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetStockTransferById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock transfer ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transfer, err := fetchStockTransfer(ctx, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock transfer not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  transfer,
+	})
+}
+
+// CreateStockTransfer ships stock out of the source warehouse. The quantities
+// stay in transit until the destination receives the transfer.
+func CreateStockTransfer(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+
+	var req CreateStockTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.FromWarehouseId == "" || req.ToWarehouseId == "" || len(req.Details) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Source warehouse, destination warehouse and at least one detail item are required",
+		})
+	}
+
+	if req.FromWarehouseId == req.ToWarehouseId {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Source and destination warehouses must differ",
+		})
+	}
+
+	shippedAt, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	for _, warehouseId := range []string{req.FromWarehouseId, req.ToWarehouseId} {
+		if _, message := resolveWarehouse(ctx, tx, warehouseId); message != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+	}
+
+	now := time.Now()
+	var transferId string
+	insertQuery := `
+		INSERT INTO stock_transfers (from_warehouse_id, to_warehouse_id, status, shipped_at, received_at, user_id, received_by, notes, created_at, updated_at)
+		VALUES ($1, $2, 'in_transit', $3, NULL, $4, NULL, $5, $6, $6)
+		RETURNING stock_transfers_id
+	`
+	err = tx.QueryRow(ctx, insertQuery, req.FromWarehouseId, req.ToWarehouseId, shippedAt, claims.UserID, req.Notes, now).Scan(&transferId)
+	if err != nil {
+		errors.LogError("Stock transfer creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create stock transfer",
+		})
+	}
+
+	for i := range req.Details {
+		detail := &req.Details[i]
+
+		var itemName string
+		var trackSerial, trackLot bool
+		err = tx.QueryRow(ctx, "SELECT name, track_serial, track_lot FROM items WHERE items_id = $1 FOR UPDATE", detail.ItemId).Scan(&itemName, &trackSerial, &trackLot)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Item with ID %s not found", detail.ItemId),
+			})
+		}
+
+		if message := validateStockLine(detail, itemName, trackSerial, trackLot); message != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+
+		available, err := warehouseStock(ctx, tx, detail.ItemId, req.FromWarehouseId)
+		if err != nil {
+			errors.LogError("Warehouse stock query error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to read warehouse stock",
+			})
+		}
+
+		if available < detail.Qty {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Insufficient stock for item %s in the source warehouse: %d available", itemName, available),
+			})
+		}
+
+		if trackSerial {
+			tag, err := tx.Exec(ctx, `
+				UPDATE item_serials
+				SET status = 'in_transit', updated_at = $1
+				WHERE item_id = $2 AND serial_number = ANY($3) AND status = 'in_stock' AND warehouse_id = $4
+			`, now, detail.ItemId, detail.SerialNumbers, req.FromWarehouseId)
+			if err != nil {
+				errors.LogError("Item serial transfer error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to transfer serial numbers",
+				})
+			}
+			if int(tag.RowsAffected()) != len(detail.SerialNumbers) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": fmt.Sprintf("Some serial numbers of item %s are not in stock at the source warehouse", itemName),
+				})
+			}
+		}
+
+		serials := detail.SerialNumbers
+		if serials == nil {
+			serials = []string{}
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO stock_transfer_details (stock_transfer_id, item_id, qty, lot_number, serial_numbers)
+			VALUES ($1, $2, $3, $4, $5)
+		`, transferId, detail.ItemId, detail.Qty, detail.LotNumber, serials)
+		if err != nil {
+			errors.LogError("Stock transfer detail creation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to create stock transfer details",
+			})
+		}
+
+		if err = changeWarehouseStock(ctx, tx, detail.ItemId, req.FromWarehouseId, -detail.Qty); err != nil {
+			return warehouseStockError(c, err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	transfer, err := fetchStockTransfer(ctx, transferId)
+	if err != nil {
+		errors.LogError("Stock transfer fetch error", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Stock transfer created successfully",
+		"data":    transfer,
+	})
+}
+
+// completeStockTransfer books in-transit quantities into either the
+// destination (receive) or back into the source warehouse (cancel).
+func completeStockTransfer(c *fiber.Ctx, status string) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var fromWarehouseId, toWarehouseId, currentStatus string
+	err = tx.QueryRow(ctx, "SELECT from_warehouse_id, to_warehouse_id, status FROM stock_transfers WHERE stock_transfers_id = $1 FOR UPDATE", id).Scan(&fromWarehouseId, &toWarehouseId, &currentStatus)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Stock transfer not found",
+		})
+	}
+
+	if currentStatus != "in_transit" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Stock transfer is already %s", currentStatus),
+		})
+	}
+
+	targetWarehouseId := toWarehouseId
+	if status == "cancelled" {
+		targetWarehouseId = fromWarehouseId
+	}
+
+	rows, err := tx.Query(ctx, "SELECT item_id, qty, serial_numbers FROM stock_transfer_details WHERE stock_transfer_id = $1", id)
+	if err != nil {
+		errors.LogError("Stock transfer detail query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to read stock transfer details",
+		})
+	}
+
+	var details []models.StockTransferDetails
+	for rows.Next() {
+		var detail models.StockTransferDetails
+		if err := rows.Scan(&detail.ItemId, &detail.Qty, &detail.SerialNumbers); err != nil {
+			rows.Close()
+			errors.LogError("Stock transfer detail scan error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to read stock transfer details",
+			})
+		}
+		details = append(details, detail)
+	}
+	rows.Close()
+
+	now := time.Now()
+	for _, detail := range details {
+		if err = changeWarehouseStock(ctx, tx, detail.ItemId, targetWarehouseId, detail.Qty); err != nil {
+			return warehouseStockError(c, err)
+		}
+
+		if len(detail.SerialNumbers) > 0 {
+			_, err = tx.Exec(ctx, `
+				UPDATE item_serials
+				SET status = 'in_stock', warehouse_id = $1, updated_at = $2
+				WHERE item_id = $3 AND serial_number = ANY($4) AND status = 'in_transit'
+			`, targetWarehouseId, now, detail.ItemId, detail.SerialNumbers)
+			if err != nil {
+				errors.LogError("Item serial transfer error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to update serial numbers",
+				})
+			}
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE stock_transfers
+		SET status = $1, received_at = $2, received_by = $3, updated_at = $2
+		WHERE stock_transfers_id = $4
+	`, status, now, claims.UserID, id)
+	if err != nil {
+		errors.LogError("Stock transfer update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update stock transfer",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	transfer, err := fetchStockTransfer(ctx, id)
+	if err != nil {
+		errors.LogError("Stock transfer fetch error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": fmt.Sprintf("Stock transfer %s successfully", status),
+		"data":    transfer,
+	})
+}
+
+func ReceiveStockTransfer(c *fiber.Ctx) error {
+	return completeStockTransfer(c, "received")
+}
+
+func CancelStockTransfer(c *fiber.Ctx) error {
+	return completeStockTransfer(c, "cancelled")
+}
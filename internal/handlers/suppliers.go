@@ -19,6 +19,7 @@ type CreateSupplierRequest struct {
 	Address      string `json:"address"`
 	Phone        string `json:"phone"`
 	SupplierType string `json:"supplier_type"`
+	CategoryId   string `json:"category_id"`
 	IsActive     *bool  `json:"is_active"`
 }
 
@@ -28,6 +29,7 @@ type UpdateSupplierRequest struct {
 	Address      *string `json:"address"`
 	Phone        *string `json:"phone"`
 	SupplierType *string `json:"supplier_type"`
+	CategoryId   *string `json:"category_id"`
 	IsActive     *bool   `json:"is_active"`
 }
 
@@ -39,14 +41,27 @@ func GetSuppliers(c *fiber.Ctx) error {
 	
 	searchFields := []string{"name", "email", "address", "phone", "supplier_type"}
 	filterFields := map[string]string{
-		"supplier_type": "supplier_type",
-		"is_active":     "is_active",
+		"is_active": "is_active",
 	}
 
 	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	for _, key := range []string{"category", "supplier_type"} {
+		if category := params.Filters[key]; category != "" {
+			whereClause, whereArgs = withCategoryFilter(whereClause, whereArgs, category, "suppliers.category_id", "suppliers.supplier_type")
+		}
+	}
 	orderClause := query.BuildOrderClause(params, "created_at")
 	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
 
+	baseQuery := `
+		SELECT suppliers_id, name, email, address, phone, supplier_type, category_id, is_active, created_at, updated_at
+		FROM suppliers
+	`
+
+	if format := exportFormat(c); format != "" {
+		return streamExport(c, format, "suppliers", baseQuery+" "+whereClause+" "+orderClause, whereArgs)
+	}
+
 	countQuery := query.BuildCountQuery("suppliers", whereClause)
 
 	var totalCount int
@@ -59,11 +74,6 @@ func GetSuppliers(c *fiber.Ctx) error {
 		})
 	}
 
-	baseQuery := `
-		SELECT suppliers_id, name, email, address, phone, supplier_type, is_active, created_at, updated_at
-		FROM suppliers
-	`
-	
 	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
 	allArgs := append(whereArgs, paginationArgs...)
 
@@ -87,6 +97,7 @@ func GetSuppliers(c *fiber.Ctx) error {
 			&supplier.Address,
 			&supplier.Phone,
 			&supplier.SupplierType,
+			&supplier.CategoryId,
 			&supplier.IsActive,
 			&supplier.CreatedAt,
 			&supplier.UpdatedAt,
@@ -132,7 +143,7 @@ func GetSupplierById(c *fiber.Ctx) error {
 
 	var supplier models.Suppliers
 	query := `
-		SELECT suppliers_id, name, email, address, phone, supplier_type, is_active, created_at, updated_at
+		SELECT suppliers_id, name, email, address, phone, supplier_type, category_id, is_active, created_at, updated_at
 		FROM suppliers
 		WHERE suppliers_id = $1
 	`
@@ -144,6 +155,7 @@ func GetSupplierById(c *fiber.Ctx) error {
 		&supplier.Address,
 		&supplier.Phone,
 		&supplier.SupplierType,
+		&supplier.CategoryId,
 		&supplier.IsActive,
 		&supplier.CreatedAt,
 		&supplier.UpdatedAt,
@@ -181,6 +193,23 @@ func CreateSupplier(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	var categoryId *string
+	categoryKey := req.CategoryId
+	if categoryKey == "" {
+		categoryKey = req.SupplierType
+	}
+	if categoryKey != "" {
+		category, ok := resolveCategory(ctx, database.DB, categoryKey)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Category not found",
+			})
+		}
+		categoryId = &category.Id
+		req.SupplierType = category.Code
+	}
+
 	now := time.Now()
 	isActive := true
 	if req.IsActive != nil {
@@ -188,9 +217,9 @@ func CreateSupplier(c *fiber.Ctx) error {
 	}
 
 	query := `
-		INSERT INTO suppliers (name, email, address, phone, supplier_type, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING suppliers_id, name, email, address, phone, supplier_type, is_active, created_at, updated_at
+		INSERT INTO suppliers (name, email, address, phone, supplier_type, category_id, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $9, $6, $7, $8)
+		RETURNING suppliers_id, name, email, address, phone, supplier_type, category_id, is_active, created_at, updated_at
 	`
 
 	var supplier models.Suppliers
@@ -203,6 +232,7 @@ func CreateSupplier(c *fiber.Ctx) error {
 		isActive,
 		now,
 		now,
+		categoryId,
 	).Scan(
 		&supplier.SuppliersId,
 		&supplier.Name,
@@ -210,6 +240,7 @@ func CreateSupplier(c *fiber.Ctx) error {
 		&supplier.Address,
 		&supplier.Phone,
 		&supplier.SupplierType,
+		&supplier.CategoryId,
 		&supplier.IsActive,
 		&supplier.CreatedAt,
 		&supplier.UpdatedAt,
@@ -288,10 +319,29 @@ func UpdateSupplier(c *fiber.Ctx) error {
 		argPos++
 	}
 
-	if req.SupplierType != nil {
-		updateFields = append(updateFields, fmt.Sprintf("supplier_type = $%d", argPos))
-		args = append(args, *req.SupplierType)
-		argPos++
+	if req.CategoryId != nil || req.SupplierType != nil {
+		categoryKey := req.CategoryId
+		if categoryKey == nil {
+			categoryKey = req.SupplierType
+		}
+
+		var categoryId *string
+		code := ""
+		if *categoryKey != "" {
+			category, ok := resolveCategory(ctx, database.DB, *categoryKey)
+			if !ok {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Category not found",
+				})
+			}
+			categoryId = &category.Id
+			code = category.Code
+		}
+
+		updateFields = append(updateFields, fmt.Sprintf("supplier_type = $%d, category_id = $%d", argPos, argPos+1))
+		args = append(args, code, categoryId)
+		argPos += 2
 	}
 
 	if req.IsActive != nil {
@@ -317,7 +367,7 @@ func UpdateSupplier(c *fiber.Ctx) error {
 		UPDATE suppliers
 		SET %s
 		WHERE suppliers_id = $%d
-		RETURNING suppliers_id, name, email, address, phone, supplier_type, is_active, created_at, updated_at
+		RETURNING suppliers_id, name, email, address, phone, supplier_type, category_id, is_active, created_at, updated_at
 	`, strings.Join(updateFields, ", "), argPos)
 
 	var supplier models.Suppliers
@@ -328,6 +378,7 @@ func UpdateSupplier(c *fiber.Ctx) error {
 		&supplier.Address,
 		&supplier.Phone,
 		&supplier.SupplierType,
+		&supplier.CategoryId,
 		&supplier.IsActive,
 		&supplier.CreatedAt,
 		&supplier.UpdatedAt,
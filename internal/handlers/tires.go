@@ -0,0 +1,1329 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+var validTirePositions = map[string]bool{
+	"front-left":       true,
+	"front-right":      true,
+	"rear-left-outer":  true,
+	"rear-left-inner":  true,
+	"rear-right-outer": true,
+	"rear-right-inner": true,
+	"spare":            true,
+}
+
+// tireKmExpression is the distance a tire has run, including the open
+// mounting on its current vehicle.
+const tireKmExpression = `t.total_km + CASE WHEN t.status = 'mounted' THEN GREATEST(COALESCE(v.odometer, 0) - t.mount_odometer, 0) ELSE 0 END`
+
+type ReceiveTiresRequest struct {
+	PurchasingId  string   `json:"purchasing_id" validate:"required"`
+	ItemId        string   `json:"item_id" validate:"required"`
+	WarehouseId   string   `json:"warehouse_id"`
+	Brand         string   `json:"brand" validate:"required"`
+	SerialNumbers []string `json:"serial_numbers" validate:"required,min=1"`
+	LotNumber     string   `json:"lot_number"`
+	TreadDepth    float64  `json:"tread_depth"`
+}
+
+type MountTireRequest struct {
+	VehicleId string `json:"vehicle_id" validate:"required"`
+	Position  string `json:"position" validate:"required"`
+	Odometer  *int   `json:"odometer"`
+	Notes     string `json:"notes"`
+}
+
+type DismountTireRequest struct {
+	Odometer   *int     `json:"odometer"`
+	TreadDepth *float64 `json:"tread_depth"`
+	Notes      string   `json:"notes"`
+}
+
+type RotateTireRequest struct {
+	VehicleId *string `json:"vehicle_id"`
+	Position  string  `json:"position" validate:"required"`
+	Odometer  *int    `json:"odometer"`
+	Notes     string  `json:"notes"`
+}
+
+type RetreadTireRequest struct {
+	Cost       float64 `json:"cost"`
+	TreadDepth float64 `json:"tread_depth" validate:"required,gt=0"`
+	Notes      string  `json:"notes"`
+}
+
+type InspectTireRequest struct {
+	TreadDepth float64 `json:"tread_depth" validate:"required,gt=0"`
+	Odometer   *int    `json:"odometer"`
+	Notes      string  `json:"notes"`
+}
+
+type ScrapTireRequest struct {
+	Notes string `json:"notes"`
+}
+
+type TireResponse struct {
+	models.Tires
+	ItemName     string              `json:"item_name"`
+	SupplierName string              `json:"supplier_name"`
+	PlateNumber  string              `json:"plate_number"`
+	CurrentKm    int                 `json:"current_km"`
+	CostPerKm    float64             `json:"cost_per_km"`
+	Events       []models.TireEvents `json:"events,omitempty"`
+}
+
+type TireCostPerKmResponse struct {
+	GroupId       string  `json:"group_id"`
+	GroupName     string  `json:"group_name"`
+	TireCount     int     `json:"tire_count"`
+	ScrappedCount int     `json:"scrapped_count"`
+	TotalCost     float64 `json:"total_cost"`
+	TotalKm       int     `json:"total_km"`
+	CostPerKm     float64 `json:"cost_per_km"`
+}
+
+func costPerKm(cost float64, km int) float64 {
+	if km <= 0 {
+		return 0
+	}
+	return cost / float64(km)
+}
+
+// lockTire loads a tire in scope inside the transaction and locks it for
+// update.
+func lockTire(ctx context.Context, tx pgx.Tx, scope query.Scope, id string) (models.Tires, error) {
+	var tire models.Tires
+	whereClause, whereArgs := query.ScopeTable("WHERE tires_id = $1", []interface{}{id}, scope, "tires", "")
+	query := `
+		SELECT tires_id, serial_number, item_id, purchasing_id, supplier_id, brand, purchase_cost, total_cost, status,
+		       vehicle_id, position, mount_odometer, total_km, tread_depth, retread_count, created_at, updated_at
+		FROM tires
+	` + whereClause + `
+		FOR UPDATE
+	`
+	err := tx.QueryRow(ctx, query, whereArgs...).Scan(
+		&tire.TiresId,
+		&tire.SerialNumber,
+		&tire.ItemId,
+		&tire.PurchasingId,
+		&tire.SupplierId,
+		&tire.Brand,
+		&tire.PurchaseCost,
+		&tire.TotalCost,
+		&tire.Status,
+		&tire.VehicleId,
+		&tire.Position,
+		&tire.MountOdometer,
+		&tire.TotalKm,
+		&tire.TreadDepth,
+		&tire.RetreadCount,
+		&tire.CreatedAt,
+		&tire.UpdatedAt,
+	)
+	return tire, err
+}
+
+// resolveVehicleOdometer returns the odometer reading to use for a tire event
+// on the vehicle. A supplied reading must not go backwards and is stored as the
+// vehicle's new odometer.
+func resolveVehicleOdometer(ctx context.Context, tx pgx.Tx, vehicleId string, reading *int) (int, int, string) {
+	var current int
+	err := tx.QueryRow(ctx, "SELECT odometer FROM vehicles WHERE vehicles_id = $1 FOR UPDATE", vehicleId).Scan(&current)
+	if err != nil {
+		return 0, fiber.StatusBadRequest, "Vehicle not found"
+	}
+
+	if reading == nil {
+		return current, 0, ""
+	}
+
+	if *reading < current {
+		return 0, fiber.StatusBadRequest, "Odometer cannot be lower than the vehicle's current reading"
+	}
+
+	if *reading > current {
+		_, err = tx.Exec(ctx, "UPDATE vehicles SET odometer = $1, updated_at = $2 WHERE vehicles_id = $3", *reading, time.Now(), vehicleId)
+		if err != nil {
+			errors.LogError("Vehicle odometer update error", err)
+			return 0, fiber.StatusInternalServerError, "Failed to update vehicle odometer"
+		}
+	}
+
+	return *reading, 0, ""
+}
+
+func insertTireEvent(ctx context.Context, tx pgx.Tx, event models.TireEvents) error {
+	query := `
+		INSERT INTO tire_events (tire_id, event_type, vehicle_id, position, odometer, tread_depth, cost, notes, user_id, event_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := tx.Exec(ctx, query,
+		event.TireId,
+		event.EventType,
+		event.VehicleId,
+		event.Position,
+		event.Odometer,
+		event.TreadDepth,
+		event.Cost,
+		event.Notes,
+		event.UserId,
+		time.Now(),
+	)
+	return err
+}
+
+func GetTires(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"t.serial_number", "t.brand", "i.name", "v.plate_number"}
+	filterFields := map[string]string{
+		"status":        "t.status",
+		"brand":         "t.brand",
+		"item_id":       "t.item_id",
+		"vehicle_id":    "t.vehicle_id",
+		"supplier_id":   "t.supplier_id",
+		"purchasing_id": "t.purchasing_id",
+	}
+
+	whereClause, whereArgs := query.BuildScopedWhereClause(params, searchFields, filterFields, "tires", "t")
+	orderClause := query.BuildOrderClause(params, "t.created_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	fromClause := `
+		FROM tires t
+		LEFT JOIN items i ON t.item_id = i.items_id
+		LEFT JOIN suppliers s ON t.supplier_id = s.suppliers_id
+		LEFT JOIN vehicles v ON t.vehicle_id = v.vehicles_id
+	`
+
+	countQuery := "SELECT COUNT(*) " + fromClause + " " + whereClause
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get tires count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count tires",
+		})
+	}
+
+	baseQuery := `
+		SELECT t.tires_id, t.serial_number, t.item_id, t.purchasing_id, t.supplier_id, t.brand, t.purchase_cost, t.total_cost, t.status,
+		       t.vehicle_id, t.position, t.mount_odometer, t.total_km, t.tread_depth, t.retread_count, t.created_at, t.updated_at,
+		       COALESCE(i.name, ''), COALESCE(s.name, ''), COALESCE(v.plate_number, ''), ` + tireKmExpression + `
+	` + fromClause
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get tires query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tires",
+		})
+	}
+	defer rows.Close()
+
+	var tires []TireResponse
+	for rows.Next() {
+		var t TireResponse
+		err := rows.Scan(
+			&t.TiresId,
+			&t.SerialNumber,
+			&t.ItemId,
+			&t.PurchasingId,
+			&t.SupplierId,
+			&t.Brand,
+			&t.PurchaseCost,
+			&t.TotalCost,
+			&t.Status,
+			&t.VehicleId,
+			&t.Position,
+			&t.MountOdometer,
+			&t.TotalKm,
+			&t.TreadDepth,
+			&t.RetreadCount,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+			&t.ItemName,
+			&t.SupplierName,
+			&t.PlateNumber,
+			&t.CurrentKm,
+		)
+		if err != nil {
+			errors.LogError("Tire scan error", err)
+			continue
+		}
+		t.CostPerKm = costPerKm(t.TotalCost, t.CurrentKm)
+		tires = append(tires, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process tires",
+		})
+	}
+
+	response := query.NewPaginatedResponse(tires, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetTireById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var t TireResponse
+	whereClause, whereArgs := query.ScopeTable("WHERE t.tires_id = $1", []interface{}{id}, query.ScopeFrom(c), "tires", "t")
+	query := `
+		SELECT t.tires_id, t.serial_number, t.item_id, t.purchasing_id, t.supplier_id, t.brand, t.purchase_cost, t.total_cost, t.status,
+		       t.vehicle_id, t.position, t.mount_odometer, t.total_km, t.tread_depth, t.retread_count, t.created_at, t.updated_at,
+		       COALESCE(i.name, ''), COALESCE(s.name, ''), COALESCE(v.plate_number, ''), ` + tireKmExpression + `
+		FROM tires t
+		LEFT JOIN items i ON t.item_id = i.items_id
+		LEFT JOIN suppliers s ON t.supplier_id = s.suppliers_id
+		LEFT JOIN vehicles v ON t.vehicle_id = v.vehicles_id
+	` + whereClause
+
+	err := database.DB.QueryRow(ctx, query, whereArgs...).Scan(
+		&t.TiresId,
+		&t.SerialNumber,
+		&t.ItemId,
+		&t.PurchasingId,
+		&t.SupplierId,
+		&t.Brand,
+		&t.PurchaseCost,
+		&t.TotalCost,
+		&t.Status,
+		&t.VehicleId,
+		&t.Position,
+		&t.MountOdometer,
+		&t.TotalKm,
+		&t.TreadDepth,
+		&t.RetreadCount,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.ItemName,
+		&t.SupplierName,
+		&t.PlateNumber,
+		&t.CurrentKm,
+	)
+
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire not found",
+		})
+	}
+	t.CostPerKm = costPerKm(t.TotalCost, t.CurrentKm)
+
+	eventsQuery := `
+		SELECT tire_events_id, tire_id, event_type, vehicle_id, position, odometer, tread_depth, cost, notes, user_id, event_date
+		FROM tire_events
+		WHERE tire_id = $1
+		ORDER BY event_date
+	`
+	eventsRows, err := database.DB.Query(ctx, eventsQuery, id)
+	if err == nil {
+		defer eventsRows.Close()
+		for eventsRows.Next() {
+			var event models.TireEvents
+			err := eventsRows.Scan(
+				&event.TireEventsId,
+				&event.TireId,
+				&event.EventType,
+				&event.VehicleId,
+				&event.Position,
+				&event.Odometer,
+				&event.TreadDepth,
+				&event.Cost,
+				&event.Notes,
+				&event.UserId,
+				&event.EventDate,
+			)
+			if err != nil {
+				errors.LogError("Tire event scan error", err)
+				continue
+			}
+			t.Events = append(t.Events, event)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  t,
+	})
+}
+
+func ReceiveTires(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+
+	var req ReceiveTiresRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.PurchasingId == "" || req.ItemId == "" || req.Brand == "" || len(req.SerialNumbers) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Purchasing ID, item ID, brand and at least one serial number are required",
+		})
+	}
+
+	seen := map[string]bool{}
+	for i, serial := range req.SerialNumbers {
+		serial = strings.ToUpper(strings.TrimSpace(serial))
+		if serial == "" || seen[serial] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Serial numbers must be unique and not empty",
+			})
+		}
+		seen[serial] = true
+		req.SerialNumbers[i] = serial
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var duplicate string
+	err = tx.QueryRow(ctx, "SELECT serial_number FROM tires WHERE serial_number = ANY($1) LIMIT 1", req.SerialNumbers).Scan(&duplicate)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Serial number %s already exists", duplicate),
+		})
+	}
+
+	// Tires are counted in the stock unit, whatever the purchase unit is.
+	var trackSerial bool
+	var stockUnitId string
+	err = tx.QueryRow(ctx, `
+		SELECT track_serial, COALESCE(COALESCE(unit_id, (SELECT units_id FROM units WHERE code = LOWER(TRIM(unit))))::text, '')
+		FROM items WHERE items_id = $1
+	`, req.ItemId).Scan(&trackSerial, &stockUnitId)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Item with ID %s not found", req.ItemId),
+		})
+	}
+
+	line := StockLineRequest{
+		ItemId:    req.ItemId,
+		UnitId:    stockUnitId,
+		Qty:       len(req.SerialNumbers),
+		LotNumber: req.LotNumber,
+	}
+	if trackSerial {
+		line.SerialNumbers = req.SerialNumbers
+	}
+
+	// The tires go through a goods receipt, so they count against the same
+	// open quantity of the purchasing and enter stock and its cost layers.
+	receiptId, status, message := postGoodsReceipt(ctx, tx, query.ScopeFrom(c), claims.UserID, &CreateGoodsReceiptRequest{
+		PurchasingId: req.PurchasingId,
+		WarehouseId:  req.WarehouseId,
+		Notes:        fmt.Sprintf("Tire receipt: %s", req.Brand),
+		Details:      []StockLineRequest{line},
+	}, time.Now())
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	var supplierId string
+	var unitCost float64
+	err = tx.QueryRow(ctx, `
+		SELECT p.supplier_id, d.unit_cost
+		FROM goods_receipt_details d
+		JOIN goods_receipts r ON d.goods_receipt_id = r.goods_receipts_id
+		JOIN purchasings p ON r.purchasing_id = p.purchasings_id
+		WHERE d.goods_receipt_id = $1
+	`, receiptId).Scan(&supplierId, &unitCost)
+	if err != nil {
+		errors.LogError("Goods receipt detail query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to receive tires",
+		})
+	}
+
+	now := time.Now()
+	insertQuery := `
+		INSERT INTO tires (serial_number, item_id, purchasing_id, supplier_id, brand, purchase_cost, total_cost, status,
+		                   vehicle_id, position, mount_odometer, total_km, tread_depth, retread_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, 'in_stock', NULL, '', 0, 0, $7, 0, $8, $8)
+		RETURNING tires_id
+	`
+
+	tireIds := []string{}
+	for _, serial := range req.SerialNumbers {
+		var tireId string
+		err = tx.QueryRow(ctx, insertQuery,
+			serial,
+			req.ItemId,
+			req.PurchasingId,
+			supplierId,
+			req.Brand,
+			unitCost,
+			req.TreadDepth,
+			now,
+		).Scan(&tireId)
+		if err != nil {
+			errors.LogError("Tire creation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to receive tires",
+			})
+		}
+
+		err = insertTireEvent(ctx, tx, models.TireEvents{
+			TireId:     tireId,
+			EventType:  "receive",
+			TreadDepth: req.TreadDepth,
+			Cost:       unitCost,
+			UserId:     claims.UserID,
+		})
+		if err != nil {
+			errors.LogError("Tire event creation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to receive tires",
+			})
+		}
+		tireIds = append(tireIds, tireId)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Tires received successfully",
+		"data": fiber.Map{
+			"tire_ids":         tireIds,
+			"goods_receipt_id": receiptId,
+			"unit_cost":        unitCost,
+		},
+	})
+}
+
+func MountTire(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req MountTireRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.VehicleId == "" || !validTirePositions[req.Position] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle ID and a valid position are required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	tire, err := lockTire(ctx, tx, query.ScopeFrom(c), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire not found",
+		})
+	}
+
+	if tire.Status != "in_stock" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Only tires in stock can be mounted",
+		})
+	}
+
+	odometer, status, message := resolveVehicleOdometer(ctx, tx, req.VehicleId, req.Odometer)
+	if message != "" {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	var occupiedBy string
+	err = tx.QueryRow(ctx, "SELECT serial_number FROM tires WHERE vehicle_id = $1 AND position = $2 AND status = 'mounted'", req.VehicleId, req.Position).Scan(&occupiedBy)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Position %s is already occupied by tire %s", req.Position, occupiedBy),
+		})
+	}
+
+	updateQuery := `
+		UPDATE tires
+		SET status = 'mounted', vehicle_id = $1, position = $2, mount_odometer = $3, updated_at = $4
+		WHERE tires_id = $5
+	`
+	_, err = tx.Exec(ctx, updateQuery, req.VehicleId, req.Position, odometer, time.Now(), id)
+	if err != nil {
+		errors.LogError("Tire mount error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to mount tire",
+		})
+	}
+
+	err = insertTireEvent(ctx, tx, models.TireEvents{
+		TireId:     id,
+		EventType:  "mount",
+		VehicleId:  &req.VehicleId,
+		Position:   req.Position,
+		Odometer:   odometer,
+		TreadDepth: tire.TreadDepth,
+		Notes:      req.Notes,
+		UserId:     claims.UserID,
+	})
+	if err != nil {
+		errors.LogError("Tire event creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to mount tire",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return GetTireById(c)
+}
+
+func DismountTire(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req DismountTireRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	tire, err := lockTire(ctx, tx, query.ScopeFrom(c), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire not found",
+		})
+	}
+
+	if tire.Status != "mounted" || tire.VehicleId == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire is not mounted",
+		})
+	}
+
+	odometer, status, message := resolveVehicleOdometer(ctx, tx, *tire.VehicleId, req.Odometer)
+	if message != "" {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	treadDepth := tire.TreadDepth
+	if req.TreadDepth != nil {
+		treadDepth = *req.TreadDepth
+	}
+
+	km := odometer - tire.MountOdometer
+	if km < 0 {
+		km = 0
+	}
+
+	updateQuery := `
+		UPDATE tires
+		SET status = 'in_stock', vehicle_id = NULL, position = '', mount_odometer = 0,
+		    total_km = total_km + $1, tread_depth = $2, updated_at = $3
+		WHERE tires_id = $4
+	`
+	_, err = tx.Exec(ctx, updateQuery, km, treadDepth, time.Now(), id)
+	if err != nil {
+		errors.LogError("Tire dismount error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to dismount tire",
+		})
+	}
+
+	err = insertTireEvent(ctx, tx, models.TireEvents{
+		TireId:     id,
+		EventType:  "dismount",
+		VehicleId:  tire.VehicleId,
+		Position:   tire.Position,
+		Odometer:   odometer,
+		TreadDepth: treadDepth,
+		Notes:      req.Notes,
+		UserId:     claims.UserID,
+	})
+	if err != nil {
+		errors.LogError("Tire event creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to dismount tire",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return GetTireById(c)
+}
+
+func RotateTire(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req RotateTireRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if !validTirePositions[req.Position] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "A valid position is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	tire, err := lockTire(ctx, tx, query.ScopeFrom(c), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire not found",
+		})
+	}
+
+	if tire.Status != "mounted" || tire.VehicleId == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Only mounted tires can be rotated",
+		})
+	}
+
+	fromVehicleId := *tire.VehicleId
+	toVehicleId := fromVehicleId
+	if req.VehicleId != nil && *req.VehicleId != "" {
+		toVehicleId = *req.VehicleId
+	}
+
+	if toVehicleId == fromVehicleId && req.Position == tire.Position {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire is already mounted at this position",
+		})
+	}
+
+	now := time.Now()
+
+	if toVehicleId == fromVehicleId {
+		odometer, status, message := resolveVehicleOdometer(ctx, tx, fromVehicleId, req.Odometer)
+		if message != "" {
+			return c.Status(status).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+
+		// Rotating onto an occupied position on the same vehicle swaps the two tires.
+		var otherId string
+		err = tx.QueryRow(ctx, "SELECT tires_id FROM tires WHERE vehicle_id = $1 AND position = $2 AND status = 'mounted' FOR UPDATE", fromVehicleId, req.Position).Scan(&otherId)
+		swapping := err == nil
+
+		if swapping {
+			if _, err = tx.Exec(ctx, "UPDATE tires SET position = '', updated_at = $1 WHERE tires_id = $2", now, otherId); err != nil {
+				errors.LogError("Tire rotation error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to rotate tire",
+				})
+			}
+		}
+
+		if _, err = tx.Exec(ctx, "UPDATE tires SET position = $1, updated_at = $2 WHERE tires_id = $3", req.Position, now, id); err != nil {
+			errors.LogError("Tire rotation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to rotate tire",
+			})
+		}
+
+		err = insertTireEvent(ctx, tx, models.TireEvents{
+			TireId:     id,
+			EventType:  "rotate",
+			VehicleId:  &fromVehicleId,
+			Position:   req.Position,
+			Odometer:   odometer,
+			TreadDepth: tire.TreadDepth,
+			Notes:      req.Notes,
+			UserId:     claims.UserID,
+		})
+		if err != nil {
+			errors.LogError("Tire event creation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to rotate tire",
+			})
+		}
+
+		if swapping {
+			if _, err = tx.Exec(ctx, "UPDATE tires SET position = $1, updated_at = $2 WHERE tires_id = $3", tire.Position, now, otherId); err != nil {
+				errors.LogError("Tire rotation error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to rotate tire",
+				})
+			}
+
+			err = insertTireEvent(ctx, tx, models.TireEvents{
+				TireId:    otherId,
+				EventType: "rotate",
+				VehicleId: &fromVehicleId,
+				Position:  tire.Position,
+				Odometer:  odometer,
+				Notes:     req.Notes,
+				UserId:    claims.UserID,
+			})
+			if err != nil {
+				errors.LogError("Tire event creation error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to rotate tire",
+				})
+			}
+		}
+	} else {
+		fromOdometer, status, message := resolveVehicleOdometer(ctx, tx, fromVehicleId, nil)
+		if message != "" {
+			return c.Status(status).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+
+		toOdometer, status, message := resolveVehicleOdometer(ctx, tx, toVehicleId, req.Odometer)
+		if message != "" {
+			return c.Status(status).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+
+		var occupiedBy string
+		err = tx.QueryRow(ctx, "SELECT serial_number FROM tires WHERE vehicle_id = $1 AND position = $2 AND status = 'mounted'", toVehicleId, req.Position).Scan(&occupiedBy)
+		if err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": fmt.Sprintf("Position %s is already occupied by tire %s", req.Position, occupiedBy),
+			})
+		}
+
+		km := fromOdometer - tire.MountOdometer
+		if km < 0 {
+			km = 0
+		}
+
+		updateQuery := `
+			UPDATE tires
+			SET vehicle_id = $1, position = $2, mount_odometer = $3, total_km = total_km + $4, updated_at = $5
+			WHERE tires_id = $6
+		`
+		_, err = tx.Exec(ctx, updateQuery, toVehicleId, req.Position, toOdometer, km, now, id)
+		if err != nil {
+			errors.LogError("Tire rotation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to rotate tire",
+			})
+		}
+
+		err = insertTireEvent(ctx, tx, models.TireEvents{
+			TireId:     id,
+			EventType:  "rotate",
+			VehicleId:  &toVehicleId,
+			Position:   req.Position,
+			Odometer:   toOdometer,
+			TreadDepth: tire.TreadDepth,
+			Notes:      req.Notes,
+			UserId:     claims.UserID,
+		})
+		if err != nil {
+			errors.LogError("Tire event creation error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to rotate tire",
+			})
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return GetTireById(c)
+}
+
+func RetreadTire(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req RetreadTireRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.TreadDepth <= 0 || req.Cost < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tread depth must be greater than 0 and cost cannot be negative",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	tire, err := lockTire(ctx, tx, query.ScopeFrom(c), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire not found",
+		})
+	}
+
+	if tire.Status != "in_stock" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Only dismounted tires in stock can be retreaded",
+		})
+	}
+
+	updateQuery := `
+		UPDATE tires
+		SET total_cost = total_cost + $1, tread_depth = $2, retread_count = retread_count + 1, updated_at = $3
+		WHERE tires_id = $4
+	`
+	_, err = tx.Exec(ctx, updateQuery, req.Cost, req.TreadDepth, time.Now(), id)
+	if err != nil {
+		errors.LogError("Tire retread error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to retread tire",
+		})
+	}
+
+	err = insertTireEvent(ctx, tx, models.TireEvents{
+		TireId:     id,
+		EventType:  "retread",
+		TreadDepth: req.TreadDepth,
+		Cost:       req.Cost,
+		Notes:      req.Notes,
+		UserId:     claims.UserID,
+	})
+	if err != nil {
+		errors.LogError("Tire event creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to retread tire",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return GetTireById(c)
+}
+
+func InspectTire(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req InspectTireRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.TreadDepth <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tread depth must be greater than 0",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	tire, err := lockTire(ctx, tx, query.ScopeFrom(c), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire not found",
+		})
+	}
+
+	if tire.Status == "scrapped" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Scrapped tires cannot be inspected",
+		})
+	}
+
+	odometer := 0
+	if tire.VehicleId != nil {
+		var status int
+		var message string
+		odometer, status, message = resolveVehicleOdometer(ctx, tx, *tire.VehicleId, req.Odometer)
+		if message != "" {
+			return c.Status(status).JSON(fiber.Map{
+				"error":   true,
+				"message": message,
+			})
+		}
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE tires SET tread_depth = $1, updated_at = $2 WHERE tires_id = $3", req.TreadDepth, time.Now(), id)
+	if err != nil {
+		errors.LogError("Tire inspection error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to record inspection",
+		})
+	}
+
+	err = insertTireEvent(ctx, tx, models.TireEvents{
+		TireId:     id,
+		EventType:  "inspect",
+		VehicleId:  tire.VehicleId,
+		Position:   tire.Position,
+		Odometer:   odometer,
+		TreadDepth: req.TreadDepth,
+		Notes:      req.Notes,
+		UserId:     claims.UserID,
+	})
+	if err != nil {
+		errors.LogError("Tire event creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to record inspection",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return GetTireById(c)
+}
+
+func ScrapTire(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*jwt.Claims)
+	id := c.Params("id")
+
+	var req ScrapTireRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	tire, err := lockTire(ctx, tx, query.ScopeFrom(c), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tire not found",
+		})
+	}
+
+	if tire.Status != "in_stock" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Only dismounted tires in stock can be scrapped",
+		})
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE tires SET status = 'scrapped', updated_at = $1 WHERE tires_id = $2", time.Now(), id)
+	if err != nil {
+		errors.LogError("Tire scrap error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to scrap tire",
+		})
+	}
+
+	err = insertTireEvent(ctx, tx, models.TireEvents{
+		TireId:     id,
+		EventType:  "scrap",
+		TreadDepth: tire.TreadDepth,
+		Notes:      req.Notes,
+		UserId:     claims.UserID,
+	})
+	if err != nil {
+		errors.LogError("Tire event creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to scrap tire",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return GetTireById(c)
+}
+
+func GetTireCostPerKmReport(c *fiber.Ctx) error {
+	groupBy := c.Query("group_by", "brand")
+
+	var groupColumns string
+	switch groupBy {
+	case "brand":
+		groupColumns = "t.brand, t.brand"
+	case "supplier":
+		groupColumns = "t.supplier_id::text, COALESCE(s.name, '')"
+	case "item":
+		groupColumns = "t.item_id::text, COALESCE(i.name, '')"
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "group_by must be one of brand, supplier, item",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	whereClause, whereArgs := query.ScopeTable("", nil, query.ScopeFrom(c), "tires", "t")
+	query := fmt.Sprintf(`
+		SELECT %s, COUNT(*), COUNT(*) FILTER (WHERE t.status = 'scrapped'),
+		       COALESCE(SUM(t.total_cost), 0), COALESCE(SUM(%s), 0)
+		FROM tires t
+		LEFT JOIN items i ON t.item_id = i.items_id
+		LEFT JOIN suppliers s ON t.supplier_id = s.suppliers_id
+		LEFT JOIN vehicles v ON t.vehicle_id = v.vehicles_id
+		%s
+		GROUP BY 1, 2
+		ORDER BY 2
+	`, groupColumns, tireKmExpression, whereClause)
+
+	rows, err := database.DB.Query(ctx, query, whereArgs...)
+	if err != nil {
+		errors.LogError("Tire cost per km report error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to build tire cost report",
+		})
+	}
+	defer rows.Close()
+
+	report := []TireCostPerKmResponse{}
+	for rows.Next() {
+		var r TireCostPerKmResponse
+		err := rows.Scan(
+			&r.GroupId,
+			&r.GroupName,
+			&r.TireCount,
+			&r.ScrappedCount,
+			&r.TotalCost,
+			&r.TotalKm,
+		)
+		if err != nil {
+			errors.LogError("Tire cost report scan error", err)
+			continue
+		}
+		r.CostPerKm = costPerKm(r.TotalCost, r.TotalKm)
+		report = append(report, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process tire cost report",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":    false,
+		"data":     report,
+		"count":    len(report),
+		"group_by": groupBy,
+	})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SerialTraceResponse struct {
+	Source         string     `json:"source"`
+	SerialNumber   string     `json:"serial_number"`
+	ItemId         string     `json:"item_id"`
+	ItemName       string     `json:"item_name"`
+	LotNumber      string     `json:"lot_number"`
+	Status         string     `json:"status"`
+	GoodsReceiptId *string    `json:"goods_receipt_id"`
+	ReceivedAt     *time.Time `json:"received_at"`
+	PurchasingId   string     `json:"purchasing_id"`
+	PurchasingDate time.Time  `json:"purchasing_date"`
+	SupplierId     string     `json:"supplier_id"`
+	SupplierName   string     `json:"supplier_name"`
+	StockIssueId   *string    `json:"stock_issue_id"`
+	IssuedAt       *time.Time `json:"issued_at"`
+	VehicleId      *string    `json:"vehicle_id"`
+	PlateNumber    *string    `json:"plate_number"`
+}
+
+type LotMovementResponse struct {
+	Type        string    `json:"type"`
+	DocumentId  string    `json:"document_id"`
+	Date        time.Time `json:"date"`
+	Qty         int       `json:"qty"`
+	Reference   string    `json:"reference"`
+	Counterpart string    `json:"counterpart"`
+}
+
+type LotTraceResponse struct {
+	ItemId       string                `json:"item_id"`
+	ItemName     string                `json:"item_name"`
+	LotNumber    string                `json:"lot_number"`
+	ReceivedQty  int                   `json:"received_qty"`
+	IssuedQty    int                   `json:"issued_qty"`
+	RemainingQty int                   `json:"remaining_qty"`
+	Movements    []LotMovementResponse `json:"movements"`
+}
+
+// TraceSerial follows a serial number from the purchasing it arrived on to the
+// vehicle it was installed on. Tires are traced through their own lifecycle.
+func TraceSerial(c *fiber.Ctx) error {
+	serial := strings.ToUpper(strings.TrimSpace(c.Params("serial")))
+	if serial == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Serial number is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	scope := query.ScopeFrom(c)
+	serialWhere, args := query.ScopeTable("WHERE ser.serial_number = $1", []interface{}{serial}, scope, "item_serials", "ser")
+	tireWhere, args := query.ScopeTable("WHERE t.serial_number = $1", args, scope, "tires", "t")
+
+	query := `
+		SELECT 'item', ser.serial_number, ser.item_id, i.name, COALESCE(ser.lot_number, ''), ser.status,
+		       ser.goods_receipt_id, r.date, p.purchasings_id, p.date, s.suppliers_id, s.name,
+		       ser.stock_issue_id, si.date, si.vehicle_id, v.plate_number
+		FROM item_serials ser
+		JOIN items i ON ser.item_id = i.items_id
+		JOIN goods_receipts r ON ser.goods_receipt_id = r.goods_receipts_id
+		JOIN purchasings p ON r.purchasing_id = p.purchasings_id
+		JOIN suppliers s ON p.supplier_id = s.suppliers_id
+		LEFT JOIN stock_issues si ON ser.stock_issue_id = si.stock_issues_id
+		LEFT JOIN vehicles v ON si.vehicle_id = v.vehicles_id
+		` + serialWhere + `
+		UNION ALL
+		SELECT 'tire', t.serial_number, t.item_id, i.name, '', t.status,
+		       NULL, t.created_at, p.purchasings_id, p.date, s.suppliers_id, s.name,
+		       NULL, NULL, t.vehicle_id, v.plate_number
+		FROM tires t
+		JOIN items i ON t.item_id = i.items_id
+		JOIN purchasings p ON t.purchasing_id = p.purchasings_id
+		JOIN suppliers s ON t.supplier_id = s.suppliers_id
+		LEFT JOIN vehicles v ON t.vehicle_id = v.vehicles_id
+		` + tireWhere
+
+	rows, err := database.DB.Query(ctx, query, args...)
+	if err != nil {
+		errors.LogError("Trace serial query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to trace serial number",
+		})
+	}
+	defer rows.Close()
+
+	traces := []SerialTraceResponse{}
+	for rows.Next() {
+		var t SerialTraceResponse
+		err := rows.Scan(
+			&t.Source,
+			&t.SerialNumber,
+			&t.ItemId,
+			&t.ItemName,
+			&t.LotNumber,
+			&t.Status,
+			&t.GoodsReceiptId,
+			&t.ReceivedAt,
+			&t.PurchasingId,
+			&t.PurchasingDate,
+			&t.SupplierId,
+			&t.SupplierName,
+			&t.StockIssueId,
+			&t.IssuedAt,
+			&t.VehicleId,
+			&t.PlateNumber,
+		)
+		if err != nil {
+			errors.LogError("Serial trace scan error", err)
+			continue
+		}
+		traces = append(traces, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process serial trace",
+		})
+	}
+
+	if len(traces) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Serial number not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  traces,
+		"count": len(traces),
+	})
+}
+
+// TraceLot lists every receipt and issue of a lot, optionally narrowed to a
+// single item, with the remaining balance per item.
+func TraceLot(c *fiber.Ctx) error {
+	lot := strings.TrimSpace(c.Params("lot"))
+	if lot == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Lot number is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args := []interface{}{lot}
+	itemFilter := ""
+	if itemId := c.Query("item_id"); itemId != "" {
+		args = append(args, itemId)
+		itemFilter = " AND d.item_id = $2"
+	}
+
+	scope := query.ScopeFrom(c)
+	receiptWhere, args := query.ScopeTable("WHERE d.lot_number = $1"+itemFilter, args, scope, "goods_receipt_details", "d")
+	issueWhere, args := query.ScopeTable("WHERE d.lot_number = $1"+itemFilter, args, scope, "stock_issue_details", "d")
+
+	query := `
+		SELECT 'receipt', d.item_id, i.name, r.goods_receipts_id, r.date, d.qty, r.purchasing_id::text, COALESCE(s.name, '')
+		FROM goods_receipt_details d
+		JOIN goods_receipts r ON d.goods_receipt_id = r.goods_receipts_id
+		JOIN items i ON d.item_id = i.items_id
+		LEFT JOIN purchasings p ON r.purchasing_id = p.purchasings_id
+		LEFT JOIN suppliers s ON p.supplier_id = s.suppliers_id
+		` + receiptWhere + `
+		UNION ALL
+		SELECT 'issue', d.item_id, i.name, si.stock_issues_id, si.date, d.qty, COALESCE(si.vehicle_id::text, ''), COALESCE(v.plate_number, '')
+		FROM stock_issue_details d
+		JOIN stock_issues si ON d.stock_issue_id = si.stock_issues_id
+		JOIN items i ON d.item_id = i.items_id
+		LEFT JOIN vehicles v ON si.vehicle_id = v.vehicles_id
+		` + issueWhere + `
+		ORDER BY 5
+	`
+
+	rows, err := database.DB.Query(ctx, query, args...)
+	if err != nil {
+		errors.LogError("Trace lot query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to trace lot",
+		})
+	}
+	defer rows.Close()
+
+	traces := []*LotTraceResponse{}
+	byItem := map[string]*LotTraceResponse{}
+	for rows.Next() {
+		var itemId, itemName string
+		var m LotMovementResponse
+		err := rows.Scan(
+			&m.Type,
+			&itemId,
+			&itemName,
+			&m.DocumentId,
+			&m.Date,
+			&m.Qty,
+			&m.Reference,
+			&m.Counterpart,
+		)
+		if err != nil {
+			errors.LogError("Lot trace scan error", err)
+			continue
+		}
+
+		trace, ok := byItem[itemId]
+		if !ok {
+			trace = &LotTraceResponse{ItemId: itemId, ItemName: itemName, LotNumber: lot}
+			byItem[itemId] = trace
+			traces = append(traces, trace)
+		}
+
+		if m.Type == "receipt" {
+			trace.ReceivedQty += m.Qty
+		} else {
+			trace.IssuedQty += m.Qty
+		}
+		trace.RemainingQty = trace.ReceivedQty - trace.IssuedQty
+		trace.Movements = append(trace.Movements, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process lot trace",
+		})
+	}
+
+	if len(traces) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Lot number not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  traces,
+		"count": len(traces),
+	})
+}
+
+func GetItemSerials(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Item ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args := []interface{}{id}
+	whereClause := "WHERE item_id = $1"
+	if status := c.Query("status"); status != "" {
+		args = append(args, status)
+		whereClause += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if warehouseId := c.Query("warehouse_id"); warehouseId != "" {
+		args = append(args, warehouseId)
+		whereClause += fmt.Sprintf(" AND warehouse_id = $%d", len(args))
+	}
+	whereClause, args = query.ScopeTable(whereClause, args, query.ScopeFrom(c), "item_serials", "")
+
+	query := `
+		SELECT item_serials_id, item_id, serial_number, COALESCE(lot_number, ''), status, warehouse_id, goods_receipt_id, stock_issue_id, created_at, updated_at
+		FROM item_serials
+	` + whereClause + " ORDER BY serial_number"
+
+	rows, err := database.DB.Query(ctx, query, args...)
+	if err != nil {
+		errors.LogError("Get item serials query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch item serials",
+		})
+	}
+	defer rows.Close()
+
+	serials := []models.ItemSerials{}
+	for rows.Next() {
+		var serial models.ItemSerials
+		err := rows.Scan(
+			&serial.ItemSerialsId,
+			&serial.ItemId,
+			&serial.SerialNumber,
+			&serial.LotNumber,
+			&serial.Status,
+			&serial.WarehouseId,
+			&serial.GoodsReceiptId,
+			&serial.StockIssueId,
+			&serial.CreatedAt,
+			&serial.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Item serial scan error", err)
+			continue
+		}
+		serials = append(serials, serial)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process item serials",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  serials,
+		"count": len(serials),
+	})
+}
@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateTripRequest struct {
+	VehicleId   string `json:"vehicle_id" validate:"required"`
+	DriverId    string `json:"driver_id" validate:"required"`
+	Origin      string `json:"origin" validate:"required"`
+	Destination string `json:"destination" validate:"required"`
+	DepartureAt string `json:"departure_at" validate:"required"`
+	Status      string `json:"status"`
+	Notes       string `json:"notes"`
+}
+
+type UpdateTripRequest struct {
+	VehicleId   *string `json:"vehicle_id"`
+	DriverId    *string `json:"driver_id"`
+	Origin      *string `json:"origin"`
+	Destination *string `json:"destination"`
+	DepartureAt *string `json:"departure_at"`
+	ArrivalAt   *string `json:"arrival_at"`
+	Status      *string `json:"status"`
+	Notes       *string `json:"notes"`
+}
+
+type TripResponse struct {
+	models.Trips
+	PlateNumber string `json:"plate_number"`
+	DriverName  string `json:"driver_name"`
+}
+
+// checkVehicleAssignable verifies the vehicle exists, is active and has no
+// expired blocking compliance documents. It returns a non-empty message when
+// the vehicle must not be assigned to a trip.
+func checkVehicleAssignable(ctx context.Context, vehicleId string) (int, string) {
+	var isActive bool
+	err := database.DB.QueryRow(ctx, "SELECT is_active FROM vehicles WHERE vehicles_id = $1", vehicleId).Scan(&isActive)
+	if err != nil {
+		return fiber.StatusBadRequest, "Vehicle not found"
+	}
+
+	if !isActive {
+		return fiber.StatusUnprocessableEntity, "Vehicle is inactive"
+	}
+
+	expired, err := expiredBlockingDocuments(ctx, vehicleId)
+	if err != nil {
+		errors.LogError("Vehicle compliance check error", err)
+		return fiber.StatusInternalServerError, "Failed to check vehicle compliance"
+	}
+
+	if len(expired) > 0 {
+		return fiber.StatusUnprocessableEntity, fmt.Sprintf("Vehicle cannot be assigned: expired or missing %s", strings.Join(expired, ", "))
+	}
+
+	return 0, ""
+}
+
+func GetTrips(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"t.origin", "t.destination", "t.notes", "v.plate_number", "u.full_name"}
+	filterFields := map[string]string{
+		"status":     "t.status",
+		"vehicle_id": "t.vehicle_id",
+		"driver_id":  "t.driver_id",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "t.created_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM trips t
+		LEFT JOIN vehicles v ON t.vehicle_id = v.vehicles_id
+		LEFT JOIN users u ON t.driver_id = u.users_id
+		%s
+	`, whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get trips count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count trips",
+		})
+	}
+
+	baseQuery := `
+		SELECT t.trips_id, t.vehicle_id, t.driver_id, t.origin, t.destination, t.departure_at, t.arrival_at, t.status, t.notes, t.created_at, t.updated_at,
+		       COALESCE(v.plate_number, ''), COALESCE(u.full_name, '')
+		FROM trips t
+		LEFT JOIN vehicles v ON t.vehicle_id = v.vehicles_id
+		LEFT JOIN users u ON t.driver_id = u.users_id
+	`
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get trips query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch trips",
+		})
+	}
+	defer rows.Close()
+
+	var trips []TripResponse
+	for rows.Next() {
+		var t TripResponse
+		err := rows.Scan(
+			&t.TripsId,
+			&t.VehicleId,
+			&t.DriverId,
+			&t.Origin,
+			&t.Destination,
+			&t.DepartureAt,
+			&t.ArrivalAt,
+			&t.Status,
+			&t.Notes,
+			&t.CreatedAt,
+			&t.UpdatedAt,
+			&t.PlateNumber,
+			&t.DriverName,
+		)
+		if err != nil {
+			errors.LogError("Trip scan error", err)
+			continue
+		}
+		trips = append(trips, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process trips",
+		})
+	}
+
+	response := query.NewPaginatedResponse(trips, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetTripById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Trip ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var t TripResponse
+	query := `
+		SELECT t.trips_id, t.vehicle_id, t.driver_id, t.origin, t.destination, t.departure_at, t.arrival_at, t.status, t.notes, t.created_at, t.updated_at,
+		       COALESCE(v.plate_number, ''), COALESCE(u.full_name, '')
+		FROM trips t
+		LEFT JOIN vehicles v ON t.vehicle_id = v.vehicles_id
+		LEFT JOIN users u ON t.driver_id = u.users_id
+		WHERE t.trips_id = $1
+	`
+
+	err := database.DB.QueryRow(ctx, query, id).Scan(
+		&t.TripsId,
+		&t.VehicleId,
+		&t.DriverId,
+		&t.Origin,
+		&t.Destination,
+		&t.DepartureAt,
+		&t.ArrivalAt,
+		&t.Status,
+		&t.Notes,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.PlateNumber,
+		&t.DriverName,
+	)
+
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Trip not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  t,
+	})
+}
+
+func CreateTrip(c *fiber.Ctx) error {
+	var req CreateTripRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.VehicleId == "" || req.DriverId == "" || req.Origin == "" || req.Destination == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle ID, driver ID, origin and destination are required",
+		})
+	}
+
+	departureAt, err := time.Parse(time.RFC3339, req.DepartureAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid departure_at format. Use RFC3339",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if status, message := checkVehicleAssignable(ctx, req.VehicleId); message != "" {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	var driverExists string
+	err = database.DB.QueryRow(ctx, "SELECT users_id FROM users WHERE users_id = $1", req.DriverId).Scan(&driverExists)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Driver not found",
+		})
+	}
+
+	status := "scheduled"
+	if req.Status != "" {
+		status = req.Status
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO trips (vehicle_id, driver_id, origin, destination, departure_at, status, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING trips_id, vehicle_id, driver_id, origin, destination, departure_at, arrival_at, status, notes, created_at, updated_at
+	`
+
+	var trip models.Trips
+	err = database.DB.QueryRow(ctx, query,
+		req.VehicleId,
+		req.DriverId,
+		req.Origin,
+		req.Destination,
+		departureAt,
+		status,
+		req.Notes,
+		now,
+		now,
+	).Scan(
+		&trip.TripsId,
+		&trip.VehicleId,
+		&trip.DriverId,
+		&trip.Origin,
+		&trip.Destination,
+		&trip.DepartureAt,
+		&trip.ArrivalAt,
+		&trip.Status,
+		&trip.Notes,
+		&trip.CreatedAt,
+		&trip.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Trip creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create trip",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Trip created successfully",
+		"data":    trip,
+	})
+}
+
+func UpdateTrip(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Trip ID is required",
+		})
+	}
+
+	var req UpdateTripRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingTrip models.Trips
+	checkQuery := `SELECT trips_id, vehicle_id FROM trips WHERE trips_id = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingTrip.TripsId, &existingTrip.VehicleId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Trip not found",
+		})
+	}
+
+	updateFields := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	if req.VehicleId != nil {
+		if *req.VehicleId != existingTrip.VehicleId {
+			if status, message := checkVehicleAssignable(ctx, *req.VehicleId); message != "" {
+				return c.Status(status).JSON(fiber.Map{
+					"error":   true,
+					"message": message,
+				})
+			}
+		}
+		updateFields = append(updateFields, fmt.Sprintf("vehicle_id = $%d", argPos))
+		args = append(args, *req.VehicleId)
+		argPos++
+	}
+
+	if req.DriverId != nil {
+		var driverExists string
+		err = database.DB.QueryRow(ctx, "SELECT users_id FROM users WHERE users_id = $1", *req.DriverId).Scan(&driverExists)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Driver not found",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("driver_id = $%d", argPos))
+		args = append(args, *req.DriverId)
+		argPos++
+	}
+
+	if req.Origin != nil {
+		updateFields = append(updateFields, fmt.Sprintf("origin = $%d", argPos))
+		args = append(args, *req.Origin)
+		argPos++
+	}
+
+	if req.Destination != nil {
+		updateFields = append(updateFields, fmt.Sprintf("destination = $%d", argPos))
+		args = append(args, *req.Destination)
+		argPos++
+	}
+
+	if req.DepartureAt != nil {
+		departureAt, err := time.Parse(time.RFC3339, *req.DepartureAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid departure_at format. Use RFC3339",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("departure_at = $%d", argPos))
+		args = append(args, departureAt)
+		argPos++
+	}
+
+	if req.ArrivalAt != nil {
+		arrivalAt, err := time.Parse(time.RFC3339, *req.ArrivalAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid arrival_at format. Use RFC3339",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("arrival_at = $%d", argPos))
+		args = append(args, arrivalAt)
+		argPos++
+	}
+
+	if req.Status != nil {
+		updateFields = append(updateFields, fmt.Sprintf("status = $%d", argPos))
+		args = append(args, *req.Status)
+		argPos++
+	}
+
+	if req.Notes != nil {
+		updateFields = append(updateFields, fmt.Sprintf("notes = $%d", argPos))
+		args = append(args, *req.Notes)
+		argPos++
+	}
+
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	updateFields = append(updateFields, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE trips
+		SET %s
+		WHERE trips_id = $%d
+		RETURNING trips_id, vehicle_id, driver_id, origin, destination, departure_at, arrival_at, status, notes, created_at, updated_at
+	`, strings.Join(updateFields, ", "), argPos)
+
+	var trip models.Trips
+	err = database.DB.QueryRow(ctx, query, args...).Scan(
+		&trip.TripsId,
+		&trip.VehicleId,
+		&trip.DriverId,
+		&trip.Origin,
+		&trip.Destination,
+		&trip.DepartureAt,
+		&trip.ArrivalAt,
+		&trip.Status,
+		&trip.Notes,
+		&trip.CreatedAt,
+		&trip.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Trip update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update trip",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Trip updated successfully",
+		"data":    trip,
+	})
+}
+
+func DeleteTrip(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Trip ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingTrip models.Trips
+	checkQuery := `SELECT trips_id FROM trips WHERE trips_id = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingTrip.TripsId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Trip not found",
+		})
+	}
+
+	deleteQuery := `DELETE FROM trips WHERE trips_id = $1`
+	_, err = database.DB.Exec(ctx, deleteQuery, id)
+	if err != nil {
+		errors.LogError("Trip deletion error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete trip",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Trip deleted successfully",
+	})
+}
@@ -0,0 +1,856 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/internal/loginguard"
+	"fleetify/internal/models"
+	"fleetify/internal/revocation"
+	"fleetify/pkg/barcode"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/password"
+	"fleetify/pkg/totp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// maxChallengeAttempts is how many wrong codes a login challenge takes
+// before the password has to be entered again.
+const maxChallengeAttempts = 5
+
+// twoFactorChallengeTTL is how long the second login step may take.
+func twoFactorChallengeTTL() time.Duration {
+	ttl, err := time.ParseDuration(config.AppConfig.TwoFactor.ChallengeExpiresIn)
+	if err != nil || ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return ttl
+}
+
+// startLoginChallenge stores a challenge for a user who passed the password
+// check and returns its token.
+func startLoginChallenge(ctx context.Context, c *fiber.Ctx, userId string) (string, time.Duration, error) {
+	token, tokenHash, err := jwt.NewOpaqueToken()
+	if err != nil {
+		return "", 0, err
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM login_challenges WHERE user_id = $1 AND expires_at < NOW()", userId)
+	if err != nil {
+		return "", 0, err
+	}
+
+	ttl := twoFactorChallengeTTL()
+	now := time.Now()
+	_, err = database.DB.Exec(ctx, `
+		INSERT INTO login_challenges (user_id, token_hash, expires_at, ip_address, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`, userId, tokenHash, now.Add(ttl), c.IP(), now)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, ttl, nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes with or without the
+// dash and in any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// newRecoveryCodes replaces the user's recovery codes and returns the new
+// ones. Only their hashes are stored.
+func newRecoveryCodes(ctx context.Context, q execer, userId string) ([]string, error) {
+	if _, err := q.Exec(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userId); err != nil {
+		return nil, err
+	}
+
+	count := config.AppConfig.TwoFactor.RecoveryCodes
+	if count <= 0 {
+		count = 10
+	}
+
+	now := time.Now()
+	codes := make([]string, 0, count)
+	for len(codes) < count {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(buf))[:10]
+		code := raw[:5] + "-" + raw[5:]
+
+		_, err := q.Exec(ctx, `
+			INSERT INTO user_recovery_codes (user_id, code_hash, created_at, updated_at)
+			VALUES ($1, $2, $3, $3)
+		`, userId, jwt.HashOpaqueToken(raw), now)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code
+// of the user and uses it up.
+func verifySecondFactor(ctx context.Context, q execer, userId, secret string, lastStep int64, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := totp.Validate(secret, code, time.Now(), lastStep)
+		if !ok {
+			return false, nil
+		}
+		// The condition keeps two concurrent requests from using one code.
+		tag, err := q.Exec(ctx, `
+			UPDATE users SET two_factor_last_step = $1
+			WHERE users_id = $2 AND two_factor_last_step < $1
+		`, step, userId)
+		if err != nil {
+			return false, err
+		}
+		return tag.RowsAffected() == 1, nil
+	}
+
+	if recoveryCode != "" {
+		now := time.Now()
+		tag, err := q.Exec(ctx, `
+			UPDATE user_recovery_codes SET used_at = $1, updated_at = $1
+			WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+		`, now, userId, jwt.HashOpaqueToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return false, err
+		}
+		return tag.RowsAffected() == 1, nil
+	}
+
+	return false, nil
+}
+
+// GetTwoFactorStatus tells the current user whether two-factor
+// authentication is enabled, required for their role and how many recovery
+// codes are left.
+func GetTwoFactorStatus(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var enabled bool
+	var role string
+	var remaining int
+	err := database.DB.QueryRow(ctx, `
+		SELECT two_factor_enabled, role,
+			(SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = users.users_id AND used_at IS NULL)
+		FROM users
+		WHERE users_id = $1
+	`, claims.UserID).Scan(&enabled, &role, &remaining)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"enabled":                  enabled,
+			"required":                 config.AppConfig.TwoFactor.RoleRequired(role),
+			"recovery_codes_remaining": remaining,
+		},
+	})
+}
+
+// SetupTwoFactor creates a new TOTP secret for the current user and returns
+// it with an otpauth URI and its QR code. Two-factor authentication is only
+// enabled once EnableTwoFactor confirms a code from the app.
+func SetupTwoFactor(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var username string
+	var enabled bool
+	err := database.DB.QueryRow(ctx, "SELECT username, two_factor_enabled FROM users WHERE users_id = $1", claims.UserID).Scan(&username, &enabled)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if enabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Two-factor authentication is already enabled",
+		})
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		errors.LogError("TOTP secret generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to set up two-factor authentication",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, `
+		UPDATE users SET two_factor_secret = $1, two_factor_last_step = 0, updated_at = $2
+		WHERE users_id = $3 AND NOT two_factor_enabled
+	`, secret, time.Now(), claims.UserID)
+	if err != nil {
+		errors.LogError("TOTP secret update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to set up two-factor authentication",
+		})
+	}
+
+	uri := totp.URI(config.AppConfig.TwoFactor.Issuer, username, secret)
+
+	var qr bytes.Buffer
+	if err := barcode.WritePNG(&qr, barcode.QR, uri, 256, 256); err != nil {
+		errors.LogError("TOTP QR code error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to set up two-factor authentication",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Scan the QR code with an authenticator app and confirm with a code to enable two-factor authentication",
+		"data": fiber.Map{
+			"secret":      secret,
+			"otpauth_uri": uri,
+			"qr_code":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(qr.Bytes()),
+		},
+	})
+}
+
+// EnableTwoFactor confirms the secret from SetupTwoFactor with a code and
+// turns two-factor authentication on. The recovery codes are returned only
+// here. All sessions are revoked, so the user logs in again with both
+// factors.
+func EnableTwoFactor(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Code is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to enable two-factor authentication",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var enabled bool
+	var secret string
+	var lastStep int64
+	err = tx.QueryRow(ctx, `
+		SELECT two_factor_enabled, two_factor_secret, two_factor_last_step
+		FROM users WHERE users_id = $1
+		FOR UPDATE
+	`, claims.UserID).Scan(&enabled, &secret, &lastStep)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if enabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Two-factor authentication is already enabled",
+		})
+	}
+
+	if secret == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Start two-factor setup first",
+		})
+	}
+
+	verified, err := verifySecondFactor(ctx, tx, claims.UserID, secret, lastStep, req.Code, "")
+	if err != nil {
+		errors.LogError("TOTP verification error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to enable two-factor authentication",
+		})
+	}
+	if !verified {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid two-factor code",
+		})
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE users SET two_factor_enabled = true, updated_at = $1 WHERE users_id = $2", time.Now(), claims.UserID)
+	if err != nil {
+		errors.LogError("Enable two-factor error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to enable two-factor authentication",
+		})
+	}
+
+	codes, err := newRecoveryCodes(ctx, tx, claims.UserID)
+	if err != nil {
+		errors.LogError("Recovery code generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to enable two-factor authentication",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to enable two-factor authentication",
+		})
+	}
+
+	if err := revocation.RevokeUser(ctx, claims.UserID, "two_factor_enabled"); err != nil {
+		errors.LogError("Two-factor enable revoke error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Two-factor authentication enabled. Keep the recovery codes somewhere safe and log in again",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableTwoFactor turns two-factor authentication off for the current user
+// after checking the password and a code. Wrong ones count as failed logins.
+// Users whose role requires it cannot turn it off.
+func DisableTwoFactor(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req DisableTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Password == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Password and a code or recovery code are required",
+		})
+	}
+
+	if config.AppConfig.TwoFactor.RoleRequired(claims.Role) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Two-factor authentication is required for your role",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ip := c.IP()
+	block, err := loginguard.Check(ctx, claims.Username, ip)
+	if err != nil {
+		errors.LogError("Login guard check error", err)
+	}
+	if block != nil {
+		return blockedLogin(c, block)
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to disable two-factor authentication",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var currentHash, secret string
+	var enabled bool
+	var lastStep int64
+	err = tx.QueryRow(ctx, `
+		SELECT password, two_factor_enabled, two_factor_secret, two_factor_last_step
+		FROM users WHERE users_id = $1
+		FOR UPDATE
+	`, claims.UserID).Scan(&currentHash, &enabled, &secret, &lastStep)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if !enabled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Two-factor authentication is not enabled",
+		})
+	}
+
+	if !password.Verify(req.Password, currentHash) {
+		failLogin(ctx, claims.Username, ip)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Password is incorrect",
+		})
+	}
+
+	verified, err := verifySecondFactor(ctx, tx, claims.UserID, secret, lastStep, req.Code, req.RecoveryCode)
+	if err != nil {
+		errors.LogError("TOTP verification error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to disable two-factor authentication",
+		})
+	}
+	if !verified {
+		failLogin(ctx, claims.Username, ip)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid two-factor code",
+		})
+	}
+
+	if err := clearTwoFactor(ctx, tx, claims.UserID); err != nil {
+		errors.LogError("Disable two-factor error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to disable two-factor authentication",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to disable two-factor authentication",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes after
+// checking a code from the authenticator app. Wrong codes count as failed
+// logins.
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Code is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ip := c.IP()
+	block, err := loginguard.Check(ctx, claims.Username, ip)
+	if err != nil {
+		errors.LogError("Login guard check error", err)
+	}
+	if block != nil {
+		return blockedLogin(c, block)
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate recovery codes",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var enabled bool
+	var secret string
+	var lastStep int64
+	err = tx.QueryRow(ctx, `
+		SELECT two_factor_enabled, two_factor_secret, two_factor_last_step
+		FROM users WHERE users_id = $1
+		FOR UPDATE
+	`, claims.UserID).Scan(&enabled, &secret, &lastStep)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if !enabled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Two-factor authentication is not enabled",
+		})
+	}
+
+	verified, err := verifySecondFactor(ctx, tx, claims.UserID, secret, lastStep, req.Code, "")
+	if err != nil {
+		errors.LogError("TOTP verification error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate recovery codes",
+		})
+	}
+	if !verified {
+		failLogin(ctx, claims.Username, ip)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid two-factor code",
+		})
+	}
+
+	codes, err := newRecoveryCodes(ctx, tx, claims.UserID)
+	if err != nil {
+		errors.LogError("Recovery code generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate recovery codes",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate recovery codes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Recovery codes replaced; the old ones no longer work",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// LoginTwoFactor completes a login that Login answered with a challenge,
+// using a TOTP code or a recovery code. Wrong codes count as failed logins,
+// and a challenge is dropped after maxChallengeAttempts of them.
+func LoginTwoFactor(c *fiber.Ctx) error {
+	var req LoginTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "challenge_token and a code or recovery_code are required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to complete login",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var challenge models.LoginChallenges
+	var user models.Users
+	err = tx.QueryRow(ctx, `
+		SELECT c.login_challenges_id, c.expires_at, c.attempts,
+			u.users_id, u.username, u.role, u.full_name, u.email, u.phone, u.is_active,
+			u.must_change_password, u.two_factor_enabled, u.two_factor_secret, u.two_factor_last_step
+		FROM login_challenges c
+		JOIN users u ON c.user_id = u.users_id
+		WHERE c.token_hash = $1
+		FOR UPDATE OF c
+	`, jwt.HashOpaqueToken(req.ChallengeToken)).Scan(
+		&challenge.LoginChallengesId,
+		&challenge.ExpiresAt,
+		&challenge.Attempts,
+		&user.UsersId,
+		&user.Username,
+		&user.Role,
+		&user.FullName,
+		&user.Email,
+		&user.Phone,
+		&user.IsActive,
+		&user.MustChangePassword,
+		&user.TwoFactorEnabled,
+		&user.TwoFactorSecret,
+		&user.TwoFactorLastStep,
+	)
+	if err != nil || time.Now().After(challenge.ExpiresAt) || challenge.Attempts >= maxChallengeAttempts || !user.TwoFactorEnabled {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired login challenge, please log in again",
+		})
+	}
+
+	ip := c.IP()
+	block, err := loginguard.Check(ctx, user.Username, ip)
+	if err != nil {
+		errors.LogError("Login guard check error", err)
+	}
+	if block != nil {
+		return blockedLogin(c, block)
+	}
+
+	if !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Account is inactive",
+		})
+	}
+
+	verified, err := verifySecondFactor(ctx, tx, user.UsersId, user.TwoFactorSecret, user.TwoFactorLastStep, req.Code, req.RecoveryCode)
+	if err != nil {
+		errors.LogError("TOTP verification error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to complete login",
+		})
+	}
+	if !verified {
+		_, err = tx.Exec(ctx, "UPDATE login_challenges SET attempts = attempts + 1, updated_at = $1 WHERE login_challenges_id = $2", time.Now(), challenge.LoginChallengesId)
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		if err != nil {
+			errors.LogError("Login challenge update error", err)
+		}
+		failLogin(ctx, user.Username, ip)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid two-factor code",
+		})
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM login_challenges WHERE login_challenges_id = $1", challenge.LoginChallengesId)
+	if err != nil {
+		errors.LogError("Login challenge delete error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to complete login",
+		})
+	}
+
+	// Expired refresh tokens can no longer be used or replayed; drop them.
+	_, err = tx.Exec(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < NOW()", user.UsersId)
+	if err != nil {
+		errors.LogError("Refresh token cleanup error", err)
+	}
+
+	response, _, err := issueTokens(ctx, tx, c, user, uuid.New().String())
+	if err != nil {
+		errors.LogError("Token generation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate token",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to complete login",
+		})
+	}
+
+	if err = loginguard.Succeed(ctx, user.Username); err != nil {
+		errors.LogError("Login guard reset error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  response,
+	})
+}
+
+// clearTwoFactor turns two-factor authentication off for the user and drops
+// the recovery codes and pending login challenges.
+func clearTwoFactor(ctx context.Context, q execer, userId string) error {
+	_, err := q.Exec(ctx, `
+		UPDATE users SET two_factor_enabled = false, two_factor_secret = '', two_factor_last_step = 0, updated_at = $1
+		WHERE users_id = $2
+	`, time.Now(), userId)
+	if err != nil {
+		return err
+	}
+
+	if _, err = q.Exec(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userId); err != nil {
+		return err
+	}
+	_, err = q.Exec(ctx, "DELETE FROM login_challenges WHERE user_id = $1", userId)
+	return err
+}
+
+// ResetUserTwoFactor turns off two-factor authentication of a user who lost
+// their device and signs them out everywhere. If their role requires it,
+// they have to enroll again on the next login.
+func ResetUserTwoFactor(c *fiber.Ctx) error {
+	username := c.Params("uname")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Username parameter is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var userId string
+	err := database.DB.QueryRow(ctx, "SELECT users_id FROM users WHERE username = $1", username).Scan(&userId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Begin transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset two-factor authentication",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	if err := clearTwoFactor(ctx, tx, userId); err != nil {
+		errors.LogError("Reset two-factor error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset two-factor authentication",
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		errors.LogError("Commit transaction error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset two-factor authentication",
+		})
+	}
+
+	if err := revocation.RevokeUser(ctx, userId, "two_factor_reset"); err != nil {
+		errors.LogError("Two-factor reset revoke error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Two-factor authentication reset",
+	})
+}
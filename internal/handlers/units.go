@@ -0,0 +1,629 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateUnitRequest struct {
+	Code string `json:"code" validate:"required"`
+	Name string `json:"name" validate:"required"`
+}
+
+type UpdateUnitRequest struct {
+	Code *string `json:"code"`
+	Name *string `json:"name"`
+}
+
+type ItemUnitRequest struct {
+	UnitId string `json:"unit_id" validate:"required"`
+	Factor int    `json:"factor" validate:"required,gt=0"`
+}
+
+type SetItemUnitsRequest struct {
+	UnitId         *string           `json:"unit_id"`
+	PurchaseUnitId *string           `json:"purchase_unit_id"`
+	Conversions    []ItemUnitRequest `json:"conversions"`
+}
+
+type ItemUnitResponse struct {
+	models.ItemUnits
+	UnitCode string `json:"unit_code"`
+	UnitName string `json:"unit_name"`
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// unitQuantity is a line quantity as entered together with its value in the
+// item's stock unit.
+type unitQuantity struct {
+	UnitId  *string
+	UnitQty int
+	Factor  int
+	Qty     int
+}
+
+// convertToStockUnits turns qty in unitId into the item's stock unit. An
+// empty unit means the item's purchase unit, or the stock unit when the item
+// has none, so callers that never send units keep working in stock units.
+func convertToStockUnits(ctx context.Context, q rowQuerier, itemId, unitId string, qty int) (unitQuantity, string) {
+	var itemName string
+	var stockUnitId, purchaseUnitId *string
+	err := q.QueryRow(ctx, `
+		SELECT i.name, COALESCE(i.unit_id, (SELECT units_id FROM units WHERE code = LOWER(TRIM(i.unit)))), i.purchase_unit_id
+		FROM items i
+		WHERE i.items_id = $1
+	`, itemId).Scan(&itemName, &stockUnitId, &purchaseUnitId)
+	if err != nil {
+		return unitQuantity{}, fmt.Sprintf("Item with ID %s not found", itemId)
+	}
+
+	if unitId == "" && purchaseUnitId != nil {
+		unitId = *purchaseUnitId
+	}
+
+	if unitId == "" || (stockUnitId != nil && unitId == *stockUnitId) {
+		return unitQuantity{UnitId: stockUnitId, UnitQty: qty, Factor: 1, Qty: qty}, ""
+	}
+
+	var factor int
+	err = q.QueryRow(ctx, "SELECT factor FROM item_units WHERE item_id = $1 AND unit_id = $2", itemId, unitId).Scan(&factor)
+	if err != nil {
+		return unitQuantity{}, fmt.Sprintf("Unit %s has no conversion configured for item %s", unitId, itemName)
+	}
+
+	return unitQuantity{UnitId: &unitId, UnitQty: qty, Factor: factor, Qty: qty * factor}, ""
+}
+
+func GetUnits(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"code", "name"}
+	filterFields := map[string]string{}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "code")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("units", whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get units count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count units",
+		})
+	}
+
+	baseQuery := `
+		SELECT units_id, code, name, created_at, updated_at
+		FROM units
+	`
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get units query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch units",
+		})
+	}
+	defer rows.Close()
+
+	var units []models.Units
+	for rows.Next() {
+		var unit models.Units
+		err := rows.Scan(
+			&unit.UnitsId,
+			&unit.Code,
+			&unit.Name,
+			&unit.CreatedAt,
+			&unit.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Unit scan error", err)
+			continue
+		}
+		units = append(units, unit)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process units",
+		})
+	}
+
+	response := query.NewPaginatedResponse(units, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetUnitById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var unit models.Units
+	err := database.DB.QueryRow(ctx, "SELECT units_id, code, name, created_at, updated_at FROM units WHERE units_id = $1", id).Scan(
+		&unit.UnitsId,
+		&unit.Code,
+		&unit.Name,
+		&unit.CreatedAt,
+		&unit.UpdatedAt,
+	)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  unit,
+	})
+}
+
+func CreateUnit(c *fiber.Ctx) error {
+	var req CreateUnitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Code = strings.ToLower(strings.TrimSpace(req.Code))
+	if req.Code == "" || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Code and name are required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT units_id FROM units WHERE code = $1", req.Code).Scan(&existing)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit code already exists",
+		})
+	}
+
+	now := time.Now()
+	var unit models.Units
+	err = database.DB.QueryRow(ctx, `
+		INSERT INTO units (code, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		RETURNING units_id, code, name, created_at, updated_at
+	`, req.Code, req.Name, now).Scan(
+		&unit.UnitsId,
+		&unit.Code,
+		&unit.Name,
+		&unit.CreatedAt,
+		&unit.UpdatedAt,
+	)
+	if err != nil {
+		errors.LogError("Unit creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create unit",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Unit created successfully",
+		"data":    unit,
+	})
+}
+
+func UpdateUnit(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit ID is required",
+		})
+	}
+
+	var req UpdateUnitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT units_id FROM units WHERE units_id = $1", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit not found",
+		})
+	}
+
+	updateFields := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	if req.Code != nil {
+		code := strings.ToLower(strings.TrimSpace(*req.Code))
+		var duplicate string
+		err = database.DB.QueryRow(ctx, "SELECT units_id FROM units WHERE code = $1 AND units_id <> $2", code, id).Scan(&duplicate)
+		if err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Unit code already exists",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("code = $%d", argPos))
+		args = append(args, code)
+		argPos++
+	}
+
+	if req.Name != nil {
+		updateFields = append(updateFields, fmt.Sprintf("name = $%d", argPos))
+		args = append(args, *req.Name)
+		argPos++
+	}
+
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	updateFields = append(updateFields, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	args = append(args, id)
+	updateQuery := fmt.Sprintf(`
+		UPDATE units
+		SET %s
+		WHERE units_id = $%d
+		RETURNING units_id, code, name, created_at, updated_at
+	`, strings.Join(updateFields, ", "), argPos)
+
+	var unit models.Units
+	err = database.DB.QueryRow(ctx, updateQuery, args...).Scan(
+		&unit.UnitsId,
+		&unit.Code,
+		&unit.Name,
+		&unit.CreatedAt,
+		&unit.UpdatedAt,
+	)
+	if err != nil {
+		errors.LogError("Unit update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update unit",
+		})
+	}
+
+	// Keep the free-text unit on items in step with the managed code.
+	_, err = database.DB.Exec(ctx, "UPDATE items SET unit = $1 WHERE unit_id = $2", unit.Code, id)
+	if err != nil {
+		errors.LogError("Item unit sync error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Unit updated successfully",
+		"data":    unit,
+	})
+}
+
+func DeleteUnit(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existing string
+	err := database.DB.QueryRow(ctx, "SELECT units_id FROM units WHERE units_id = $1", id).Scan(&existing)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit not found",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM units WHERE units_id = $1", id)
+	if err != nil {
+		errors.LogError("Unit deletion error", err)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unit is used by items or documents and cannot be deleted",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Unit deleted successfully",
+	})
+}
+
+func fetchItemUnits(ctx context.Context, itemId string) ([]ItemUnitResponse, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT iu.item_units_id, iu.item_id, iu.unit_id, iu.factor, iu.created_at, iu.updated_at, u.code, u.name
+		FROM item_units iu
+		JOIN units u ON iu.unit_id = u.units_id
+		WHERE iu.item_id = $1
+		ORDER BY iu.factor
+	`, itemId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversions := []ItemUnitResponse{}
+	for rows.Next() {
+		var conversion ItemUnitResponse
+		err := rows.Scan(
+			&conversion.ItemUnitsId,
+			&conversion.ItemId,
+			&conversion.UnitId,
+			&conversion.Factor,
+			&conversion.CreatedAt,
+			&conversion.UpdatedAt,
+			&conversion.UnitCode,
+			&conversion.UnitName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		conversions = append(conversions, conversion)
+	}
+
+	return conversions, rows.Err()
+}
+
+func GetItemUnits(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var unit string
+	var unitId, purchaseUnitId *string
+	err := database.DB.QueryRow(ctx, "SELECT COALESCE(unit, ''), unit_id, purchase_unit_id FROM items WHERE items_id = $1", id).Scan(&unit, &unitId, &purchaseUnitId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Item not found",
+		})
+	}
+
+	conversions, err := fetchItemUnits(ctx, id)
+	if err != nil {
+		errors.LogError("Get item units query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch item units",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data": fiber.Map{
+			"unit":             unit,
+			"unit_id":          unitId,
+			"purchase_unit_id": purchaseUnitId,
+			"conversions":      conversions,
+		},
+	})
+}
+
+// SetItemUnits sets the item's stock and purchase units and replaces its
+// conversion factors. The stock unit cannot change while the item holds
+// stock, since every stored quantity is expressed in it.
+func SetItemUnits(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req SetItemUnitsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var stock int
+	var stockUnitId *string
+	err = tx.QueryRow(ctx, "SELECT stock, unit_id FROM items WHERE items_id = $1 FOR UPDATE", id).Scan(&stock, &stockUnitId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Item not found",
+		})
+	}
+
+	now := time.Now()
+	if req.UnitId != nil && (stockUnitId == nil || *stockUnitId != *req.UnitId) {
+		if stock != 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Stock unit cannot be changed while the item has stock",
+			})
+		}
+
+		var code string
+		err = tx.QueryRow(ctx, "SELECT code FROM units WHERE units_id = $1", *req.UnitId).Scan(&code)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Stock unit not found",
+			})
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE items SET unit_id = $1, unit = $2, updated_at = $3 WHERE items_id = $4", *req.UnitId, code, now, id)
+		if err != nil {
+			errors.LogError("Item unit update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update item units",
+			})
+		}
+		stockUnitId = req.UnitId
+	}
+
+	if req.Conversions != nil {
+		_, err = tx.Exec(ctx, "DELETE FROM item_units WHERE item_id = $1", id)
+		if err != nil {
+			errors.LogError("Item units deletion error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update item units",
+			})
+		}
+
+		for _, conversion := range req.Conversions {
+			if conversion.Factor <= 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Conversion factor must be greater than 0",
+				})
+			}
+
+			if stockUnitId != nil && conversion.UnitId == *stockUnitId {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "The stock unit cannot have a conversion factor",
+				})
+			}
+
+			_, err = tx.Exec(ctx, `
+				INSERT INTO item_units (item_id, unit_id, factor, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $4)
+			`, id, conversion.UnitId, conversion.Factor, now)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": fmt.Sprintf("Unit %s not found or listed twice", conversion.UnitId),
+				})
+			}
+		}
+	}
+
+	if req.PurchaseUnitId != nil {
+		var purchaseUnitId *string
+		if *req.PurchaseUnitId != "" {
+			purchaseUnitId = req.PurchaseUnitId
+			if _, message := convertToStockUnits(ctx, tx, id, *purchaseUnitId, 1); message != "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Purchase unit must be the stock unit or have a conversion factor",
+				})
+			}
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE items SET purchase_unit_id = $1, updated_at = $2 WHERE items_id = $3", purchaseUnitId, now, id)
+		if err != nil {
+			errors.LogError("Item purchase unit update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update item units",
+			})
+		}
+	} else {
+		// A purchase unit whose conversion was just removed can no longer be used.
+		_, err = tx.Exec(ctx, `
+			UPDATE items SET purchase_unit_id = NULL
+			WHERE items_id = $1 AND purchase_unit_id IS NOT NULL
+			AND purchase_unit_id IS DISTINCT FROM unit_id
+			AND NOT EXISTS (SELECT 1 FROM item_units WHERE item_id = $1 AND unit_id = items.purchase_unit_id)
+		`, id)
+		if err != nil {
+			errors.LogError("Item purchase unit update error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to update item units",
+			})
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	conversions, err := fetchItemUnits(ctx, id)
+	if err != nil {
+		errors.LogError("Get item units query error", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Item units updated successfully",
+		"data":    conversions,
+	})
+}
@@ -8,8 +8,11 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"fleetify/internal/database"
+	"fleetify/internal/loginguard"
 	"fleetify/internal/models"
+	"fleetify/internal/revocation"
 	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
 	"fleetify/pkg/password"
 	"fleetify/pkg/query"
 )
@@ -23,7 +26,10 @@ type UpdateUserRequest struct {
 }
 
 type ChangePasswordRequest struct {
-	NewPassword string `json:"new_password" validate:"required,min=6"`
+	NewPassword string `json:"new_password" validate:"required"`
+	// MustChangePassword defaults to true: a password set by an admin is
+	// known to them, so the user has to replace it.
+	MustChangePassword *bool `json:"must_change_password"`
 }
 
 func GetUsers(c *fiber.Ctx) error {
@@ -38,10 +44,19 @@ func GetUsers(c *fiber.Ctx) error {
 		"is_active": "is_active",
 	}
 
-	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	whereClause, whereArgs := query.BuildScopedWhereClause(params, searchFields, filterFields, "users", "")
 	orderClause := query.BuildOrderClause(params, "created_at")
 	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
 
+	baseQuery := `
+		SELECT users_id, username, role, full_name, email, phone, is_active, must_change_password, two_factor_enabled, created_at, updated_at
+		FROM users
+	`
+
+	if format := exportFormat(c); format != "" {
+		return streamExport(c, format, "users", baseQuery+" "+whereClause+" "+orderClause, whereArgs)
+	}
+
 	countQuery := query.BuildCountQuery("users", whereClause)
 
 	var totalCount int
@@ -54,11 +69,6 @@ func GetUsers(c *fiber.Ctx) error {
 		})
 	}
 
-	baseQuery := `
-		SELECT users_id, username, role, full_name, email, phone, is_active, created_at, updated_at
-		FROM users
-	`
-	
 	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
 	allArgs := append(whereArgs, paginationArgs...)
 
@@ -83,6 +93,8 @@ func GetUsers(c *fiber.Ctx) error {
 			&user.Email,
 			&user.Phone,
 			&user.IsActive,
+			&user.MustChangePassword,
+			&user.TwoFactorEnabled,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -125,14 +137,15 @@ func GetUserByUsername(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	whereClause, whereArgs := query.ScopeTable("WHERE username = $1", []interface{}{username}, query.ScopeFrom(c), "users", "")
+
 	var user models.Users
 	query := `
-		SELECT users_id, username, role, full_name, email, phone, is_active, created_at, updated_at
+		SELECT users_id, username, role, full_name, email, phone, is_active, must_change_password, two_factor_enabled, created_at, updated_at
 		FROM users
-		WHERE username = $1
-	`
+	` + whereClause
 
-	err := database.DB.QueryRow(ctx, query, username).Scan(
+	err := database.DB.QueryRow(ctx, query, whereArgs...).Scan(
 		&user.UsersId,
 		&user.Username,
 		&user.Role,
@@ -140,6 +153,8 @@ func GetUserByUsername(c *fiber.Ctx) error {
 		&user.Email,
 		&user.Phone,
 		&user.IsActive,
+		&user.MustChangePassword,
+		&user.TwoFactorEnabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -166,6 +181,14 @@ func UpdateUserByUsername(c *fiber.Ctx) error {
 		})
 	}
 
+	claims, ok := c.Locals("user").(*jwt.Claims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
 	var req UpdateUserRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -178,8 +201,8 @@ func UpdateUserByUsername(c *fiber.Ctx) error {
 	defer cancel()
 
 	var existingUser models.Users
-	checkQuery := `SELECT users_id FROM users WHERE username = $1`
-	err := database.DB.QueryRow(ctx, checkQuery, username).Scan(&existingUser.UsersId)
+	checkQuery := `SELECT users_id, role FROM users WHERE username = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, username).Scan(&existingUser.UsersId, &existingUser.Role)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
@@ -192,8 +215,29 @@ func UpdateUserByUsername(c *fiber.Ctx) error {
 	argPos := 1
 
 	if req.Role != "" {
+		if !roleExists(ctx, database.DB, strings.ToUpper(req.Role)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Role does not exist",
+			})
+		}
+		// The current role counts too, so an ADMIN cannot be demoted by
+		// someone who could not have made them one.
+		ok, err := roleAssignable(ctx, claims, strings.ToUpper(req.Role))
+		if ok && !strings.EqualFold(req.Role, existingUser.Role) {
+			ok, err = roleAssignable(ctx, claims, existingUser.Role)
+		}
+		if !ok {
+			if err != nil {
+				errors.LogError("Role permission check error", err)
+			}
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "You cannot assign a role with permissions you do not hold",
+			})
+		}
 		updateFields = append(updateFields, fmt.Sprintf("role = $%d", argPos))
-		args = append(args, req.Role)
+		args = append(args, strings.ToUpper(req.Role))
 		argPos++
 	}
 
@@ -262,6 +306,12 @@ func UpdateUserByUsername(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.IsActive != nil && !*req.IsActive {
+		if err := revocation.RevokeUser(ctx, user.UsersId, "deactivated"); err != nil {
+			errors.LogError("Deactivated user revoke error", err)
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "User updated successfully",
@@ -269,6 +319,8 @@ func UpdateUserByUsername(c *fiber.Ctx) error {
 	})
 }
 
+// ChangePassword sets a user's password on their behalf and logs out their
+// sessions.
 func ChangePassword(c *fiber.Ctx) error {
 	username := c.Params("uname")
 	if username == "" {
@@ -286,10 +338,10 @@ func ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.NewPassword == "" || len(req.NewPassword) < 6 {
+	if message := passwordPolicyMessage(req.NewPassword, username); message != "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
-			"message": "New password must be at least 6 characters",
+			"message": message,
 		})
 	}
 
@@ -306,6 +358,21 @@ func ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
+	message, err := passwordReuseMessage(ctx, existingUser.UsersId, req.NewPassword)
+	if err != nil {
+		errors.LogError("Password history lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update password",
+		})
+	}
+	if message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
 	hashedPassword, err := password.Hash(req.NewPassword)
 	if err != nil {
 		errors.LogError("Password hashing error", err)
@@ -315,13 +382,12 @@ func ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
-	updateQuery := `
-		UPDATE users
-		SET password = $1, updated_at = $2
-		WHERE username = $3
-	`
+	mustChange := true
+	if req.MustChangePassword != nil {
+		mustChange = *req.MustChangePassword
+	}
 
-	_, err = database.DB.Exec(ctx, updateQuery, hashedPassword, time.Now(), username)
+	err = setPassword(ctx, existingUser.UsersId, hashedPassword, mustChange)
 	if err != nil {
 		errors.LogError("Password update error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -330,12 +396,53 @@ func ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := revocation.RevokeUser(ctx, existingUser.UsersId, "password_set"); err != nil {
+		errors.LogError("Password change revoke error", err)
+	}
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "Password updated successfully",
 	})
 }
 
+// UnlockUser lifts a login lockout of the user before it runs out and
+// forgets their failed attempts.
+func UnlockUser(c *fiber.Ctx) error {
+	username := c.Params("uname")
+	if username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Username parameter is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingUser models.Users
+	err := database.DB.QueryRow(ctx, `SELECT users_id FROM users WHERE username = $1`, username).Scan(&existingUser.UsersId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if err := loginguard.Unlock(ctx, username); err != nil {
+		errors.LogError("User unlock error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to unlock user",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "User unlocked successfully",
+	})
+}
+
 func DeleteUser(c *fiber.Ctx) error {
 	username := c.Params("uname")
 	if username == "" {
@@ -368,6 +475,10 @@ func DeleteUser(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := revocation.RevokeUser(ctx, existingUser.UsersId, "deleted"); err != nil {
+		errors.LogError("Deleted user revoke error", err)
+	}
+
 	return c.JSON(fiber.Map{
 		"error":   false,
 		"message": "User deleted successfully",
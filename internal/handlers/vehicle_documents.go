@@ -0,0 +1,653 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var validDocumentTypes = map[string]bool{
+	"STNK":      true,
+	"KIR":       true,
+	"INSURANCE": true,
+}
+
+var allowedScanExtensions = map[string]bool{
+	".pdf":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+type CreateVehicleDocumentRequest struct {
+	VehicleId      string `json:"vehicle_id" validate:"required"`
+	DocumentType   string `json:"document_type" validate:"required"`
+	DocumentNumber string `json:"document_number" validate:"required"`
+	IssueDate      string `json:"issue_date" validate:"required"`
+	ExpiryDate     string `json:"expiry_date" validate:"required"`
+	Notes          string `json:"notes"`
+}
+
+type UpdateVehicleDocumentRequest struct {
+	DocumentType   *string `json:"document_type"`
+	DocumentNumber *string `json:"document_number"`
+	IssueDate      *string `json:"issue_date"`
+	ExpiryDate     *string `json:"expiry_date"`
+	Notes          *string `json:"notes"`
+}
+
+func GetVehicleDocuments(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"document_number", "document_type", "notes"}
+	filterFields := map[string]string{
+		"vehicle_id":    "vehicle_id",
+		"document_type": "document_type",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "created_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("vehicle_documents", whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get vehicle documents count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count vehicle documents",
+		})
+	}
+
+	baseQuery := `
+		SELECT vehicle_documents_id, vehicle_id, document_type, document_number, issue_date, expiry_date, scan_path, notes, created_at, updated_at
+		FROM vehicle_documents
+	`
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get vehicle documents query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch vehicle documents",
+		})
+	}
+	defer rows.Close()
+
+	var documents []models.VehicleDocuments
+	for rows.Next() {
+		var document models.VehicleDocuments
+		err := rows.Scan(
+			&document.VehicleDocumentsId,
+			&document.VehicleId,
+			&document.DocumentType,
+			&document.DocumentNumber,
+			&document.IssueDate,
+			&document.ExpiryDate,
+			&document.ScanPath,
+			&document.Notes,
+			&document.CreatedAt,
+			&document.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Vehicle document scan error", err)
+			continue
+		}
+		documents = append(documents, document)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process vehicle documents",
+		})
+	}
+
+	response := query.NewPaginatedResponse(documents, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetVehicleDocumentsByVehicleId(c *fiber.Ctx) error {
+	vehicleId := c.Params("id")
+	if vehicleId == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT vehicle_documents_id, vehicle_id, document_type, document_number, issue_date, expiry_date, scan_path, notes, created_at, updated_at
+		FROM vehicle_documents
+		WHERE vehicle_id = $1
+		ORDER BY expiry_date
+	`
+
+	rows, err := database.DB.Query(ctx, query, vehicleId)
+	if err != nil {
+		errors.LogError("Get vehicle documents query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch vehicle documents",
+		})
+	}
+	defer rows.Close()
+
+	var documents []models.VehicleDocuments
+	for rows.Next() {
+		var document models.VehicleDocuments
+		err := rows.Scan(
+			&document.VehicleDocumentsId,
+			&document.VehicleId,
+			&document.DocumentType,
+			&document.DocumentNumber,
+			&document.IssueDate,
+			&document.ExpiryDate,
+			&document.ScanPath,
+			&document.Notes,
+			&document.CreatedAt,
+			&document.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Vehicle document scan error", err)
+			continue
+		}
+		documents = append(documents, document)
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  documents,
+		"count": len(documents),
+	})
+}
+
+func GetVehicleDocumentById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var document models.VehicleDocuments
+	query := `
+		SELECT vehicle_documents_id, vehicle_id, document_type, document_number, issue_date, expiry_date, scan_path, notes, created_at, updated_at
+		FROM vehicle_documents
+		WHERE vehicle_documents_id = $1
+	`
+
+	err := database.DB.QueryRow(ctx, query, id).Scan(
+		&document.VehicleDocumentsId,
+		&document.VehicleId,
+		&document.DocumentType,
+		&document.DocumentNumber,
+		&document.IssueDate,
+		&document.ExpiryDate,
+		&document.ScanPath,
+		&document.Notes,
+		&document.CreatedAt,
+		&document.UpdatedAt,
+	)
+
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  document,
+	})
+}
+
+func CreateVehicleDocument(c *fiber.Ctx) error {
+	var req CreateVehicleDocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.VehicleId == "" || req.DocumentType == "" || req.DocumentNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle ID, document type and document number are required",
+		})
+	}
+
+	documentType := strings.ToUpper(req.DocumentType)
+	if !validDocumentTypes[documentType] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Document type must be one of STNK, KIR, INSURANCE",
+		})
+	}
+
+	issueDate, err := time.Parse("2006-01-02", req.IssueDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid issue_date format. Use YYYY-MM-DD",
+		})
+	}
+
+	expiryDate, err := time.Parse("2006-01-02", req.ExpiryDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid expiry_date format. Use YYYY-MM-DD",
+		})
+	}
+
+	if expiryDate.Before(issueDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Expiry date must be after issue date",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var vehicleExists string
+	err = database.DB.QueryRow(ctx, "SELECT vehicles_id FROM vehicles WHERE vehicles_id = $1", req.VehicleId).Scan(&vehicleExists)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle not found",
+		})
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO vehicle_documents (vehicle_id, document_type, document_number, issue_date, expiry_date, scan_path, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING vehicle_documents_id, vehicle_id, document_type, document_number, issue_date, expiry_date, scan_path, notes, created_at, updated_at
+	`
+
+	var document models.VehicleDocuments
+	err = database.DB.QueryRow(ctx, query,
+		req.VehicleId,
+		documentType,
+		req.DocumentNumber,
+		issueDate,
+		expiryDate,
+		"",
+		req.Notes,
+		now,
+		now,
+	).Scan(
+		&document.VehicleDocumentsId,
+		&document.VehicleId,
+		&document.DocumentType,
+		&document.DocumentNumber,
+		&document.IssueDate,
+		&document.ExpiryDate,
+		&document.ScanPath,
+		&document.Notes,
+		&document.CreatedAt,
+		&document.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Vehicle document creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create vehicle document",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Vehicle document created successfully",
+		"data":    document,
+	})
+}
+
+func UpdateVehicleDocument(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document ID is required",
+		})
+	}
+
+	var req UpdateVehicleDocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingDocument models.VehicleDocuments
+	checkQuery := `SELECT vehicle_documents_id, issue_date, expiry_date FROM vehicle_documents WHERE vehicle_documents_id = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(
+		&existingDocument.VehicleDocumentsId,
+		&existingDocument.IssueDate,
+		&existingDocument.ExpiryDate,
+	)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document not found",
+		})
+	}
+
+	issueDate := existingDocument.IssueDate
+	expiryDate := existingDocument.ExpiryDate
+
+	updateFields := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	if req.DocumentType != nil {
+		documentType := strings.ToUpper(*req.DocumentType)
+		if !validDocumentTypes[documentType] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Document type must be one of STNK, KIR, INSURANCE",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("document_type = $%d", argPos))
+		args = append(args, documentType)
+		argPos++
+	}
+
+	if req.DocumentNumber != nil {
+		updateFields = append(updateFields, fmt.Sprintf("document_number = $%d", argPos))
+		args = append(args, *req.DocumentNumber)
+		argPos++
+	}
+
+	if req.IssueDate != nil {
+		issueDate, err = time.Parse("2006-01-02", *req.IssueDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid issue_date format. Use YYYY-MM-DD",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("issue_date = $%d", argPos))
+		args = append(args, issueDate)
+		argPos++
+	}
+
+	if req.ExpiryDate != nil {
+		expiryDate, err = time.Parse("2006-01-02", *req.ExpiryDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid expiry_date format. Use YYYY-MM-DD",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("expiry_date = $%d", argPos))
+		args = append(args, expiryDate)
+		argPos++
+	}
+
+	if expiryDate.Before(issueDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Expiry date must be after issue date",
+		})
+	}
+
+	if req.Notes != nil {
+		updateFields = append(updateFields, fmt.Sprintf("notes = $%d", argPos))
+		args = append(args, *req.Notes)
+		argPos++
+	}
+
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	updateFields = append(updateFields, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE vehicle_documents
+		SET %s
+		WHERE vehicle_documents_id = $%d
+		RETURNING vehicle_documents_id, vehicle_id, document_type, document_number, issue_date, expiry_date, scan_path, notes, created_at, updated_at
+	`, strings.Join(updateFields, ", "), argPos)
+
+	var document models.VehicleDocuments
+	err = database.DB.QueryRow(ctx, query, args...).Scan(
+		&document.VehicleDocumentsId,
+		&document.VehicleId,
+		&document.DocumentType,
+		&document.DocumentNumber,
+		&document.IssueDate,
+		&document.ExpiryDate,
+		&document.ScanPath,
+		&document.Notes,
+		&document.CreatedAt,
+		&document.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Vehicle document update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update vehicle document",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Vehicle document updated successfully",
+		"data":    document,
+	})
+}
+
+func DeleteVehicleDocument(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingDocument models.VehicleDocuments
+	checkQuery := `SELECT vehicle_documents_id, scan_path FROM vehicle_documents WHERE vehicle_documents_id = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingDocument.VehicleDocumentsId, &existingDocument.ScanPath)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document not found",
+		})
+	}
+
+	deleteQuery := `DELETE FROM vehicle_documents WHERE vehicle_documents_id = $1`
+	_, err = database.DB.Exec(ctx, deleteQuery, id)
+	if err != nil {
+		errors.LogError("Vehicle document deletion error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete vehicle document",
+		})
+	}
+
+	if existingDocument.ScanPath != "" {
+		if err := os.Remove(existingDocument.ScanPath); err != nil && !os.IsNotExist(err) {
+			errors.LogError("Vehicle document scan removal error", err)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Vehicle document deleted successfully",
+	})
+}
+
+func UploadVehicleDocumentScan(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document ID is required",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Scan file is required",
+		})
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !allowedScanExtensions[ext] {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Scan must be a PDF, JPG or PNG file",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingDocument models.VehicleDocuments
+	checkQuery := `SELECT vehicle_documents_id, scan_path FROM vehicle_documents WHERE vehicle_documents_id = $1`
+	err = database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingDocument.VehicleDocumentsId, &existingDocument.ScanPath)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document not found",
+		})
+	}
+
+	scanDir := filepath.Join(config.AppConfig.Upload.Dir, "vehicle_documents")
+	if err := os.MkdirAll(scanDir, 0755); err != nil {
+		errors.LogError("Upload directory creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to store scan",
+		})
+	}
+
+	scanPath := filepath.Join(scanDir, existingDocument.VehicleDocumentsId+ext)
+	if err := c.SaveFile(file, scanPath); err != nil {
+		errors.LogError("Vehicle document scan save error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to store scan",
+		})
+	}
+
+	if existingDocument.ScanPath != "" && existingDocument.ScanPath != scanPath {
+		if err := os.Remove(existingDocument.ScanPath); err != nil && !os.IsNotExist(err) {
+			errors.LogError("Vehicle document scan removal error", err)
+		}
+	}
+
+	updateQuery := `
+		UPDATE vehicle_documents
+		SET scan_path = $1, updated_at = $2
+		WHERE vehicle_documents_id = $3
+	`
+	_, err = database.DB.Exec(ctx, updateQuery, scanPath, time.Now(), id)
+	if err != nil {
+		errors.LogError("Vehicle document scan update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update vehicle document",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Scan uploaded successfully",
+		"data": fiber.Map{
+			"vehicle_documents_id": id,
+			"scan_path":            scanPath,
+		},
+	})
+}
+
+func GetVehicleDocumentScan(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var scanPath string
+	err := database.DB.QueryRow(ctx, "SELECT scan_path FROM vehicle_documents WHERE vehicle_documents_id = $1", id).Scan(&scanPath)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle document not found",
+		})
+	}
+
+	if scanPath == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "No scan attached to this document",
+		})
+	}
+
+	return c.SendFile(scanPath)
+}
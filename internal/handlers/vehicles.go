@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateVehicleRequest struct {
+	PlateNumber string `json:"plate_number" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Brand       string `json:"brand"`
+	Model       string `json:"model"`
+	Year        int    `json:"year"`
+	VehicleType string `json:"vehicle_type"`
+	Odometer    int    `json:"odometer"`
+}
+
+type UpdateVehicleRequest struct {
+	PlateNumber *string `json:"plate_number"`
+	Name        *string `json:"name"`
+	Brand       *string `json:"brand"`
+	Model       *string `json:"model"`
+	Year        *int    `json:"year"`
+	VehicleType *string `json:"vehicle_type"`
+	Odometer    *int    `json:"odometer"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+func GetVehicles(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"plate_number", "name", "brand", "model"}
+	filterFields := map[string]string{
+		"vehicle_type": "vehicle_type",
+		"is_active":    "is_active",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "created_at")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("vehicles", whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get vehicles count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count vehicles",
+		})
+	}
+
+	baseQuery := `
+		SELECT vehicles_id, plate_number, name, brand, model, year, vehicle_type, odometer, is_active, created_at, updated_at
+		FROM vehicles
+	`
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get vehicles query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch vehicles",
+		})
+	}
+	defer rows.Close()
+
+	var vehicles []models.Vehicles
+	for rows.Next() {
+		var vehicle models.Vehicles
+		err := rows.Scan(
+			&vehicle.VehiclesId,
+			&vehicle.PlateNumber,
+			&vehicle.Name,
+			&vehicle.Brand,
+			&vehicle.Model,
+			&vehicle.Year,
+			&vehicle.VehicleType,
+			&vehicle.Odometer,
+			&vehicle.IsActive,
+			&vehicle.CreatedAt,
+			&vehicle.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Vehicle scan error", err)
+			continue
+		}
+		vehicles = append(vehicles, vehicle)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process vehicles",
+		})
+	}
+
+	response := query.NewPaginatedResponse(vehicles, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetVehicleById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var vehicle models.Vehicles
+	query := `
+		SELECT vehicles_id, plate_number, name, brand, model, year, vehicle_type, odometer, is_active, created_at, updated_at
+		FROM vehicles
+		WHERE vehicles_id = $1
+	`
+
+	err := database.DB.QueryRow(ctx, query, id).Scan(
+		&vehicle.VehiclesId,
+		&vehicle.PlateNumber,
+		&vehicle.Name,
+		&vehicle.Brand,
+		&vehicle.Model,
+		&vehicle.Year,
+		&vehicle.VehicleType,
+		&vehicle.Odometer,
+		&vehicle.IsActive,
+		&vehicle.CreatedAt,
+		&vehicle.UpdatedAt,
+	)
+
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  vehicle,
+	})
+}
+
+func CreateVehicle(c *fiber.Ctx) error {
+	var req CreateVehicleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.PlateNumber == "" || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Plate number and name are required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	plateNumber := strings.ToUpper(strings.TrimSpace(req.PlateNumber))
+
+	var existingVehicle models.Vehicles
+	checkQuery := `SELECT vehicles_id FROM vehicles WHERE plate_number = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, plateNumber).Scan(&existingVehicle.VehiclesId)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Plate number already exists",
+		})
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO vehicles (plate_number, name, brand, model, year, vehicle_type, odometer, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING vehicles_id, plate_number, name, brand, model, year, vehicle_type, odometer, is_active, created_at, updated_at
+	`
+
+	var vehicle models.Vehicles
+	err = database.DB.QueryRow(ctx, query,
+		plateNumber,
+		req.Name,
+		req.Brand,
+		req.Model,
+		req.Year,
+		req.VehicleType,
+		req.Odometer,
+		true,
+		now,
+		now,
+	).Scan(
+		&vehicle.VehiclesId,
+		&vehicle.PlateNumber,
+		&vehicle.Name,
+		&vehicle.Brand,
+		&vehicle.Model,
+		&vehicle.Year,
+		&vehicle.VehicleType,
+		&vehicle.Odometer,
+		&vehicle.IsActive,
+		&vehicle.CreatedAt,
+		&vehicle.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Vehicle creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create vehicle",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Vehicle created successfully",
+		"data":    vehicle,
+	})
+}
+
+func UpdateVehicle(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle ID is required",
+		})
+	}
+
+	var req UpdateVehicleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingVehicle models.Vehicles
+	checkQuery := `SELECT vehicles_id, odometer FROM vehicles WHERE vehicles_id = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingVehicle.VehiclesId, &existingVehicle.Odometer)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle not found",
+		})
+	}
+
+	updateFields := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	if req.PlateNumber != nil {
+		updateFields = append(updateFields, fmt.Sprintf("plate_number = $%d", argPos))
+		args = append(args, strings.ToUpper(strings.TrimSpace(*req.PlateNumber)))
+		argPos++
+	}
+
+	if req.Name != nil {
+		updateFields = append(updateFields, fmt.Sprintf("name = $%d", argPos))
+		args = append(args, *req.Name)
+		argPos++
+	}
+
+	if req.Brand != nil {
+		updateFields = append(updateFields, fmt.Sprintf("brand = $%d", argPos))
+		args = append(args, *req.Brand)
+		argPos++
+	}
+
+	if req.Model != nil {
+		updateFields = append(updateFields, fmt.Sprintf("model = $%d", argPos))
+		args = append(args, *req.Model)
+		argPos++
+	}
+
+	if req.Year != nil {
+		updateFields = append(updateFields, fmt.Sprintf("year = $%d", argPos))
+		args = append(args, *req.Year)
+		argPos++
+	}
+
+	if req.VehicleType != nil {
+		updateFields = append(updateFields, fmt.Sprintf("vehicle_type = $%d", argPos))
+		args = append(args, *req.VehicleType)
+		argPos++
+	}
+
+	if req.Odometer != nil {
+		if *req.Odometer < existingVehicle.Odometer {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Odometer cannot be lower than the current reading",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("odometer = $%d", argPos))
+		args = append(args, *req.Odometer)
+		argPos++
+	}
+
+	if req.IsActive != nil {
+		updateFields = append(updateFields, fmt.Sprintf("is_active = $%d", argPos))
+		args = append(args, *req.IsActive)
+		argPos++
+	}
+
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	updateFields = append(updateFields, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE vehicles
+		SET %s
+		WHERE vehicles_id = $%d
+		RETURNING vehicles_id, plate_number, name, brand, model, year, vehicle_type, odometer, is_active, created_at, updated_at
+	`, strings.Join(updateFields, ", "), argPos)
+
+	var vehicle models.Vehicles
+	err = database.DB.QueryRow(ctx, query, args...).Scan(
+		&vehicle.VehiclesId,
+		&vehicle.PlateNumber,
+		&vehicle.Name,
+		&vehicle.Brand,
+		&vehicle.Model,
+		&vehicle.Year,
+		&vehicle.VehicleType,
+		&vehicle.Odometer,
+		&vehicle.IsActive,
+		&vehicle.CreatedAt,
+		&vehicle.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Vehicle update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update vehicle",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Vehicle updated successfully",
+		"data":    vehicle,
+	})
+}
+
+func DeleteVehicle(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var existingVehicle models.Vehicles
+	checkQuery := `SELECT vehicles_id FROM vehicles WHERE vehicles_id = $1`
+	err := database.DB.QueryRow(ctx, checkQuery, id).Scan(&existingVehicle.VehiclesId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Vehicle not found",
+		})
+	}
+
+	deleteQuery := `DELETE FROM vehicles WHERE vehicles_id = $1`
+	_, err = database.DB.Exec(ctx, deleteQuery, id)
+	if err != nil {
+		errors.LogError("Vehicle deletion error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete vehicle",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Vehicle deleted successfully",
+	})
+}
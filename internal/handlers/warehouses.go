@@ -0,0 +1,758 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/models"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+type CreateWarehouseRequest struct {
+	Code      string `json:"code" validate:"required"`
+	Name      string `json:"name" validate:"required"`
+	Address   string `json:"address"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type UpdateWarehouseRequest struct {
+	Code      *string `json:"code"`
+	Name      *string `json:"name"`
+	Address   *string `json:"address"`
+	IsDefault *bool   `json:"is_default"`
+	IsActive  *bool   `json:"is_active"`
+}
+
+type ItemLocationStock struct {
+	WarehouseId   string `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	WarehouseName string `json:"warehouse_name"`
+	Qty           int    `json:"qty"`
+}
+
+type WarehouseStockResponse struct {
+	ItemId   string `json:"item_id"`
+	ItemName string `json:"item_name"`
+	Unit     string `json:"unit"`
+	Qty      int    `json:"qty"`
+	MinStock int    `json:"min_stock"`
+}
+
+// itemLocationsQuery lists per-warehouse balances for a set of items. Items
+// whose stock predates warehouses have no balance rows yet and are reported
+// as held entirely in the default warehouse.
+const itemLocationsQuery = `
+	SELECT s.item_id, s.warehouse_id, w.code, w.name, s.qty
+	FROM item_stocks s
+	JOIN warehouses w ON s.warehouse_id = w.warehouses_id
+	WHERE s.item_id = ANY($1) AND s.qty <> 0
+	UNION ALL
+	SELECT i.items_id, w.warehouses_id, w.code, w.name, i.stock
+	FROM items i
+	JOIN warehouses w ON w.is_default
+	WHERE i.items_id = ANY($1) AND i.stock <> 0
+	AND NOT EXISTS (SELECT 1 FROM item_stocks WHERE item_id = i.items_id)
+	ORDER BY 3
+`
+
+// defaultWarehouseId returns the warehouse used when a document names none.
+func defaultWarehouseId(ctx context.Context, tx pgx.Tx) (string, error) {
+	var id string
+	err := tx.QueryRow(ctx, "SELECT warehouses_id FROM warehouses WHERE is_default").Scan(&id)
+	return id, err
+}
+
+// resolveWarehouse validates the requested warehouse, falling back to the
+// default warehouse when none is given.
+func resolveWarehouse(ctx context.Context, tx pgx.Tx, warehouseId string) (string, string) {
+	if warehouseId == "" {
+		id, err := defaultWarehouseId(ctx, tx)
+		if err != nil {
+			return "", "No default warehouse is configured"
+		}
+		return id, ""
+	}
+
+	var isActive bool
+	err := tx.QueryRow(ctx, "SELECT is_active FROM warehouses WHERE warehouses_id = $1", warehouseId).Scan(&isActive)
+	if err != nil {
+		return "", "Warehouse not found"
+	}
+	if !isActive {
+		return "", "Warehouse is inactive"
+	}
+	return warehouseId, ""
+}
+
+// allocateLegacyStock moves stock recorded only on items.stock into the
+// default warehouse the first time the item's balances are touched.
+func allocateLegacyStock(ctx context.Context, tx pgx.Tx, itemId string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO item_stocks (item_id, warehouse_id, qty, created_at, updated_at)
+		SELECT i.items_id, w.warehouses_id, i.stock, NOW(), NOW()
+		FROM items i
+		JOIN warehouses w ON w.is_default
+		WHERE i.items_id = $1 AND i.stock <> 0
+		AND NOT EXISTS (SELECT 1 FROM item_stocks WHERE item_id = i.items_id)
+	`, itemId)
+	return err
+}
+
+// warehouseStock locks and returns the item's balance at the warehouse.
+func warehouseStock(ctx context.Context, tx pgx.Tx, itemId, warehouseId string) (int, error) {
+	if err := allocateLegacyStock(ctx, tx, itemId); err != nil {
+		return 0, err
+	}
+
+	var qty int
+	err := tx.QueryRow(ctx, "SELECT qty FROM item_stocks WHERE item_id = $1 AND warehouse_id = $2 FOR UPDATE", itemId, warehouseId).Scan(&qty)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	return qty, err
+}
+
+// errWarehouseFrozen is returned by changeWarehouseStock while a stock count
+// that freezes movements is open at the warehouse.
+var errWarehouseFrozen = fmt.Errorf("warehouse is frozen for a stock count")
+
+// changeWarehouseStock adds delta to the item's balance at the warehouse.
+func changeWarehouseStock(ctx context.Context, tx pgx.Tx, itemId, warehouseId string, delta int) error {
+	var frozen bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM stock_counts
+			WHERE warehouse_id = $1 AND freeze_movements AND status IN ('counting', 'submitted')
+		)
+	`, warehouseId).Scan(&frozen)
+	if err != nil {
+		return err
+	}
+	if frozen {
+		return errWarehouseFrozen
+	}
+
+	if err := allocateLegacyStock(ctx, tx, itemId); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO item_stocks (item_id, warehouse_id, qty, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (item_id, warehouse_id)
+		DO UPDATE SET qty = item_stocks.qty + EXCLUDED.qty, updated_at = EXCLUDED.updated_at
+	`, itemId, warehouseId, delta, time.Now())
+	return err
+}
+
+// warehouseStockError reports a failed changeWarehouseStock call.
+func warehouseStockError(c *fiber.Ctx, err error) error {
+	status, message := warehouseStockStatus(err)
+	return c.Status(status).JSON(fiber.Map{
+		"error":   true,
+		"message": message,
+	})
+}
+
+// warehouseStockStatus is the response status and message for a failed
+// changeWarehouseStock call.
+func warehouseStockStatus(err error) (int, string) {
+	if err == errWarehouseFrozen {
+		return fiber.StatusConflict, "Warehouse stock is frozen while a stock count is in progress"
+	}
+
+	errors.LogError("Warehouse stock update error", err)
+	return fiber.StatusInternalServerError, "Failed to update warehouse stock"
+}
+
+// loadItemLocations returns per-warehouse balances and in-transit quantities
+// keyed by item ID.
+func loadItemLocations(ctx context.Context, itemIds []string) (map[string][]ItemLocationStock, map[string]int, error) {
+	locations := map[string][]ItemLocationStock{}
+	inTransit := map[string]int{}
+	if len(itemIds) == 0 {
+		return locations, inTransit, nil
+	}
+
+	rows, err := database.DB.Query(ctx, itemLocationsQuery, itemIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemId string
+		var location ItemLocationStock
+		err := rows.Scan(
+			&itemId,
+			&location.WarehouseId,
+			&location.WarehouseCode,
+			&location.WarehouseName,
+			&location.Qty,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		locations[itemId] = append(locations[itemId], location)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	transitRows, err := database.DB.Query(ctx, `
+		SELECT d.item_id, SUM(d.qty)
+		FROM stock_transfer_details d
+		JOIN stock_transfers t ON d.stock_transfer_id = t.stock_transfers_id
+		WHERE t.status = 'in_transit' AND d.item_id = ANY($1)
+		GROUP BY d.item_id
+	`, itemIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer transitRows.Close()
+
+	for transitRows.Next() {
+		var itemId string
+		var qty int
+		if err := transitRows.Scan(&itemId, &qty); err != nil {
+			return nil, nil, err
+		}
+		inTransit[itemId] = qty
+	}
+
+	return locations, inTransit, transitRows.Err()
+}
+
+func GetWarehouses(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	params := query.ParseQueryParams(c)
+
+	searchFields := []string{"code", "name", "address"}
+	filterFields := map[string]string{
+		"is_active":  "is_active",
+		"is_default": "is_default",
+	}
+
+	whereClause, whereArgs := query.BuildWhereClause(params, searchFields, filterFields)
+	orderClause := query.BuildOrderClause(params, "code")
+	paginationClause, paginationArgs := query.BuildPaginationClause(params, len(whereArgs)+1)
+
+	countQuery := query.BuildCountQuery("warehouses", whereClause)
+
+	var totalCount int
+	err := database.DB.QueryRow(ctx, countQuery, whereArgs...).Scan(&totalCount)
+	if err != nil {
+		errors.LogError("Get warehouses count error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to count warehouses",
+		})
+	}
+
+	baseQuery := `
+		SELECT warehouses_id, code, name, address, is_default, is_active, created_at, updated_at
+		FROM warehouses
+	`
+
+	fullQuery := baseQuery + " " + whereClause + " " + orderClause + " " + paginationClause
+	allArgs := append(whereArgs, paginationArgs...)
+
+	rows, err := database.DB.Query(ctx, fullQuery, allArgs...)
+	if err != nil {
+		errors.LogError("Get warehouses query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch warehouses",
+		})
+	}
+	defer rows.Close()
+
+	var warehouses []models.Warehouses
+	for rows.Next() {
+		var warehouse models.Warehouses
+		err := rows.Scan(
+			&warehouse.WarehousesId,
+			&warehouse.Code,
+			&warehouse.Name,
+			&warehouse.Address,
+			&warehouse.IsDefault,
+			&warehouse.IsActive,
+			&warehouse.CreatedAt,
+			&warehouse.UpdatedAt,
+		)
+		if err != nil {
+			errors.LogError("Warehouse scan error", err)
+			continue
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process warehouses",
+		})
+	}
+
+	response := query.NewPaginatedResponse(warehouses, totalCount, params.Page, params.Limit)
+	return c.JSON(fiber.Map{
+		"error":       false,
+		"data":        response.Data,
+		"count":       response.Count,
+		"page":        response.Page,
+		"limit":       response.Limit,
+		"total_pages": response.TotalPages,
+		"total_count": response.TotalCount,
+	})
+}
+
+func GetWarehouseById(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var warehouse models.Warehouses
+	query := `
+		SELECT warehouses_id, code, name, address, is_default, is_active, created_at, updated_at
+		FROM warehouses
+		WHERE warehouses_id = $1
+	`
+
+	err := database.DB.QueryRow(ctx, query, id).Scan(
+		&warehouse.WarehousesId,
+		&warehouse.Code,
+		&warehouse.Name,
+		&warehouse.Address,
+		&warehouse.IsDefault,
+		&warehouse.IsActive,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	)
+
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  warehouse,
+	})
+}
+
+func GetWarehouseStock(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var isDefault bool
+	err := database.DB.QueryRow(ctx, "SELECT is_default FROM warehouses WHERE warehouses_id = $1", id).Scan(&isDefault)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse not found",
+		})
+	}
+
+	query := `
+		SELECT i.items_id, i.name, i.unit, s.qty, i.min_stock
+		FROM item_stocks s
+		JOIN items i ON s.item_id = i.items_id
+		WHERE s.warehouse_id = $1 AND s.qty <> 0
+	`
+	if isDefault {
+		query += `
+		UNION ALL
+		SELECT i.items_id, i.name, i.unit, i.stock, i.min_stock
+		FROM items i
+		WHERE i.stock <> 0 AND NOT EXISTS (SELECT 1 FROM item_stocks WHERE item_id = i.items_id)
+		`
+	}
+	query += " ORDER BY 2"
+
+	rows, err := database.DB.Query(ctx, query, id)
+	if err != nil {
+		errors.LogError("Get warehouse stock query error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch warehouse stock",
+		})
+	}
+	defer rows.Close()
+
+	stock := []WarehouseStockResponse{}
+	for rows.Next() {
+		var s WarehouseStockResponse
+		err := rows.Scan(
+			&s.ItemId,
+			&s.ItemName,
+			&s.Unit,
+			&s.Qty,
+			&s.MinStock,
+		)
+		if err != nil {
+			errors.LogError("Warehouse stock scan error", err)
+			continue
+		}
+		stock = append(stock, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		errors.LogError("Rows iteration error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to process warehouse stock",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error": false,
+		"data":  stock,
+		"count": len(stock),
+	})
+}
+
+func CreateWarehouse(c *fiber.Ctx) error {
+	var req CreateWarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	if req.Code == "" || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse code and name are required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var existing string
+	err = tx.QueryRow(ctx, "SELECT warehouses_id FROM warehouses WHERE code = $1", req.Code).Scan(&existing)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse code already exists",
+		})
+	}
+
+	now := time.Now()
+	if req.IsDefault {
+		if _, err = tx.Exec(ctx, "UPDATE warehouses SET is_default = false, updated_at = $1 WHERE is_default", now); err != nil {
+			errors.LogError("Default warehouse reset error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to create warehouse",
+			})
+		}
+	}
+
+	query := `
+		INSERT INTO warehouses (code, name, address, is_default, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, true, $5, $5)
+		RETURNING warehouses_id, code, name, address, is_default, is_active, created_at, updated_at
+	`
+
+	var warehouse models.Warehouses
+	err = tx.QueryRow(ctx, query,
+		req.Code,
+		req.Name,
+		req.Address,
+		req.IsDefault,
+		now,
+	).Scan(
+		&warehouse.WarehousesId,
+		&warehouse.Code,
+		&warehouse.Name,
+		&warehouse.Address,
+		&warehouse.IsDefault,
+		&warehouse.IsActive,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Warehouse creation error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create warehouse",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"error":   false,
+		"message": "Warehouse created successfully",
+		"data":    warehouse,
+	})
+}
+
+func UpdateWarehouse(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse ID is required",
+		})
+	}
+
+	var req UpdateWarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		errors.LogError("Transaction begin error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to start transaction",
+		})
+	}
+	defer tx.Rollback(ctx)
+
+	var existing models.Warehouses
+	checkQuery := `SELECT warehouses_id, is_default FROM warehouses WHERE warehouses_id = $1 FOR UPDATE`
+	err = tx.QueryRow(ctx, checkQuery, id).Scan(&existing.WarehousesId, &existing.IsDefault)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse not found",
+		})
+	}
+
+	updateFields := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	if req.Code != nil {
+		code := strings.ToUpper(strings.TrimSpace(*req.Code))
+		var duplicate string
+		err = tx.QueryRow(ctx, "SELECT warehouses_id FROM warehouses WHERE code = $1 AND warehouses_id <> $2", code, id).Scan(&duplicate)
+		if err == nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Warehouse code already exists",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("code = $%d", argPos))
+		args = append(args, code)
+		argPos++
+	}
+
+	if req.Name != nil {
+		updateFields = append(updateFields, fmt.Sprintf("name = $%d", argPos))
+		args = append(args, *req.Name)
+		argPos++
+	}
+
+	if req.Address != nil {
+		updateFields = append(updateFields, fmt.Sprintf("address = $%d", argPos))
+		args = append(args, *req.Address)
+		argPos++
+	}
+
+	if req.IsDefault != nil {
+		if !*req.IsDefault && existing.IsDefault {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Mark another warehouse as default instead",
+			})
+		}
+		if *req.IsDefault && !existing.IsDefault {
+			if _, err = tx.Exec(ctx, "UPDATE warehouses SET is_default = false, updated_at = $1 WHERE is_default", time.Now()); err != nil {
+				errors.LogError("Default warehouse reset error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to update warehouse",
+				})
+			}
+		}
+		updateFields = append(updateFields, fmt.Sprintf("is_default = $%d", argPos))
+		args = append(args, *req.IsDefault)
+		argPos++
+	}
+
+	if req.IsActive != nil {
+		if !*req.IsActive && (existing.IsDefault || (req.IsDefault != nil && *req.IsDefault)) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "The default warehouse cannot be deactivated",
+			})
+		}
+		updateFields = append(updateFields, fmt.Sprintf("is_active = $%d", argPos))
+		args = append(args, *req.IsActive)
+		argPos++
+	}
+
+	if len(updateFields) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	updateFields = append(updateFields, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE warehouses
+		SET %s
+		WHERE warehouses_id = $%d
+		RETURNING warehouses_id, code, name, address, is_default, is_active, created_at, updated_at
+	`, strings.Join(updateFields, ", "), argPos)
+
+	var warehouse models.Warehouses
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&warehouse.WarehousesId,
+		&warehouse.Code,
+		&warehouse.Name,
+		&warehouse.Address,
+		&warehouse.IsDefault,
+		&warehouse.IsActive,
+		&warehouse.CreatedAt,
+		&warehouse.UpdatedAt,
+	)
+
+	if err != nil {
+		errors.LogError("Warehouse update error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update warehouse",
+		})
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		errors.LogError("Transaction commit error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Warehouse updated successfully",
+		"data":    warehouse,
+	})
+}
+
+func DeleteWarehouse(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse ID is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var isDefault bool
+	err := database.DB.QueryRow(ctx, "SELECT is_default FROM warehouses WHERE warehouses_id = $1", id).Scan(&isDefault)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse not found",
+		})
+	}
+
+	if isDefault {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "The default warehouse cannot be deleted",
+		})
+	}
+
+	var held int
+	err = database.DB.QueryRow(ctx, "SELECT COALESCE(SUM(ABS(qty)), 0) FROM item_stocks WHERE warehouse_id = $1", id).Scan(&held)
+	if err != nil {
+		errors.LogError("Warehouse stock check error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete warehouse",
+		})
+	}
+
+	if held > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse still holds stock; transfer it out or deactivate the warehouse",
+		})
+	}
+
+	_, err = database.DB.Exec(ctx, "DELETE FROM item_stocks WHERE warehouse_id = $1", id)
+	if err == nil {
+		_, err = database.DB.Exec(ctx, "DELETE FROM warehouses WHERE warehouses_id = $1", id)
+	}
+	if err != nil {
+		errors.LogError("Warehouse deletion error", err)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Warehouse is referenced by stock documents; deactivate it instead",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"error":   false,
+		"message": "Warehouse deleted successfully",
+	})
+}
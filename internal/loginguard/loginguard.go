@@ -0,0 +1,151 @@
+// Package loginguard slows down password guessing. Failed logins are counted
+// per username and per client IP in ratelimit.DefaultStore: every failure
+// makes the next attempt for that username wait longer, and too many
+// failures lock the username or the IP out for a while. Users with the
+// users:manage permission can lift a username's lockout early.
+package loginguard
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/pkg/ratelimit"
+)
+
+// Block says why a login may not be attempted yet.
+type Block struct {
+	// Locked is set when the username or IP is locked out, as opposed to
+	// waiting out the delay after a failure.
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+type settings struct {
+	maxAttempts   int
+	ipMaxAttempts int
+	window        time.Duration
+	lockout       time.Duration
+	delayBase     time.Duration
+	delayMax      time.Duration
+}
+
+func current() settings {
+	cfg := config.AppConfig.Login
+	return settings{
+		maxAttempts:   cfg.MaxAttempts,
+		ipMaxAttempts: cfg.IPMaxAttempts,
+		window:        duration(cfg.AttemptWindow, 15*time.Minute),
+		lockout:       duration(cfg.LockoutDuration, 15*time.Minute),
+		delayBase:     duration(cfg.DelayBase, time.Second),
+		delayMax:      duration(cfg.DelayMax, 30*time.Second),
+	}
+}
+
+func duration(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return fallback
+	}
+	return d
+}
+
+func failKey(kind, id string) string  { return "login:fail:" + kind + ":" + id }
+func lockKey(kind, id string) string  { return "login:lock:" + kind + ":" + id }
+func delayKey(username string) string { return "login:delay:user:" + username }
+
+// Check returns the Block standing in the way of a login for username from
+// ip, or nil when it may be attempted now.
+func Check(ctx context.Context, username, ip string) (*Block, error) {
+	store := ratelimit.DefaultStore
+	now := time.Now()
+
+	for _, key := range []string{lockKey("user", username), lockKey("ip", ip)} {
+		count, resetAt, err := store.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return &Block{Locked: true, RetryAfter: resetAt.Sub(now)}, nil
+		}
+	}
+
+	count, resetAt, err := store.Get(ctx, delayKey(username))
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return &Block{RetryAfter: resetAt.Sub(now)}, nil
+	}
+	return nil, nil
+}
+
+// Fail records a failed login for username from ip, delaying the next
+// attempt or locking the username or IP out.
+func Fail(ctx context.Context, username, ip string) error {
+	store := ratelimit.DefaultStore
+	s := current()
+
+	failures, _, err := store.Incr(ctx, failKey("user", username), s.window)
+	if err != nil {
+		return err
+	}
+
+	if s.maxAttempts > 0 && failures >= s.maxAttempts {
+		log.Printf("WARNING: Login for %q locked for %s after %d failed attempts", username, s.lockout, failures)
+		if err := restart(ctx, lockKey("user", username), s.lockout); err != nil {
+			return err
+		}
+		if err := store.Reset(ctx, failKey("user", username)); err != nil {
+			return err
+		}
+	} else if s.delayBase > 0 {
+		delay := s.delayBase << (failures - 1)
+		if delay > s.delayMax || delay <= 0 {
+			delay = s.delayMax
+		}
+		if err := restart(ctx, delayKey(username), delay); err != nil {
+			return err
+		}
+	}
+
+	ipFailures, _, err := store.Incr(ctx, failKey("ip", ip), s.window)
+	if err != nil {
+		return err
+	}
+	if s.ipMaxAttempts > 0 && ipFailures >= s.ipMaxAttempts {
+		log.Printf("WARNING: Logins from %s locked for %s after %d failed attempts", ip, s.lockout, ipFailures)
+		if err := restart(ctx, lockKey("ip", ip), s.lockout); err != nil {
+			return err
+		}
+		return store.Reset(ctx, failKey("ip", ip))
+	}
+	return nil
+}
+
+// Succeed forgets the failed attempts of username after a good login.
+func Succeed(ctx context.Context, username string) error {
+	store := ratelimit.DefaultStore
+	if err := store.Reset(ctx, failKey("user", username)); err != nil {
+		return err
+	}
+	return store.Reset(ctx, delayKey(username))
+}
+
+// Unlock lifts a lockout of username and forgets its failed attempts.
+func Unlock(ctx context.Context, username string) error {
+	if err := Succeed(ctx, username); err != nil {
+		return err
+	}
+	return ratelimit.DefaultStore.Reset(ctx, lockKey("user", username))
+}
+
+// restart starts a new window for key that lasts d.
+func restart(ctx context.Context, key string, d time.Duration) error {
+	if err := ratelimit.DefaultStore.Reset(ctx, key); err != nil {
+		return err
+	}
+	_, _, err := ratelimit.DefaultStore.Incr(ctx, key, d)
+	return err
+}
@@ -1,13 +1,42 @@
 package middleware
 
 import (
+	"context"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"fleetify/internal/apikeys"
+	"fleetify/internal/revocation"
+	"fleetify/internal/sessions"
+	"fleetify/pkg/errors"
 	"fleetify/pkg/jwt"
 )
 
+// Auth accepts a Bearer access token or an API key.
 func Auth() fiber.Handler {
+	return authenticate(false, false)
+}
+
+// AuthAllowingPasswordChange is Auth for the few routes a user who has to
+// change their password may still use.
+func AuthAllowingPasswordChange() fiber.Handler {
+	return authenticate(true, false)
+}
+
+// AuthAllowingTwoFactorSetup is Auth for the routes a user whose role
+// requires two-factor authentication uses to enroll.
+func AuthAllowingTwoFactorSetup() fiber.Handler {
+	return authenticate(false, true)
+}
+
+// AuthAllowingRestricted is Auth for routes such as logout that any signed-in
+// user may use, whatever their token is limited to.
+func AuthAllowingRestricted() fiber.Handler {
+	return authenticate(true, true)
+}
+
+func authenticate(allowPasswordChange, allowTwoFactorSetup bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -26,6 +55,18 @@ func Auth() fiber.Handler {
 		}
 
 		tokenString := parts[1]
+		if apikeys.IsKey(tokenString) {
+			// The restricted variants guard the user's own account, which
+			// an integration has no business changing.
+			if allowPasswordChange || allowTwoFactorSetup {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error":   true,
+					"message": "API keys cannot be used for this route",
+				})
+			}
+			return authenticateAPIKey(c, tokenString)
+		}
+
 		claims, err := jwt.ValidateToken(tokenString)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -34,8 +75,81 @@ func Auth() fiber.Handler {
 			})
 		}
 
+		if revocation.IsRevoked(claims) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   true,
+				"message": "Token has been revoked",
+			})
+		}
+
+		if claims.MustChangePassword && !allowPasswordChange {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Password change required",
+			})
+		}
+
+		if claims.TwoFactorSetupRequired && !allowTwoFactorSetup {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Two-factor authentication setup required",
+			})
+		}
+
+		if claims.SessionID != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := sessions.Touch(ctx, claims.SessionID, c.IP())
+			cancel()
+			if err != nil {
+				errors.LogError("Session touch error", err)
+			}
+		}
+
 		c.Locals("user", claims)
 		return c.Next()
 	}
 }
 
+
+func authenticateAPIKey(c *fiber.Ctx, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	claims, err := apikeys.Authenticate(ctx, key, c.IP())
+	switch {
+	case err == apikeys.ErrInvalid, err == apikeys.ErrExpired:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired API key",
+		})
+	case err == apikeys.ErrIPNotAllowed:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "API key is not allowed from this IP address",
+		})
+	case err != nil:
+		errors.LogError("API key lookup error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to check API key",
+		})
+	}
+
+	// The owner's keys stop working until they have dealt with it
+	// themselves.
+	if claims.MustChangePassword {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Password change required",
+		})
+	}
+	if claims.TwoFactorSetupRequired {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Two-factor authentication setup required",
+		})
+	}
+
+	c.Locals("user", claims)
+	return c.Next()
+}
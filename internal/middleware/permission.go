@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"fleetify/internal/permissions"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission lets the request through when the caller's role has been
+// granted permission, e.g. RequirePermission(permissions.ItemsWrite). Calls
+// made with an API key also need the key to be scoped to it.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("user").(*jwt.Claims)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   true,
+				"message": "Unauthorized",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		allowed, err := permissions.Granted(ctx, claims, permission)
+		if err != nil {
+			errors.LogError("Permission lookup error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to check permissions",
+			})
+		}
+
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Access denied. Missing permission " + permission,
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimit limits how often one client IP may call a route. The limit is
+// the rule called name in config RateLimit.Rules; without a rule the route is
+// not limited. Counts are kept in ratelimit.DefaultStore. The rules are
+// checked by config.Validate at startup.
+func RateLimit(name string) fiber.Handler {
+	rules, _ := ratelimit.ParseRules(config.AppConfig.RateLimit.Rules)
+	rule, ok := rules[name]
+	if !ok {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		count, resetAt, err := ratelimit.DefaultStore.Incr(ctx, "rate:"+name+":"+c.IP(), rule.Window)
+		if err != nil {
+			// A broken shared store should not take the API down with it.
+			errors.LogError("Rate limit store error", err)
+			return c.Next()
+		}
+
+		remaining := rule.Max - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Set("X-RateLimit-Limit", strconv.Itoa(rule.Max))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+
+		if count > rule.Max {
+			c.Set(fiber.HeaderRetryAfter, ratelimit.RetryAfter(time.Until(resetAt)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   true,
+				"message": "Too many requests, try again later",
+			})
+		}
+
+		return c.Next()
+	}
+}
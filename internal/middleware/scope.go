@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/internal/permissions"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+	"fleetify/pkg/query"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DepartmentScope resolves which departments the caller may see and stores
+// it for query.ParseQueryParams. Roles holding departments:all see every row;
+// everyone else is limited to the departments they are assigned to.
+func DepartmentScope() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals("user").(*jwt.Claims)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   true,
+				"message": "Unauthorized",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		global, err := permissions.Granted(ctx, claims, permissions.DepartmentsAll)
+		if err != nil {
+			errors.LogError("Permission lookup error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to check permissions",
+			})
+		}
+
+		scope := query.Scope{Global: global}
+		if !global {
+			scope.Departments, err = userDepartments(ctx, claims.UserID)
+			if err != nil {
+				errors.LogError("User departments lookup error", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   true,
+					"message": "Failed to resolve departments",
+				})
+			}
+		}
+
+		c.Locals("scope", scope)
+		return c.Next()
+	}
+}
+
+func userDepartments(ctx context.Context, userId string) ([]string, error) {
+	rows, err := database.DB.Query(ctx, "SELECT department_id FROM user_departments WHERE user_id = $1", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	departments := []string{}
+	for rows.Next() {
+		var departmentId string
+		if err := rows.Scan(&departmentId); err != nil {
+			return nil, err
+		}
+		departments = append(departments, departmentId)
+	}
+	return departments, rows.Err()
+}
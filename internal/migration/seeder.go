@@ -9,6 +9,7 @@ import (
 
 	"fleetify/internal/database"
 	"fleetify/pkg/errors"
+	"fleetify/pkg/password"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -64,7 +65,9 @@ func (s *Seeder) RunSeeder(tableName string) error {
 			recordMap["updated_timestamp"] = time.Now()
 		}
 
-		s.hashPasswordFields(recordMap)
+		if err := s.hashPasswordFields(recordMap); err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
 
 		columns := []string{}
 		values := []interface{}{}
@@ -152,10 +155,16 @@ func (s *Seeder) structToMap(v interface{}) map[string]interface{} {
 	return result
 }
 
-func (s *Seeder) hashPasswordFields(recordMap map[string]interface{}) {
+// hashPasswordFields hashes plain-text password columns, refusing seed
+// passwords that the password policy would not accept from a user.
+func (s *Seeder) hashPasswordFields(recordMap map[string]interface{}) error {
+	username, _ := recordMap["username"].(string)
 	for key, value := range recordMap {
 		if strings.Contains(strings.ToLower(key), "password") {
 			if strValue, ok := value.(string); ok && strValue != "" {
+				if err := password.CurrentPolicy().Validate(strValue, username); err != nil {
+					return fmt.Errorf("seed %s: %w", key, err)
+				}
 				hashed, err := bcrypt.GenerateFromPassword([]byte(strValue), bcrypt.DefaultCost)
 				if err == nil {
 					recordMap[key] = string(hashed)
@@ -163,6 +172,7 @@ func (s *Seeder) hashPasswordFields(recordMap map[string]interface{}) {
 			}
 		}
 	}
+	return nil
 }
 
 func toPascalCase(s string) string {
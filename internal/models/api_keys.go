@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+type ApiKeys struct {
+	ApiKeysId  string     `db:"api_keys_id" json:"api_keys_id"`
+	Name       string     `db:"name,notnull" json:"name"`
+	Prefix     string     `db:"prefix,unique,notnull" json:"prefix"`
+	KeyHash    string     `db:"key_hash,unique,notnull" json:"-"`
+	UserId     string     `db:"user_id,notnull" json:"user_id"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	AllowedIps []string   `db:"allowed_ips" json:"allowed_ips"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	LastUsedIp *string    `db:"last_used_ip" json:"last_used_ip"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (ApiKeys) TableName() string {
+	return "api_keys"
+}
+
+func (ApiKeys) GetID() string {
+	return "api_keys_id"
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fleetify/internal/migration"
+	"time"
+)
+
+type Categories struct {
+	CategoriesId    string    `db:"categories_id" json:"categories_id"`
+	ParentId        *string   `db:"parent_id" json:"parent_id"`
+	Code            string    `db:"code,unique,notnull" json:"code"`
+	Name            string    `db:"name,notnull" json:"name"`
+	Description     string    `db:"description" json:"description"`
+	DefaultMinStock int       `db:"default_min_stock" json:"default_min_stock"`
+	GlAccount       string    `db:"gl_account" json:"gl_account"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (Categories) TableName() string {
+	return "categories"
+}
+
+func (Categories) GetID() string {
+	return "categories_id"
+}
+
+func init() {
+	migration.RegisterSeeder("Categories", func() interface{} {
+		return SeedCategories()
+	})
+}
+
+func SeedCategories() []Categories {
+	now := time.Now()
+	return []Categories{
+		{Code: "oil", Name: "Oil & Fluids", Description: "Lubricants, coolants and brake fluid", DefaultMinStock: 10, GlAccount: "1301", CreatedAt: now, UpdatedAt: now},
+		{Code: "parts", Name: "Spare Parts", Description: "Replacement parts and consumables", DefaultMinStock: 5, GlAccount: "1302", CreatedAt: now, UpdatedAt: now},
+		{Code: "tire", Name: "Tires", Description: "Tires and tubes", DefaultMinStock: 4, GlAccount: "1303", CreatedAt: now, UpdatedAt: now},
+		{Code: "battery", Name: "Batteries", Description: "Vehicle batteries", DefaultMinStock: 3, GlAccount: "1304", CreatedAt: now, UpdatedAt: now},
+		{Code: "service", Name: "Services", Description: "Workshop and maintenance services", GlAccount: "6101", CreatedAt: now, UpdatedAt: now},
+	}
+}
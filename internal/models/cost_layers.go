@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+type CostLayers struct {
+	CostLayersId string    `db:"cost_layers_id" json:"cost_layers_id"`
+	ItemId       string    `db:"item_id,notnull" json:"item_id"`
+	SourceType   string    `db:"source_type,notnull" json:"source_type"`
+	SourceId     *string   `db:"source_id" json:"source_id"`
+	ReceivedAt   time.Time `db:"received_at,notnull" json:"received_at"`
+	UnitCost     float64   `db:"unit_cost,notnull" json:"unit_cost"`
+	QtyReceived  int       `db:"qty_received,notnull" json:"qty_received"`
+	QtyRemaining int       `db:"qty_remaining,notnull" json:"qty_remaining"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+func (CostLayers) TableName() string {
+	return "cost_layers"
+}
+
+func (CostLayers) GetID() string {
+	return "cost_layers_id"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+type Departments struct {
+	DepartmentsId string    `db:"departments_id" json:"departments_id"`
+	Code          string    `db:"code,unique,notnull" json:"code"`
+	Name          string    `db:"name,notnull" json:"name"`
+	CostCenter    string    `db:"cost_center" json:"cost_center"`
+	IsActive      bool      `db:"is_active" json:"is_active"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (Departments) TableName() string {
+	return "departments"
+}
+
+func (Departments) GetID() string {
+	return "departments_id"
+}
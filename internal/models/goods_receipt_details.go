@@ -0,0 +1,21 @@
+package models
+
+type GoodsReceiptDetails struct {
+	GoodsReceiptDetailsId string  `db:"goods_receipt_details_id" json:"goods_receipt_details_id"`
+	GoodsReceiptId        string  `db:"goods_receipt_id,notnull" json:"goods_receipt_id"`
+	ItemId                string  `db:"item_id,notnull" json:"item_id"`
+	UnitId                *string `db:"unit_id" json:"unit_id"`
+	UnitQty               int     `db:"unit_qty" json:"unit_qty"`
+	Factor                int     `db:"factor" json:"factor"`
+	Qty                   int     `db:"qty,notnull" json:"qty"`
+	UnitCost              float64 `db:"unit_cost,notnull" json:"unit_cost"`
+	LotNumber             string  `db:"lot_number" json:"lot_number"`
+}
+
+func (GoodsReceiptDetails) TableName() string {
+	return "goods_receipt_details"
+}
+
+func (GoodsReceiptDetails) GetID() string {
+	return "goods_receipt_details_id"
+}
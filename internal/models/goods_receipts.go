@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type GoodsReceipts struct {
+	GoodsReceiptsId string    `db:"goods_receipts_id" json:"goods_receipts_id"`
+	PurchasingId    string    `db:"purchasing_id,notnull" json:"purchasing_id"`
+	WarehouseId     string    `db:"warehouse_id,notnull" json:"warehouse_id"`
+	Date            time.Time `db:"date,notnull" json:"date"`
+	UserId          string    `db:"user_id,notnull" json:"user_id"`
+	Notes           string    `db:"notes" json:"notes"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (GoodsReceipts) TableName() string {
+	return "goods_receipts"
+}
+
+func (GoodsReceipts) GetID() string {
+	return "goods_receipts_id"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type InventoryCostEntries struct {
+	InventoryCostEntriesId string    `db:"inventory_cost_entries_id" json:"inventory_cost_entries_id"`
+	ItemId                 string    `db:"item_id,notnull" json:"item_id"`
+	WarehouseId            *string   `db:"warehouse_id" json:"warehouse_id"`
+	SourceType             string    `db:"source_type,notnull" json:"source_type"`
+	SourceId               string    `db:"source_id,notnull" json:"source_id"`
+	Qty                    int       `db:"qty,notnull" json:"qty"`
+	Method                 string    `db:"method,notnull" json:"method"`
+	AverageCost            float64   `db:"average_cost,notnull" json:"average_cost"`
+	FifoCost               float64   `db:"fifo_cost,notnull" json:"fifo_cost"`
+	TotalCost              float64   `db:"total_cost,notnull" json:"total_cost"`
+	CreatedAt              time.Time `db:"created_at" json:"created_at"`
+}
+
+func (InventoryCostEntries) TableName() string {
+	return "inventory_cost_entries"
+}
+
+func (InventoryCostEntries) GetID() string {
+	return "inventory_cost_entries_id"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+type ItemBarcodes struct {
+	ItemBarcodesId string    `db:"item_barcodes_id" json:"item_barcodes_id"`
+	ItemId         string    `db:"item_id,notnull" json:"item_id"`
+	Symbology      string    `db:"symbology,notnull" json:"symbology"`
+	Code           string    `db:"code,unique,notnull" json:"code"`
+	IsPrimary      bool      `db:"is_primary" json:"is_primary"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (ItemBarcodes) TableName() string {
+	return "item_barcodes"
+}
+
+func (ItemBarcodes) GetID() string {
+	return "item_barcodes_id"
+}
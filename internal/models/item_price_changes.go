@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+type ItemPriceChanges struct {
+	ItemPriceChangesId string    `db:"item_price_changes_id" json:"item_price_changes_id"`
+	ItemId             string    `db:"item_id,notnull" json:"item_id"`
+	OldPrice           float64   `db:"old_price,notnull" json:"old_price"`
+	NewPrice           float64   `db:"new_price,notnull" json:"new_price"`
+	ChangePct          *float64  `db:"change_pct" json:"change_pct"`
+	UserId             *string   `db:"user_id" json:"user_id"`
+	Reason             string    `db:"reason" json:"reason"`
+	ChangedAt          time.Time `db:"changed_at,notnull" json:"changed_at"`
+	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (ItemPriceChanges) TableName() string {
+	return "item_price_changes"
+}
+
+func (ItemPriceChanges) GetID() string {
+	return "item_price_changes_id"
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+type ItemSerials struct {
+	ItemSerialsId  string    `db:"item_serials_id" json:"item_serials_id"`
+	ItemId         string    `db:"item_id,notnull" json:"item_id"`
+	SerialNumber   string    `db:"serial_number,notnull" json:"serial_number"`
+	LotNumber      string    `db:"lot_number" json:"lot_number"`
+	Status         string    `db:"status,notnull" json:"status"`
+	WarehouseId    *string   `db:"warehouse_id" json:"warehouse_id"`
+	GoodsReceiptId string    `db:"goods_receipt_id,notnull" json:"goods_receipt_id"`
+	StockIssueId   *string   `db:"stock_issue_id" json:"stock_issue_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (ItemSerials) TableName() string {
+	return "item_serials"
+}
+
+func (ItemSerials) GetID() string {
+	return "item_serials_id"
+}
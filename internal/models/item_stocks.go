@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type ItemStocks struct {
+	ItemStocksId string    `db:"item_stocks_id" json:"item_stocks_id"`
+	ItemId       string    `db:"item_id,notnull" json:"item_id"`
+	WarehouseId  string    `db:"warehouse_id,notnull" json:"warehouse_id"`
+	Qty          int       `db:"qty,notnull" json:"qty"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (ItemStocks) TableName() string {
+	return "item_stocks"
+}
+
+func (ItemStocks) GetID() string {
+	return "item_stocks_id"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type ItemUnits struct {
+	ItemUnitsId string    `db:"item_units_id" json:"item_units_id"`
+	ItemId      string    `db:"item_id,notnull" json:"item_id"`
+	UnitId      string    `db:"unit_id,notnull" json:"unit_id"`
+	Factor      int       `db:"factor,notnull" json:"factor"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (ItemUnits) TableName() string {
+	return "item_units"
+}
+
+func (ItemUnits) GetID() string {
+	return "item_units_id"
+}
@@ -6,15 +6,22 @@ import (
 )
 
 type Items struct {
-	ItemsId   string    `db:"items_id" json:"items_id"`
-	Name      string    `db:"name,notnull" json:"name"`
-	Stock     int       `db:"stock" json:"stock"`
-	Price     float64   `db:"price,notnull" json:"price"`
-	Category  string    `db:"category" json:"category"`
-	Unit      string    `db:"unit" json:"unit"`
-	MinStock  int       `db:"min_stock" json:"min_stock"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ItemsId        string    `db:"items_id" json:"items_id"`
+	Name           string    `db:"name,notnull" json:"name"`
+	Sku            string    `db:"sku,unique,notnull" json:"sku"`
+	Stock          int       `db:"stock" json:"stock"`
+	Price          float64   `db:"price,notnull" json:"price"`
+	AvgCost        float64   `db:"avg_cost" json:"avg_cost"`
+	Category       string    `db:"category" json:"category"`
+	CategoryId     *string   `db:"category_id" json:"category_id"`
+	Unit           string    `db:"unit" json:"unit"`
+	UnitId         *string   `db:"unit_id" json:"unit_id"`
+	PurchaseUnitId *string   `db:"purchase_unit_id" json:"purchase_unit_id"`
+	MinStock       int       `db:"min_stock" json:"min_stock"`
+	TrackSerial    bool      `db:"track_serial" json:"track_serial"`
+	TrackLot       bool      `db:"track_lot" json:"track_lot"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
 }
 
 func (Items) TableName() string {
@@ -34,20 +41,20 @@ func init() {
 func SeedItems() []Items {
 	now := time.Now()
 	return []Items{
-		{Name: "Engine Oil 5W-30", Stock: 50, Price: 150000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
-		{Name: "Engine Oil 10W-40", Stock: 45, Price: 140000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
-		{Name: "Brake Pad Front", Stock: 30, Price: 250000, Category: "parts", Unit: "set", MinStock: 5, CreatedAt: now, UpdatedAt: now},
-		{Name: "Brake Pad Rear", Stock: 25, Price: 200000, Category: "parts", Unit: "set", MinStock: 5, CreatedAt: now, UpdatedAt: now},
-		{Name: "Air Filter", Stock: 40, Price: 75000, Category: "parts", Unit: "pcs", MinStock: 10, CreatedAt: now, UpdatedAt: now},
-		{Name: "Fuel Filter", Stock: 35, Price: 85000, Category: "parts", Unit: "pcs", MinStock: 10, CreatedAt: now, UpdatedAt: now},
-		{Name: "Tire 205/55R16", Stock: 20, Price: 800000, Category: "tire", Unit: "pcs", MinStock: 4, CreatedAt: now, UpdatedAt: now},
-		{Name: "Tire 215/60R16", Stock: 18, Price: 850000, Category: "tire", Unit: "pcs", MinStock: 4, CreatedAt: now, UpdatedAt: now},
-		{Name: "Battery 12V 60Ah", Stock: 15, Price: 1200000, Category: "battery", Unit: "pcs", MinStock: 3, CreatedAt: now, UpdatedAt: now},
-		{Name: "Battery 12V 70Ah", Stock: 12, Price: 1400000, Category: "battery", Unit: "pcs", MinStock: 3, CreatedAt: now, UpdatedAt: now},
-		{Name: "Spark Plug", Stock: 60, Price: 45000, Category: "parts", Unit: "pcs", MinStock: 20, CreatedAt: now, UpdatedAt: now},
-		{Name: "Radiator Coolant", Stock: 30, Price: 95000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
-		{Name: "Windshield Wiper", Stock: 25, Price: 55000, Category: "parts", Unit: "set", MinStock: 5, CreatedAt: now, UpdatedAt: now},
-		{Name: "Headlight Bulb H4", Stock: 20, Price: 125000, Category: "parts", Unit: "pcs", MinStock: 5, CreatedAt: now, UpdatedAt: now},
-		{Name: "Brake Fluid", Stock: 35, Price: 65000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
+		{Name: "Engine Oil 5W-30", Sku: "OIL-5W30", Stock: 50, Price: 150000, AvgCost: 150000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
+		{Name: "Engine Oil 10W-40", Sku: "OIL-10W40", Stock: 45, Price: 140000, AvgCost: 140000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
+		{Name: "Brake Pad Front", Sku: "BRK-PAD-F", Stock: 30, Price: 250000, AvgCost: 250000, Category: "parts", Unit: "set", MinStock: 5, CreatedAt: now, UpdatedAt: now},
+		{Name: "Brake Pad Rear", Sku: "BRK-PAD-R", Stock: 25, Price: 200000, AvgCost: 200000, Category: "parts", Unit: "set", MinStock: 5, CreatedAt: now, UpdatedAt: now},
+		{Name: "Air Filter", Sku: "FLT-AIR", Stock: 40, Price: 75000, AvgCost: 75000, Category: "parts", Unit: "pcs", MinStock: 10, CreatedAt: now, UpdatedAt: now},
+		{Name: "Fuel Filter", Sku: "FLT-FUEL", Stock: 35, Price: 85000, AvgCost: 85000, Category: "parts", Unit: "pcs", MinStock: 10, CreatedAt: now, UpdatedAt: now},
+		{Name: "Tire 205/55R16", Sku: "TIR-2055516", Stock: 20, Price: 800000, AvgCost: 800000, Category: "tire", Unit: "pcs", MinStock: 4, CreatedAt: now, UpdatedAt: now},
+		{Name: "Tire 215/60R16", Sku: "TIR-2156016", Stock: 18, Price: 850000, AvgCost: 850000, Category: "tire", Unit: "pcs", MinStock: 4, CreatedAt: now, UpdatedAt: now},
+		{Name: "Battery 12V 60Ah", Sku: "BAT-12V60", Stock: 15, Price: 1200000, AvgCost: 1200000, Category: "battery", Unit: "pcs", MinStock: 3, CreatedAt: now, UpdatedAt: now},
+		{Name: "Battery 12V 70Ah", Sku: "BAT-12V70", Stock: 12, Price: 1400000, AvgCost: 1400000, Category: "battery", Unit: "pcs", MinStock: 3, CreatedAt: now, UpdatedAt: now},
+		{Name: "Spark Plug", Sku: "ELC-SPARK", Stock: 60, Price: 45000, AvgCost: 45000, Category: "parts", Unit: "pcs", MinStock: 20, CreatedAt: now, UpdatedAt: now},
+		{Name: "Radiator Coolant", Sku: "OIL-COOLANT", Stock: 30, Price: 95000, AvgCost: 95000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
+		{Name: "Windshield Wiper", Sku: "BDY-WIPER", Stock: 25, Price: 55000, AvgCost: 55000, Category: "parts", Unit: "set", MinStock: 5, CreatedAt: now, UpdatedAt: now},
+		{Name: "Headlight Bulb H4", Sku: "ELC-H4", Stock: 20, Price: 125000, AvgCost: 125000, Category: "parts", Unit: "pcs", MinStock: 5, CreatedAt: now, UpdatedAt: now},
+		{Name: "Brake Fluid", Sku: "OIL-BRAKE", Stock: 35, Price: 65000, AvgCost: 65000, Category: "oil", Unit: "liter", MinStock: 10, CreatedAt: now, UpdatedAt: now},
 	}
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type LoginChallenges struct {
+	LoginChallengesId string    `db:"login_challenges_id" json:"login_challenges_id"`
+	UserId            string    `db:"user_id,notnull" json:"user_id"`
+	TokenHash         string    `db:"token_hash,unique,notnull" json:"-"`
+	ExpiresAt         time.Time `db:"expires_at,notnull" json:"expires_at"`
+	Attempts          int       `db:"attempts" json:"attempts"`
+	IpAddress         string    `db:"ip_address" json:"ip_address"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (LoginChallenges) TableName() string {
+	return "login_challenges"
+}
+
+func (LoginChallenges) GetID() string {
+	return "login_challenges_id"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type OidcLoginStates struct {
+	OidcLoginStatesId string    `db:"oidc_login_states_id" json:"oidc_login_states_id"`
+	StateHash         string    `db:"state_hash,unique,notnull" json:"-"`
+	Nonce             string    `db:"nonce,notnull" json:"-"`
+	CodeVerifier      string    `db:"code_verifier,notnull" json:"-"`
+	ExpiresAt         time.Time `db:"expires_at,notnull" json:"expires_at"`
+	IpAddress         string    `db:"ip_address" json:"ip_address"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (OidcLoginStates) TableName() string {
+	return "oidc_login_states"
+}
+
+func (OidcLoginStates) GetID() string {
+	return "oidc_login_states_id"
+}
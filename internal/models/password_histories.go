@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+type PasswordHistories struct {
+	PasswordHistoriesId string    `db:"password_histories_id" json:"password_histories_id"`
+	UserId              string    `db:"user_id,notnull" json:"user_id"`
+	PasswordHash        string    `db:"password_hash,notnull" json:"-"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (PasswordHistories) TableName() string {
+	return "password_histories"
+}
+
+func (PasswordHistories) GetID() string {
+	return "password_histories_id"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type PasswordResetTokens struct {
+	PasswordResetTokensId string     `db:"password_reset_tokens_id" json:"password_reset_tokens_id"`
+	UserId                string     `db:"user_id,notnull" json:"user_id"`
+	TokenHash             string     `db:"token_hash,unique,notnull" json:"-"`
+	ExpiresAt             time.Time  `db:"expires_at,notnull" json:"expires_at"`
+	UsedAt                *time.Time `db:"used_at" json:"used_at"`
+	IpAddress             string     `db:"ip_address" json:"ip_address"`
+	CreatedAt             time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (PasswordResetTokens) TableName() string {
+	return "password_reset_tokens"
+}
+
+func (PasswordResetTokens) GetID() string {
+	return "password_reset_tokens_id"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+type Permissions struct {
+	PermissionsId string    `db:"permissions_id" json:"permissions_id"`
+	Code          string    `db:"code,unique,notnull" json:"code"`
+	Description   string    `db:"description" json:"description"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (Permissions) TableName() string {
+	return "permissions"
+}
+
+func (Permissions) GetID() string {
+	return "permissions_id"
+}
@@ -8,6 +8,9 @@ type PurchasingDetails struct {
 	PurchasingDetailsId string  `db:"purchasing_details_id" json:"purchasing_details_id"`
 	PurchasingId        string  `db:"purchasing_id,notnull" json:"purchasing_id"`
 	ItemId              string  `db:"item_id,notnull" json:"item_id"`
+	UnitId              *string `db:"unit_id" json:"unit_id"`
+	UnitQty             int     `db:"unit_qty" json:"unit_qty"`
+	Factor              int     `db:"factor" json:"factor"`
 	Qty                 int     `db:"qty,notnull" json:"qty"`
 	Subtotal            float64 `db:"subtotal,notnull" json:"subtotal"`
 }
@@ -9,7 +9,9 @@ type Purchasings struct {
 	PurchasingsId string    `db:"purchasings_id" json:"purchasings_id"`
 	Date          time.Time `db:"date,notnull" json:"date"`
 	SupplierId    string    `db:"supplier_id,notnull" json:"supplier_id"`
+	WarehouseId   string    `db:"warehouse_id,notnull" json:"warehouse_id"`
 	UserId        string    `db:"user_id,notnull" json:"user_id"`
+	DepartmentId  *string   `db:"department_id" json:"department_id"`
 	GrandTotal    float64   `db:"grand_total,notnull" json:"grand_total"`
 	Status        string    `db:"status" json:"status"`
 	Notes         string    `db:"notes" json:"notes"`
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+type RefreshTokens struct {
+	RefreshTokensId string     `db:"refresh_tokens_id" json:"refresh_tokens_id"`
+	UserId          string     `db:"user_id,notnull" json:"user_id"`
+	FamilyId        string     `db:"family_id,notnull" json:"family_id"`
+	TokenHash       string     `db:"token_hash,unique,notnull" json:"-"`
+	ExpiresAt       time.Time  `db:"expires_at,notnull" json:"expires_at"`
+	UsedAt          *time.Time `db:"used_at" json:"used_at"`
+	ReplacedBy      *string    `db:"replaced_by" json:"replaced_by"`
+	RevokedAt       *time.Time `db:"revoked_at" json:"revoked_at"`
+	UserAgent       string     `db:"user_agent" json:"user_agent"`
+	IpAddress       string     `db:"ip_address" json:"ip_address"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (RefreshTokens) TableName() string {
+	return "refresh_tokens"
+}
+
+func (RefreshTokens) GetID() string {
+	return "refresh_tokens_id"
+}
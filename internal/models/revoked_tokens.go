@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+type RevokedTokens struct {
+	RevokedTokensId string     `db:"revoked_tokens_id" json:"revoked_tokens_id"`
+	Jti             *string    `db:"jti,unique" json:"jti"`
+	UserId          string     `db:"user_id,notnull" json:"user_id"`
+	RevokedBefore   *time.Time `db:"revoked_before" json:"revoked_before"`
+	SessionId       *string    `db:"session_id" json:"session_id"`
+	ExpiresAt       time.Time  `db:"expires_at,notnull" json:"expires_at"`
+	Reason          string     `db:"reason" json:"reason"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (RevokedTokens) TableName() string {
+	return "revoked_tokens"
+}
+
+func (RevokedTokens) GetID() string {
+	return "revoked_tokens_id"
+}
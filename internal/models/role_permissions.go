@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+type RolePermissions struct {
+	RolePermissionsId string    `db:"role_permissions_id" json:"role_permissions_id"`
+	RoleOid           string    `db:"role_oid,notnull" json:"role_oid"`
+	Permission        string    `db:"permission,notnull" json:"permission"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (RolePermissions) TableName() string {
+	return "role_permissions"
+}
+
+func (RolePermissions) GetID() string {
+	return "role_permissions_id"
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+type SigningKeys struct {
+	SigningKeysId string    `db:"signing_keys_id" json:"signing_keys_id"`
+	Kid           string    `db:"kid,unique,notnull" json:"kid"`
+	Algorithm     string    `db:"algorithm,notnull" json:"algorithm"`
+	PrivateKey    string    `db:"private_key,notnull" json:"-"`
+	ActivatesAt   time.Time `db:"activates_at,notnull" json:"activates_at"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (SigningKeys) TableName() string {
+	return "signing_keys"
+}
+
+func (SigningKeys) GetID() string {
+	return "signing_keys_id"
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+type StockCountDetails struct {
+	StockCountDetailsId string     `db:"stock_count_details_id" json:"stock_count_details_id"`
+	StockCountId        string     `db:"stock_count_id,notnull" json:"stock_count_id"`
+	ItemId              string     `db:"item_id,notnull" json:"item_id"`
+	ExpectedQty         int        `db:"expected_qty,notnull" json:"expected_qty"`
+	SystemQty           *int       `db:"system_qty" json:"system_qty"`
+	CountedQty          *int       `db:"counted_qty" json:"counted_qty"`
+	UnitCost            float64    `db:"unit_cost,notnull" json:"unit_cost"`
+	Notes               string     `db:"notes" json:"notes"`
+	CountedBy           *string    `db:"counted_by" json:"counted_by"`
+	CountedAt           *time.Time `db:"counted_at" json:"counted_at"`
+}
+
+func (StockCountDetails) TableName() string {
+	return "stock_count_details"
+}
+
+func (StockCountDetails) GetID() string {
+	return "stock_count_details_id"
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+type StockCounts struct {
+	StockCountsId   string     `db:"stock_counts_id" json:"stock_counts_id"`
+	WarehouseId     string     `db:"warehouse_id,notnull" json:"warehouse_id"`
+	Status          string     `db:"status,notnull" json:"status"`
+	FreezeMovements bool       `db:"freeze_movements" json:"freeze_movements"`
+	StartedAt       time.Time  `db:"started_at,notnull" json:"started_at"`
+	SubmittedAt     *time.Time `db:"submitted_at" json:"submitted_at"`
+	ApprovedAt      *time.Time `db:"approved_at" json:"approved_at"`
+	UserId          string     `db:"user_id,notnull" json:"user_id"`
+	ApprovedBy      *string    `db:"approved_by" json:"approved_by"`
+	Notes           string     `db:"notes" json:"notes"`
+	ReviewNotes     string     `db:"review_notes" json:"review_notes"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (StockCounts) TableName() string {
+	return "stock_counts"
+}
+
+func (StockCounts) GetID() string {
+	return "stock_counts_id"
+}
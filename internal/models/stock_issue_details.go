@@ -0,0 +1,19 @@
+package models
+
+type StockIssueDetails struct {
+	StockIssueDetailsId string  `db:"stock_issue_details_id" json:"stock_issue_details_id"`
+	StockIssueId        string  `db:"stock_issue_id,notnull" json:"stock_issue_id"`
+	ItemId              string  `db:"item_id,notnull" json:"item_id"`
+	Qty                 int     `db:"qty,notnull" json:"qty"`
+	LotNumber           string  `db:"lot_number" json:"lot_number"`
+	UnitCost            float64 `db:"unit_cost" json:"unit_cost"`
+	TotalCost           float64 `db:"total_cost" json:"total_cost"`
+}
+
+func (StockIssueDetails) TableName() string {
+	return "stock_issue_details"
+}
+
+func (StockIssueDetails) GetID() string {
+	return "stock_issue_details_id"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type StockIssues struct {
+	StockIssuesId string    `db:"stock_issues_id" json:"stock_issues_id"`
+	VehicleId     *string   `db:"vehicle_id" json:"vehicle_id"`
+	WarehouseId   string    `db:"warehouse_id,notnull" json:"warehouse_id"`
+	Date          time.Time `db:"date,notnull" json:"date"`
+	UserId        string    `db:"user_id,notnull" json:"user_id"`
+	Notes         string    `db:"notes" json:"notes"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (StockIssues) TableName() string {
+	return "stock_issues"
+}
+
+func (StockIssues) GetID() string {
+	return "stock_issues_id"
+}
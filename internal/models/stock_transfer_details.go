@@ -0,0 +1,18 @@
+package models
+
+type StockTransferDetails struct {
+	StockTransferDetailsId string   `db:"stock_transfer_details_id" json:"stock_transfer_details_id"`
+	StockTransferId        string   `db:"stock_transfer_id,notnull" json:"stock_transfer_id"`
+	ItemId                 string   `db:"item_id,notnull" json:"item_id"`
+	Qty                    int      `db:"qty,notnull" json:"qty"`
+	LotNumber              string   `db:"lot_number" json:"lot_number"`
+	SerialNumbers          []string `db:"serial_numbers" json:"serial_numbers"`
+}
+
+func (StockTransferDetails) TableName() string {
+	return "stock_transfer_details"
+}
+
+func (StockTransferDetails) GetID() string {
+	return "stock_transfer_details_id"
+}
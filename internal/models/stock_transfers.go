@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type StockTransfers struct {
+	StockTransfersId string     `db:"stock_transfers_id" json:"stock_transfers_id"`
+	FromWarehouseId  string     `db:"from_warehouse_id,notnull" json:"from_warehouse_id"`
+	ToWarehouseId    string     `db:"to_warehouse_id,notnull" json:"to_warehouse_id"`
+	Status           string     `db:"status,notnull" json:"status"`
+	ShippedAt        time.Time  `db:"shipped_at,notnull" json:"shipped_at"`
+	ReceivedAt       *time.Time `db:"received_at" json:"received_at"`
+	UserId           string     `db:"user_id,notnull" json:"user_id"`
+	ReceivedBy       *string    `db:"received_by" json:"received_by"`
+	Notes            string     `db:"notes" json:"notes"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (StockTransfers) TableName() string {
+	return "stock_transfers"
+}
+
+func (StockTransfers) GetID() string {
+	return "stock_transfers_id"
+}
@@ -12,6 +12,7 @@ type Suppliers struct {
 	Address      string    `db:"address" json:"address"`
 	Phone        string    `db:"phone" json:"phone"`
 	SupplierType string    `db:"supplier_type" json:"supplier_type"`
+	CategoryId   *string   `db:"category_id" json:"category_id"`
 	IsActive     bool      `db:"is_active" json:"is_active"`
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
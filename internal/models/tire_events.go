@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type TireEvents struct {
+	TireEventsId string    `db:"tire_events_id" json:"tire_events_id"`
+	TireId       string    `db:"tire_id,notnull" json:"tire_id"`
+	EventType    string    `db:"event_type,notnull" json:"event_type"`
+	VehicleId    *string   `db:"vehicle_id" json:"vehicle_id"`
+	Position     string    `db:"position" json:"position"`
+	Odometer     int       `db:"odometer" json:"odometer"`
+	TreadDepth   float64   `db:"tread_depth" json:"tread_depth"`
+	Cost         float64   `db:"cost" json:"cost"`
+	Notes        string    `db:"notes" json:"notes"`
+	UserId       string    `db:"user_id,notnull" json:"user_id"`
+	EventDate    time.Time `db:"event_date,notnull" json:"event_date"`
+}
+
+func (TireEvents) TableName() string {
+	return "tire_events"
+}
+
+func (TireEvents) GetID() string {
+	return "tire_events_id"
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type Tires struct {
+	TiresId       string    `db:"tires_id" json:"tires_id"`
+	SerialNumber  string    `db:"serial_number,unique,notnull" json:"serial_number"`
+	ItemId        string    `db:"item_id,notnull" json:"item_id"`
+	PurchasingId  string    `db:"purchasing_id,notnull" json:"purchasing_id"`
+	SupplierId    string    `db:"supplier_id,notnull" json:"supplier_id"`
+	Brand         string    `db:"brand,notnull" json:"brand"`
+	PurchaseCost  float64   `db:"purchase_cost,notnull" json:"purchase_cost"`
+	TotalCost     float64   `db:"total_cost,notnull" json:"total_cost"`
+	Status        string    `db:"status,notnull" json:"status"`
+	VehicleId     *string   `db:"vehicle_id" json:"vehicle_id"`
+	Position      string    `db:"position" json:"position"`
+	MountOdometer int       `db:"mount_odometer" json:"mount_odometer"`
+	TotalKm       int       `db:"total_km" json:"total_km"`
+	TreadDepth    float64   `db:"tread_depth" json:"tread_depth"`
+	RetreadCount  int       `db:"retread_count" json:"retread_count"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (Tires) TableName() string {
+	return "tires"
+}
+
+func (Tires) GetID() string {
+	return "tires_id"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type Trips struct {
+	TripsId     string     `db:"trips_id" json:"trips_id"`
+	VehicleId   string     `db:"vehicle_id,notnull" json:"vehicle_id"`
+	DriverId    string     `db:"driver_id,notnull" json:"driver_id"`
+	Origin      string     `db:"origin,notnull" json:"origin"`
+	Destination string     `db:"destination,notnull" json:"destination"`
+	DepartureAt time.Time  `db:"departure_at,notnull" json:"departure_at"`
+	ArrivalAt   *time.Time `db:"arrival_at" json:"arrival_at"`
+	Status      string     `db:"status" json:"status"`
+	Notes       string     `db:"notes" json:"notes"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (Trips) TableName() string {
+	return "trips"
+}
+
+func (Trips) GetID() string {
+	return "trips_id"
+}
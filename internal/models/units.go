@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fleetify/internal/migration"
+	"time"
+)
+
+type Units struct {
+	UnitsId   string    `db:"units_id" json:"units_id"`
+	Code      string    `db:"code,unique,notnull" json:"code"`
+	Name      string    `db:"name,notnull" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (Units) TableName() string {
+	return "units"
+}
+
+func (Units) GetID() string {
+	return "units_id"
+}
+
+func init() {
+	migration.RegisterSeeder("Units", func() interface{} {
+		return SeedUnits()
+	})
+}
+
+func SeedUnits() []Units {
+	now := time.Now()
+	return []Units{
+		{Code: "liter", Name: "Liter", CreatedAt: now, UpdatedAt: now},
+		{Code: "pcs", Name: "Pieces", CreatedAt: now, UpdatedAt: now},
+		{Code: "set", Name: "Set", CreatedAt: now, UpdatedAt: now},
+		{Code: "drum", Name: "Drum", CreatedAt: now, UpdatedAt: now},
+		{Code: "box", Name: "Box", CreatedAt: now, UpdatedAt: now},
+		{Code: "pail", Name: "Pail", CreatedAt: now, UpdatedAt: now},
+	}
+}
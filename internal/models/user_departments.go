@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+type UserDepartments struct {
+	UserDepartmentsId string    `db:"user_departments_id" json:"user_departments_id"`
+	UserId            string    `db:"user_id,notnull" json:"user_id"`
+	DepartmentId      string    `db:"department_id,notnull" json:"department_id"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (UserDepartments) TableName() string {
+	return "user_departments"
+}
+
+func (UserDepartments) GetID() string {
+	return "user_departments_id"
+}
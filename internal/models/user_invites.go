@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+type UserInvites struct {
+	UserInvitesId string     `db:"user_invites_id" json:"user_invites_id"`
+	TokenHash     string     `db:"token_hash,unique,notnull" json:"-"`
+	Role          string     `db:"role,notnull" json:"role"`
+	Email         string     `db:"email" json:"email"`
+	CreatedBy     *string    `db:"created_by" json:"created_by"`
+	ExpiresAt     time.Time  `db:"expires_at,notnull" json:"expires_at"`
+	UsedAt        *time.Time `db:"used_at" json:"used_at"`
+	UsedBy        *string    `db:"used_by" json:"used_by"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (UserInvites) TableName() string {
+	return "user_invites"
+}
+
+func (UserInvites) GetID() string {
+	return "user_invites_id"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type UserRecoveryCodes struct {
+	UserRecoveryCodesId string     `db:"user_recovery_codes_id" json:"user_recovery_codes_id"`
+	UserId              string     `db:"user_id,notnull" json:"user_id"`
+	CodeHash            string     `db:"code_hash,notnull" json:"-"`
+	UsedAt              *time.Time `db:"used_at" json:"used_at"`
+	CreatedAt           time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (UserRecoveryCodes) TableName() string {
+	return "user_recovery_codes"
+}
+
+func (UserRecoveryCodes) GetID() string {
+	return "user_recovery_codes_id"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type UserSessions struct {
+	UserSessionsId string     `db:"user_sessions_id" json:"user_sessions_id"`
+	UserId         string     `db:"user_id,notnull" json:"user_id"`
+	UserAgent      string     `db:"user_agent" json:"user_agent"`
+	IpAddress      string     `db:"ip_address" json:"ip_address"`
+	LastSeenAt     time.Time  `db:"last_seen_at,notnull" json:"last_seen_at"`
+	LastSeenIp     string     `db:"last_seen_ip" json:"last_seen_ip"`
+	ExpiresAt      time.Time  `db:"expires_at,notnull" json:"expires_at"`
+	RevokedAt      *time.Time `db:"revoked_at" json:"revoked_at"`
+	RevokedReason  string     `db:"revoked_reason" json:"revoked_reason"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (UserSessions) TableName() string {
+	return "user_sessions"
+}
+
+func (UserSessions) GetID() string {
+	return "user_sessions_id"
+}
@@ -18,6 +18,15 @@ type Users struct {
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 
+	MustChangePassword bool `db:"must_change_password" json:"must_change_password"`
+
+	TwoFactorEnabled  bool   `db:"two_factor_enabled" json:"two_factor_enabled"`
+	TwoFactorSecret   string `db:"two_factor_secret" json:"-"`
+	TwoFactorLastStep int64  `db:"two_factor_last_step" json:"-"`
+
+	OidcIssuer  string `db:"oidc_issuer" json:"-"`
+	OidcSubject string `db:"oidc_subject" json:"-"`
+
 	CreatedTimestamp time.Time `db:"created_timestamp" json:"created_timestamp"`
 	UpdatedTimestamp time.Time `db:"updated_timestamp" json:"updated_timestamp"`
 }
@@ -36,72 +45,79 @@ func init() {
 	})
 }
 
+// SeedUsers are the initial accounts. Their passwords are in the source, so
+// each has to be changed at the first login.
 func SeedUsers() []Users {
 	return []Users{
 		{
-			Username:         "admin",
-			Password:         "admin123",
-			Role:             "ADMIN",
-			FullName:         "Administrator",
-			Email:            "admin@fleetify.com",
-			Phone:            "081234567890",
-			IsActive:         true,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
-			CreatedTimestamp: time.Now(),
-			UpdatedTimestamp: time.Now(),
+			Username:           "admin",
+			Password:           "Fleetify-Admin1",
+			Role:               "ADMIN",
+			FullName:           "Administrator",
+			Email:              "admin@fleetify.com",
+			Phone:              "081234567890",
+			IsActive:           true,
+			MustChangePassword: true,
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+			CreatedTimestamp:   time.Now(),
+			UpdatedTimestamp:   time.Now(),
 		},
 		{
-			Username:         "manager1",
-			Password:         "manager123",
-			Role:             "MANAGER",
-			FullName:         "Manager One",
-			Email:            "manager1@fleetify.com",
-			Phone:            "081234567891",
-			IsActive:         true,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
-			CreatedTimestamp: time.Now(),
-			UpdatedTimestamp: time.Now(),
+			Username:           "manager1",
+			Password:           "Fleetify-Manager1",
+			Role:               "MANAGER",
+			FullName:           "Manager One",
+			Email:              "manager1@fleetify.com",
+			Phone:              "081234567891",
+			IsActive:           true,
+			MustChangePassword: true,
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+			CreatedTimestamp:   time.Now(),
+			UpdatedTimestamp:   time.Now(),
 		},
 		{
-			Username:         "manager2",
-			Password:         "manager123",
-			Role:             "MANAGER",
-			FullName:         "Manager Two",
-			Email:            "manager2@fleetify.com",
-			Phone:            "081234567892",
-			IsActive:         true,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
-			CreatedTimestamp: time.Now(),
-			UpdatedTimestamp: time.Now(),
+			Username:           "manager2",
+			Password:           "Fleetify-Manager2",
+			Role:               "MANAGER",
+			FullName:           "Manager Two",
+			Email:              "manager2@fleetify.com",
+			Phone:              "081234567892",
+			IsActive:           true,
+			MustChangePassword: true,
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+			CreatedTimestamp:   time.Now(),
+			UpdatedTimestamp:   time.Now(),
 		},
 		{
-			Username:         "purchaser1",
-			Password:         "purchaser123",
-			Role:             "MANAGER",
-			FullName:         "Purchaser One",
-			Email:            "purchaser1@fleetify.com",
-			Phone:            "081234567893",
-			IsActive:         true,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
-			CreatedTimestamp: time.Now(),
-			UpdatedTimestamp: time.Now(),
+			Username:           "purchaser1",
+			Password:           "Fleetify-Purchaser1",
+			Role:               "MANAGER",
+			FullName:           "Purchaser One",
+			Email:              "purchaser1@fleetify.com",
+			Phone:              "081234567893",
+			IsActive:           true,
+			MustChangePassword: true,
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+			CreatedTimestamp:   time.Now(),
+			UpdatedTimestamp:   time.Now(),
 		},
 		{
-			Username:         "purchaser2",
-			Password:         "purchaser123",
-			Role:             "MANAGER",
-			FullName:         "Purchaser Two",
-			Email:            "purchaser2@fleetify.com",
-			Phone:            "081234567894",
-			IsActive:         true,
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
-			CreatedTimestamp: time.Now(),
-			UpdatedTimestamp: time.Now(),
+			Username:           "purchaser2",
+			Password:           "Fleetify-Purchaser2",
+			Role:               "MANAGER",
+			FullName:           "Purchaser Two",
+			Email:              "purchaser2@fleetify.com",
+			Phone:              "081234567894",
+			IsActive:           true,
+			MustChangePassword: true,
+			CreatedAt:          time.Now(),
+			UpdatedAt:          time.Now(),
+			CreatedTimestamp:   time.Now(),
+			UpdatedTimestamp:   time.Now(),
 		},
 	}
 }
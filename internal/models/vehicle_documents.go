@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+type VehicleDocuments struct {
+	VehicleDocumentsId string    `db:"vehicle_documents_id" json:"vehicle_documents_id"`
+	VehicleId          string    `db:"vehicle_id,notnull" json:"vehicle_id"`
+	DocumentType       string    `db:"document_type,notnull" json:"document_type"`
+	DocumentNumber     string    `db:"document_number,notnull" json:"document_number"`
+	IssueDate          time.Time `db:"issue_date,notnull" json:"issue_date"`
+	ExpiryDate         time.Time `db:"expiry_date,notnull" json:"expiry_date"`
+	ScanPath           string    `db:"scan_path" json:"scan_path"`
+	Notes              string    `db:"notes" json:"notes"`
+	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (VehicleDocuments) TableName() string {
+	return "vehicle_documents"
+}
+
+func (VehicleDocuments) GetID() string {
+	return "vehicle_documents_id"
+}
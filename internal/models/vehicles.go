@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fleetify/internal/migration"
+	"time"
+)
+
+type Vehicles struct {
+	VehiclesId  string    `db:"vehicles_id" json:"vehicles_id"`
+	PlateNumber string    `db:"plate_number,unique,notnull" json:"plate_number"`
+	Name        string    `db:"name,notnull" json:"name"`
+	Brand       string    `db:"brand" json:"brand"`
+	Model       string    `db:"model" json:"model"`
+	Year        int       `db:"year" json:"year"`
+	VehicleType string    `db:"vehicle_type" json:"vehicle_type"`
+	Odometer    int       `db:"odometer" json:"odometer"`
+	IsActive    bool      `db:"is_active" json:"is_active"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (Vehicles) TableName() string {
+	return "vehicles"
+}
+
+func (Vehicles) GetID() string {
+	return "vehicles_id"
+}
+
+func init() {
+	migration.RegisterSeeder("Vehicles", func() interface{} {
+		return SeedVehicles()
+	})
+}
+
+func SeedVehicles() []Vehicles {
+	now := time.Now()
+	return []Vehicles{
+		{PlateNumber: "B 1234 FLT", Name: "Truck 01", Brand: "Hino", Model: "Dutro 130 HD", Year: 2021, VehicleType: "truck", Odometer: 84500, IsActive: true, CreatedAt: now, UpdatedAt: now},
+		{PlateNumber: "B 5678 FLT", Name: "Truck 02", Brand: "Mitsubishi", Model: "Canter FE 74", Year: 2020, VehicleType: "truck", Odometer: 112300, IsActive: true, CreatedAt: now, UpdatedAt: now},
+		{PlateNumber: "B 9012 FLT", Name: "Pickup 01", Brand: "Suzuki", Model: "Carry Pick Up", Year: 2022, VehicleType: "pickup", Odometer: 43200, IsActive: true, CreatedAt: now, UpdatedAt: now},
+		{PlateNumber: "B 3456 FLT", Name: "Van 01", Brand: "Daihatsu", Model: "Gran Max Blind Van", Year: 2023, VehicleType: "van", Odometer: 21800, IsActive: true, CreatedAt: now, UpdatedAt: now},
+	}
+}
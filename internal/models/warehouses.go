@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fleetify/internal/migration"
+	"time"
+)
+
+type Warehouses struct {
+	WarehousesId string    `db:"warehouses_id" json:"warehouses_id"`
+	Code         string    `db:"code,unique,notnull" json:"code"`
+	Name         string    `db:"name,notnull" json:"name"`
+	Address      string    `db:"address" json:"address"`
+	IsDefault    bool      `db:"is_default" json:"is_default"`
+	IsActive     bool      `db:"is_active" json:"is_active"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (Warehouses) TableName() string {
+	return "warehouses"
+}
+
+func (Warehouses) GetID() string {
+	return "warehouses_id"
+}
+
+func init() {
+	migration.RegisterSeeder("Warehouses", func() interface{} {
+		return SeedWarehouses()
+	})
+}
+
+func SeedWarehouses() []Warehouses {
+	now := time.Now()
+	return []Warehouses{
+		{Code: "MAIN", Name: "Central Warehouse", Address: "Jakarta", IsDefault: true, IsActive: true, CreatedAt: now, UpdatedAt: now},
+		{Code: "DEPOT-BKS", Name: "Bekasi Depot Store", Address: "Bekasi", IsActive: true, CreatedAt: now, UpdatedAt: now},
+		{Code: "DEPOT-TGR", Name: "Tangerang Depot Store", Address: "Tangerang", IsActive: true, CreatedAt: now, UpdatedAt: now},
+	}
+}
@@ -0,0 +1,113 @@
+// Package permissions resolves what a role may do. Grants live in the
+// role_permissions table and are cached per role for a short time, so roles
+// created or changed through the roles API take effect without a restart.
+package permissions
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"fleetify/internal/database"
+	"fleetify/pkg/jwt"
+)
+
+const (
+	UsersManage        = "users:manage"
+	RolesManage        = "roles:manage"
+	ItemsWrite         = "items:write"
+	ItemsLabels        = "items:labels"
+	SuppliersWrite     = "suppliers:write"
+	PurchasingsWrite   = "purchasings:write"
+	VehiclesWrite      = "vehicles:write"
+	TripsWrite         = "trips:write"
+	TiresWrite         = "tires:write"
+	StockWrite         = "stock:write"
+	WarehousesWrite    = "warehouses:write"
+	StockCountsApprove = "stock_counts:approve"
+	PurchasingsApprove = "purchasings:approve"
+	DepartmentsAll     = "departments:all"
+	DepartmentsManage  = "departments:manage"
+	APIKeysManage      = "api_keys:manage"
+	APIKeysAdmin       = "api_keys:admin"
+)
+
+// SuperRole holds every permission and cannot be edited through the API.
+const SuperRole = "ADMIN"
+
+// cacheTTL bounds how long another instance keeps serving a role's old
+// grants after they change.
+const cacheTTL = time.Minute
+
+type entry struct {
+	granted  map[string]bool
+	loadedAt time.Time
+}
+
+var (
+	mu    sync.RWMutex
+	cache = map[string]entry{}
+)
+
+// Has reports whether role has been granted permission.
+func Has(ctx context.Context, role, permission string) (bool, error) {
+	if role == SuperRole {
+		return true, nil
+	}
+
+	mu.RLock()
+	cached, ok := cache[role]
+	mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.granted[permission], nil
+	}
+
+	granted, err := load(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return granted[permission], nil
+}
+
+// Granted reports whether the caller holds permission: their role has to
+// have been granted it and, when they use an API key, the key has to be
+// scoped to it.
+func Granted(ctx context.Context, claims *jwt.Claims, permission string) (bool, error) {
+	if claims.APIKeyID != "" && !slices.Contains(claims.Scopes, permission) {
+		return false, nil
+	}
+	return Has(ctx, claims.Role, permission)
+}
+
+func load(ctx context.Context, role string) (map[string]bool, error) {
+	rows, err := database.DB.Query(ctx, "SELECT permission FROM role_permissions WHERE role_oid = $1", role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	granted := map[string]bool{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		granted[permission] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	cache[role] = entry{granted: granted, loadedAt: time.Now()}
+	mu.Unlock()
+	return granted, nil
+}
+
+// Invalidate drops the cached grants of a role after they change.
+func Invalidate(role string) {
+	mu.Lock()
+	delete(cache, role)
+	mu.Unlock()
+}
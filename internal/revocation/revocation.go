@@ -0,0 +1,310 @@
+// Package revocation tracks revoked access tokens. Revocations are written
+// to the revoked_tokens table and mirrored in memory; middleware.Auth only
+// reads the in-memory copy, and a background sync picks up revocations made
+// by other server instances and drops entries whose tokens have expired
+// anyway.
+package revocation
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+)
+
+type userCutoff struct {
+	before  time.Time
+	expires time.Time
+}
+
+var (
+	mu       sync.RWMutex
+	tokens   = map[string]time.Time{}
+	sessions = map[string]time.Time{}
+	users    = map[string]userCutoff{}
+	syncedAt time.Time
+)
+
+// syncOverlap re-reads recent rows on every sync so revocations written by
+// an instance whose clock runs slightly behind are not missed.
+const syncOverlap = time.Minute
+
+// sessionRetention is how long expired and revoked sessions stay listed
+// before Sync deletes them.
+const sessionRetention = 30 * 24 * time.Hour
+
+// Start loads the current revocations and keeps them in sync until ctx is
+// done.
+func Start(ctx context.Context) {
+	if err := Sync(ctx); err != nil {
+		errors.LogError("Token revocation load error", err)
+	} else {
+		log.Println("SUCCESS: Token revocations loaded")
+	}
+
+	interval, err := time.ParseDuration(config.AppConfig.JWT.RevocationSyncInterval)
+	if err != nil || interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := Sync(ctx); err != nil {
+					errors.LogError("Token revocation sync error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Sync pulls revocations stored since the last sync and prunes expired ones,
+// along with sessions that ended more than sessionRetention ago.
+func Sync(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	mu.RLock()
+	since := syncedAt.Add(-syncOverlap)
+	mu.RUnlock()
+
+	_, err := database.DB.Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at < $1", now)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(ctx, `
+		DELETE FROM user_sessions
+		WHERE expires_at < $1 OR revoked_at < $1
+	`, now.Add(-sessionRetention))
+	if err != nil {
+		return err
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT COALESCE(jti, ''), COALESCE(session_id, ''), user_id, revoked_before, expires_at
+		FROM revoked_tokens
+		WHERE created_at >= $1
+	`, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type revocation struct {
+		jti           string
+		sessionId     string
+		userId        string
+		revokedBefore *time.Time
+		expiresAt     time.Time
+	}
+	loaded := []revocation{}
+	for rows.Next() {
+		var r revocation
+		if err := rows.Scan(&r.jti, &r.sessionId, &r.userId, &r.revokedBefore, &r.expiresAt); err != nil {
+			return err
+		}
+		loaded = append(loaded, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, r := range loaded {
+		if r.jti != "" {
+			tokens[r.jti] = r.expiresAt
+		}
+		if r.sessionId != "" {
+			sessions[r.sessionId] = r.expiresAt
+		}
+		if r.revokedBefore != nil {
+			remember(r.userId, *r.revokedBefore, r.expiresAt)
+		}
+	}
+	for jti, expires := range tokens {
+		if expires.Before(now) {
+			delete(tokens, jti)
+		}
+	}
+	for sessionId, expires := range sessions {
+		if expires.Before(now) {
+			delete(sessions, sessionId)
+		}
+	}
+	for userId, cutoff := range users {
+		if cutoff.expires.Before(now) {
+			delete(users, userId)
+		}
+	}
+	syncedAt = now
+	return nil
+}
+
+// remember keeps the latest cutoff of a user. mu must be held.
+func remember(userId string, before, expires time.Time) {
+	if current, ok := users[userId]; ok && current.before.After(before) {
+		return
+	}
+	users[userId] = userCutoff{before: before, expires: expires}
+}
+
+// IsRevoked reports whether the token was revoked on its own or with its
+// session, or issued before its user was logged out everywhere.
+func IsRevoked(claims *jwt.Claims) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if claims.ID != "" {
+		if _, ok := tokens[claims.ID]; ok {
+			return true
+		}
+	}
+	if claims.SessionID != "" {
+		if _, ok := sessions[claims.SessionID]; ok {
+			return true
+		}
+	}
+	if cutoff, ok := users[claims.UserID]; ok {
+		// Token times have second precision, so a token issued in the same
+		// second as the cutoff counts as issued before it.
+		if claims.IssuedAt == nil || !claims.IssuedAt.Time.After(cutoff.before) {
+			return true
+		}
+	}
+	return false
+}
+
+// RevokeToken revokes a single access token until it expires. Tokens
+// issued without a jti can only be revoked along with the rest of the
+// user's tokens.
+func RevokeToken(ctx context.Context, claims *jwt.Claims, reason string) error {
+	if claims.ID == "" {
+		return RevokeUser(ctx, claims.UserID, reason)
+	}
+
+	expiresAt := time.Now().Add(jwt.AccessTokenTTL())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	now := time.Now()
+	_, err := database.DB.Exec(ctx, `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, reason, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (jti) DO NOTHING
+	`, claims.ID, claims.UserID, expiresAt, reason, now)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	tokens[claims.ID] = expiresAt
+	mu.Unlock()
+	return nil
+}
+
+// RevokeSession ends a login session: its access tokens, its refresh tokens
+// and the session record.
+func RevokeSession(ctx context.Context, userId, sessionId, reason string) error {
+	now := time.Now()
+	expiresAt := now.Add(jwt.AccessTokenTTL())
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO revoked_tokens (user_id, session_id, expires_at, reason, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`, userId, sessionId, expiresAt, reason, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $1, updated_at = $1
+		WHERE family_id = $2 AND revoked_at IS NULL
+	`, now, sessionId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = $1, revoked_reason = $2, updated_at = $1
+		WHERE user_sessions_id = $3 AND revoked_at IS NULL
+	`, now, reason, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	sessions[sessionId] = expiresAt
+	mu.Unlock()
+	return nil
+}
+
+// RevokeUser revokes every access token issued to the user so far, every
+// refresh token the user still holds and every open session.
+func RevokeUser(ctx context.Context, userId, reason string) error {
+	now := time.Now()
+	before := now.Truncate(time.Second)
+	expiresAt := now.Add(jwt.AccessTokenTTL())
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO revoked_tokens (user_id, revoked_before, expires_at, reason, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`, userId, before, expiresAt, reason, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $1, updated_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL
+	`, now, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = $1, revoked_reason = $2, updated_at = $1
+		WHERE user_id = $3 AND revoked_at IS NULL
+	`, now, reason, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	remember(userId, before, expiresAt)
+	mu.Unlock()
+	return nil
+}
@@ -3,35 +3,80 @@ package routes
 import (
 	"fleetify/internal/handlers"
 	"fleetify/internal/middleware"
+	"fleetify/internal/permissions"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func SetupRoutes(app *fiber.App) {
+	app.Get("/.well-known/jwks.json", handlers.GetJWKS)
+
 	api := app.Group("/api/v1")
 
 	api.Get("/health", handlers.HealthCheck)
 	api.Get("/health/db", handlers.HealthCheckDB)
 
 	auth := api.Group("/auth")
-	auth.Post("/register", handlers.Register)
-	auth.Post("/login", handlers.Login)
-	auth.Post("/token", middleware.Auth(), handlers.GetToken)
-	auth.Post("/logout", middleware.Auth(), handlers.Logout)
+	auth.Post("/register", middleware.RateLimit("register"), handlers.Register)
+	auth.Post("/login", middleware.RateLimit("login"), handlers.Login)
+	auth.Post("/refresh", middleware.RateLimit("refresh"), handlers.Refresh)
+	auth.Post("/password/forgot", middleware.RateLimit("password_reset"), handlers.ForgotPassword)
+	auth.Post("/password/reset", middleware.RateLimit("password_reset"), handlers.ResetPassword)
+	auth.Put("/password", middleware.AuthAllowingPasswordChange(), middleware.RateLimit("password_change"), handlers.ChangeOwnPassword)
+	auth.Post("/logout", middleware.AuthAllowingRestricted(), handlers.Logout)
+	auth.Post("/logout-all", middleware.AuthAllowingRestricted(), handlers.LogoutAll)
+	auth.Get("/sessions", middleware.Auth(), handlers.GetSessions)
+	auth.Delete("/sessions/:id", middleware.Auth(), handlers.RevokeOwnSession)
+	auth.Post("/login/2fa", middleware.RateLimit("two_factor"), handlers.LoginTwoFactor)
+	auth.Get("/oidc/login", middleware.RateLimit("oidc"), handlers.OIDCLogin)
+	auth.Post("/oidc/callback", middleware.RateLimit("oidc"), handlers.OIDCCallback)
+	auth.Post("/oidc/link", middleware.Auth(), middleware.RateLimit("oidc"), handlers.OIDCLink)
+	auth.Get("/2fa", middleware.AuthAllowingTwoFactorSetup(), handlers.GetTwoFactorStatus)
+	auth.Post("/2fa/setup", middleware.AuthAllowingTwoFactorSetup(), handlers.SetupTwoFactor)
+	auth.Post("/2fa/enable", middleware.AuthAllowingTwoFactorSetup(), middleware.RateLimit("two_factor"), handlers.EnableTwoFactor)
+	auth.Post("/2fa/disable", middleware.Auth(), middleware.RateLimit("two_factor"), handlers.DisableTwoFactor)
+	auth.Post("/2fa/recovery-codes", middleware.Auth(), middleware.RateLimit("two_factor"), handlers.RegenerateRecoveryCodes)
 
-	users := api.Group("/users", middleware.Auth())
+	users := api.Group("/users", middleware.Auth(), middleware.DepartmentScope())
 	users.Get("/", handlers.GetUsers)
 
-	user := api.Group("/user", middleware.Auth())
+	user := api.Group("/user", middleware.Auth(), middleware.DepartmentScope())
 	user.Get("/:uname", handlers.GetUserByUsername)
+	user.Get("/:uname/departments", handlers.GetUserDepartments)
 
-	userAdmin := api.Group("/user", middleware.Auth(), middleware.Admin())
+	userAdmin := api.Group("/user", middleware.Auth(), middleware.RequirePermission(permissions.UsersManage))
 	userAdmin.Put("/:uname", handlers.UpdateUserByUsername)
 	userAdmin.Put("/:uname/password", handlers.ChangePassword)
+	userAdmin.Put("/:uname/departments", handlers.SetUserDepartments)
+	userAdmin.Post("/:uname/unlock", handlers.UnlockUser)
+	userAdmin.Delete("/:uname/2fa", handlers.ResetUserTwoFactor)
+	userAdmin.Get("/:uname/sessions", handlers.GetUserSessions)
+	userAdmin.Delete("/:uname/sessions/:id", handlers.RevokeUserSession)
 	userAdmin.Delete("/:uname", handlers.DeleteUser)
 
-	roles := api.Group("/roles", middleware.Auth(), middleware.Admin())
+	invites := api.Group("/invites", middleware.Auth(), middleware.RequirePermission(permissions.UsersManage))
+	invites.Get("/", handlers.GetInvites)
+	invites.Post("/", handlers.CreateInvite)
+	invites.Delete("/:id", handlers.DeleteInvite)
+
+	apiKeys := api.Group("/api-keys", middleware.Auth(), middleware.RequirePermission(permissions.APIKeysManage))
+	apiKeys.Get("/", handlers.GetApiKeys)
+	apiKeys.Get("/:id", handlers.GetApiKeyById)
+	apiKeys.Post("/", handlers.CreateApiKey)
+	apiKeys.Delete("/:id", handlers.RevokeApiKey)
+
+	departments := api.Group("/departments", middleware.Auth(), middleware.DepartmentScope())
+	departments.Get("/", handlers.GetDepartments)
+	departments.Get("/:id", handlers.GetDepartmentById)
+
+	departmentAdmin := api.Group("/departments", middleware.Auth(), middleware.RequirePermission(permissions.DepartmentsManage))
+	departmentAdmin.Post("/", handlers.CreateDepartment)
+	departmentAdmin.Put("/:id", handlers.UpdateDepartment)
+	departmentAdmin.Delete("/:id", handlers.DeleteDepartment)
+
+	roles := api.Group("/roles", middleware.Auth(), middleware.RequirePermission(permissions.RolesManage))
 	roles.Get("/", handlers.GetRoles)
+	roles.Get("/permissions", handlers.GetPermissions)
 	roles.Get("/:oid", handlers.GetRoleByOID)
 	roles.Post("/", handlers.CreateRole)
 	roles.Put("/:oid", handlers.UpdateRole)
@@ -39,30 +84,139 @@ func SetupRoutes(app *fiber.App) {
 
 	items := api.Group("/items", middleware.Auth())
 	items.Get("/", handlers.GetItems)
+	items.Get("/lookup", handlers.LookupItem)
+	items.Post("/labels", middleware.RequirePermission(permissions.ItemsLabels), handlers.PrintItemLabels)
+	items.Post("/import", middleware.RequirePermission(permissions.ItemsWrite), handlers.ImportItems)
 	items.Get("/:id", handlers.GetItemById)
-	items.Post("/", middleware.ItemModifyAccess(), handlers.CreateItem)
-	items.Put("/:id", middleware.ItemModifyAccess(), handlers.UpdateItem)
-	items.Delete("/:id", middleware.ItemModifyAccess(), handlers.DeleteItem)
+	items.Get("/:id/serials", middleware.DepartmentScope(), handlers.GetItemSerials)
+	items.Get("/:id/barcodes", handlers.GetItemBarcodes)
+	items.Post("/:id/barcodes", middleware.RequirePermission(permissions.ItemsWrite), handlers.CreateItemBarcode)
+	items.Delete("/:id/barcodes/:barcode_id", middleware.RequirePermission(permissions.ItemsWrite), handlers.DeleteItemBarcode)
+	items.Get("/:id/label", handlers.GetItemLabel)
+	items.Get("/:id/price-history", handlers.GetItemPriceHistory)
+	items.Get("/:id/units", handlers.GetItemUnits)
+	items.Put("/:id/units", middleware.RequirePermission(permissions.ItemsWrite), handlers.SetItemUnits)
+	items.Post("/", middleware.RequirePermission(permissions.ItemsWrite), handlers.CreateItem)
+	items.Put("/:id", middleware.RequirePermission(permissions.ItemsWrite), handlers.UpdateItem)
+	items.Delete("/:id", middleware.RequirePermission(permissions.ItemsWrite), handlers.DeleteItem)
+
+	categories := api.Group("/categories", middleware.Auth())
+	categories.Get("/", handlers.GetCategories)
+	categories.Get("/tree", handlers.GetCategoryTree)
+	categories.Get("/:id", handlers.GetCategoryById)
+	categories.Post("/", middleware.RequirePermission(permissions.ItemsWrite), handlers.CreateCategory)
+	categories.Put("/:id", middleware.RequirePermission(permissions.ItemsWrite), handlers.UpdateCategory)
+	categories.Delete("/:id", middleware.RequirePermission(permissions.ItemsWrite), handlers.DeleteCategory)
+
+	units := api.Group("/units", middleware.Auth())
+	units.Get("/", handlers.GetUnits)
+	units.Get("/:id", handlers.GetUnitById)
+	units.Post("/", middleware.RequirePermission(permissions.ItemsWrite), handlers.CreateUnit)
+	units.Put("/:id", middleware.RequirePermission(permissions.ItemsWrite), handlers.UpdateUnit)
+	units.Delete("/:id", middleware.RequirePermission(permissions.ItemsWrite), handlers.DeleteUnit)
 
 	suppliers := api.Group("/suppliers", middleware.Auth())
 	suppliers.Get("/", handlers.GetSuppliers)
+	suppliers.Post("/import", middleware.RequirePermission(permissions.SuppliersWrite), handlers.ImportSuppliers)
 	suppliers.Get("/:id", handlers.GetSupplierById)
-	suppliers.Post("/", handlers.CreateSupplier)
-	suppliers.Put("/:id", handlers.UpdateSupplier)
-	suppliers.Delete("/:id", handlers.DeleteSupplier)
+	suppliers.Post("/", middleware.RequirePermission(permissions.SuppliersWrite), handlers.CreateSupplier)
+	suppliers.Put("/:id", middleware.RequirePermission(permissions.SuppliersWrite), handlers.UpdateSupplier)
+	suppliers.Delete("/:id", middleware.RequirePermission(permissions.SuppliersWrite), handlers.DeleteSupplier)
 
-	purchasings := api.Group("/purchasings", middleware.Auth())
+	purchasings := api.Group("/purchasings", middleware.Auth(), middleware.DepartmentScope())
 	purchasings.Get("/", handlers.GetPurchasings)
 	purchasings.Get("/:id", handlers.GetPurchasingById)
-	purchasings.Post("/", handlers.CreatePurchasing)
-	purchasings.Put("/:id", handlers.UpdatePurchasing)
-	purchasings.Delete("/:id", handlers.DeletePurchasing)
+	purchasings.Post("/", middleware.RequirePermission(permissions.PurchasingsWrite), handlers.CreatePurchasing)
+	purchasings.Put("/:id", middleware.RequirePermission(permissions.PurchasingsWrite), handlers.UpdatePurchasing)
+	purchasings.Delete("/:id", middleware.RequirePermission(permissions.PurchasingsWrite), handlers.DeletePurchasing)
 
-	purchasingDetails := api.Group("/purchasing-details", middleware.Auth())
+	purchasingDetails := api.Group("/purchasing-details", middleware.Auth(), middleware.DepartmentScope())
 	purchasingDetails.Get("/", handlers.GetPurchasingDetails)
 	purchasingDetails.Get("/purchasing/:purchasing_id", handlers.GetPurchasingDetailsByPurchasingId)
 	purchasingDetails.Get("/:id", handlers.GetPurchasingDetailById)
-	purchasingDetails.Post("/", handlers.CreatePurchasingDetail)
-	purchasingDetails.Put("/:id", handlers.UpdatePurchasingDetail)
-	purchasingDetails.Delete("/:id", handlers.DeletePurchasingDetail)
+	purchasingDetails.Post("/", middleware.RequirePermission(permissions.PurchasingsWrite), handlers.CreatePurchasingDetail)
+	purchasingDetails.Put("/:id", middleware.RequirePermission(permissions.PurchasingsWrite), handlers.UpdatePurchasingDetail)
+	purchasingDetails.Delete("/:id", middleware.RequirePermission(permissions.PurchasingsWrite), handlers.DeletePurchasingDetail)
+
+	vehicles := api.Group("/vehicles", middleware.Auth())
+	vehicles.Get("/", handlers.GetVehicles)
+	vehicles.Get("/:id", handlers.GetVehicleById)
+	vehicles.Get("/:id/documents", handlers.GetVehicleDocumentsByVehicleId)
+	vehicles.Post("/", middleware.RequirePermission(permissions.VehiclesWrite), handlers.CreateVehicle)
+	vehicles.Put("/:id", middleware.RequirePermission(permissions.VehiclesWrite), handlers.UpdateVehicle)
+	vehicles.Delete("/:id", middleware.RequirePermission(permissions.VehiclesWrite), handlers.DeleteVehicle)
+
+	vehicleDocuments := api.Group("/vehicle-documents", middleware.Auth())
+	vehicleDocuments.Get("/", handlers.GetVehicleDocuments)
+	vehicleDocuments.Get("/:id", handlers.GetVehicleDocumentById)
+	vehicleDocuments.Get("/:id/scan", handlers.GetVehicleDocumentScan)
+	vehicleDocuments.Post("/", middleware.RequirePermission(permissions.VehiclesWrite), handlers.CreateVehicleDocument)
+	vehicleDocuments.Post("/:id/scan", middleware.RequirePermission(permissions.VehiclesWrite), handlers.UploadVehicleDocumentScan)
+	vehicleDocuments.Put("/:id", middleware.RequirePermission(permissions.VehiclesWrite), handlers.UpdateVehicleDocument)
+	vehicleDocuments.Delete("/:id", middleware.RequirePermission(permissions.VehiclesWrite), handlers.DeleteVehicleDocument)
+
+	compliance := api.Group("/compliance", middleware.Auth())
+	compliance.Get("/expiring", handlers.GetExpiringDocuments)
+
+	trips := api.Group("/trips", middleware.Auth())
+	trips.Get("/", handlers.GetTrips)
+	trips.Get("/:id", handlers.GetTripById)
+	trips.Post("/", middleware.RequirePermission(permissions.TripsWrite), handlers.CreateTrip)
+	trips.Put("/:id", middleware.RequirePermission(permissions.TripsWrite), handlers.UpdateTrip)
+	trips.Delete("/:id", middleware.RequirePermission(permissions.TripsWrite), handlers.DeleteTrip)
+
+	tires := api.Group("/tires", middleware.Auth(), middleware.DepartmentScope())
+	tires.Get("/", handlers.GetTires)
+	tires.Post("/receive", middleware.RequirePermission(permissions.TiresWrite), handlers.ReceiveTires)
+	tires.Get("/:id", handlers.GetTireById)
+	tires.Post("/:id/mount", middleware.RequirePermission(permissions.TiresWrite), handlers.MountTire)
+	tires.Post("/:id/dismount", middleware.RequirePermission(permissions.TiresWrite), handlers.DismountTire)
+	tires.Post("/:id/rotate", middleware.RequirePermission(permissions.TiresWrite), handlers.RotateTire)
+	tires.Post("/:id/retread", middleware.RequirePermission(permissions.TiresWrite), handlers.RetreadTire)
+	tires.Post("/:id/inspect", middleware.RequirePermission(permissions.TiresWrite), handlers.InspectTire)
+	tires.Post("/:id/scrap", middleware.RequirePermission(permissions.TiresWrite), handlers.ScrapTire)
+
+	goodsReceipts := api.Group("/goods-receipts", middleware.Auth(), middleware.DepartmentScope())
+	goodsReceipts.Get("/", handlers.GetGoodsReceipts)
+	goodsReceipts.Get("/:id", handlers.GetGoodsReceiptById)
+	goodsReceipts.Post("/", middleware.RequirePermission(permissions.StockWrite), handlers.CreateGoodsReceipt)
+
+	stockIssues := api.Group("/stock-issues", middleware.Auth())
+	stockIssues.Get("/", handlers.GetStockIssues)
+	stockIssues.Get("/:id", handlers.GetStockIssueById)
+	stockIssues.Post("/", middleware.RequirePermission(permissions.StockWrite), handlers.CreateStockIssue)
+
+	warehouses := api.Group("/warehouses", middleware.Auth())
+	warehouses.Get("/", handlers.GetWarehouses)
+	warehouses.Get("/:id", handlers.GetWarehouseById)
+	warehouses.Get("/:id/stock", handlers.GetWarehouseStock)
+	warehouses.Post("/", middleware.RequirePermission(permissions.WarehousesWrite), handlers.CreateWarehouse)
+	warehouses.Put("/:id", middleware.RequirePermission(permissions.WarehousesWrite), handlers.UpdateWarehouse)
+	warehouses.Delete("/:id", middleware.RequirePermission(permissions.WarehousesWrite), handlers.DeleteWarehouse)
+
+	stockTransfers := api.Group("/stock-transfers", middleware.Auth())
+	stockTransfers.Get("/", handlers.GetStockTransfers)
+	stockTransfers.Get("/:id", handlers.GetStockTransferById)
+	stockTransfers.Post("/", middleware.RequirePermission(permissions.StockWrite), handlers.CreateStockTransfer)
+	stockTransfers.Post("/:id/receive", middleware.RequirePermission(permissions.StockWrite), handlers.ReceiveStockTransfer)
+	stockTransfers.Post("/:id/cancel", middleware.RequirePermission(permissions.StockWrite), handlers.CancelStockTransfer)
+
+	stockCounts := api.Group("/stock-counts", middleware.Auth())
+	stockCounts.Get("/", handlers.GetStockCounts)
+	stockCounts.Get("/:id", handlers.GetStockCountById)
+	stockCounts.Post("/", middleware.RequirePermission(permissions.StockWrite), handlers.CreateStockCount)
+	stockCounts.Put("/:id/counts", middleware.RequirePermission(permissions.StockWrite), handlers.RecordStockCounts)
+	stockCounts.Post("/:id/submit", middleware.RequirePermission(permissions.StockWrite), handlers.SubmitStockCount)
+	stockCounts.Post("/:id/approve", middleware.RequirePermission(permissions.StockCountsApprove), handlers.ApproveStockCount)
+	stockCounts.Post("/:id/reject", middleware.RequirePermission(permissions.StockCountsApprove), handlers.RejectStockCount)
+	stockCounts.Post("/:id/cancel", middleware.RequirePermission(permissions.StockCountsApprove), handlers.CancelStockCount)
+
+	traceability := api.Group("/traceability", middleware.Auth(), middleware.DepartmentScope())
+	traceability.Get("/serials/:serial", handlers.TraceSerial)
+	traceability.Get("/lots/:lot", handlers.TraceLot)
+
+	reports := api.Group("/reports", middleware.Auth())
+	reports.Get("/tire-cost-per-km", middleware.DepartmentScope(), handlers.GetTireCostPerKmReport)
+	reports.Get("/inventory-valuation", handlers.GetInventoryValuationReport)
+	reports.Get("/price-changes", handlers.GetPriceChangeReport)
 }
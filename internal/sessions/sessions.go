@@ -0,0 +1,50 @@
+// Package sessions records when and from where each login session was last
+// used. Sessions are created with the first token of a login and revoked
+// through the revocation package; this package only keeps last_seen_at
+// current without writing on every request.
+package sessions
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"fleetify/internal/database"
+)
+
+// touchInterval limits how often a busy session's last use is written.
+const touchInterval = time.Minute
+
+var (
+	mu       sync.Mutex
+	touched  = map[string]time.Time{}
+	prunedAt time.Time
+)
+
+// Touch records a request made with a token of the session from ip. Writes
+// for the same session are at least touchInterval apart on each instance.
+func Touch(ctx context.Context, sessionId, ip string) error {
+	now := time.Now()
+
+	mu.Lock()
+	if last, ok := touched[sessionId]; ok && now.Sub(last) < touchInterval {
+		mu.Unlock()
+		return nil
+	}
+	touched[sessionId] = now
+	if now.Sub(prunedAt) >= touchInterval {
+		for id, last := range touched {
+			if now.Sub(last) >= touchInterval {
+				delete(touched, id)
+			}
+		}
+		prunedAt = now
+	}
+	mu.Unlock()
+
+	_, err := database.DB.Exec(ctx, `
+		UPDATE user_sessions SET last_seen_at = $1, last_seen_ip = $2
+		WHERE user_sessions_id = $3 AND revoked_at IS NULL
+	`, now, ip, sessionId)
+	return err
+}
@@ -0,0 +1,195 @@
+// Package signingkeys keeps the asymmetric keys access tokens are signed with
+// in the signing_keys table, so every instance signs and verifies with the
+// same set, and rotates them. A new key is published a little before it
+// starts signing, giving other instances and JWKS consumers time to learn
+// it, and a replaced key keeps verifying for the grace period so tokens it
+// signed stay valid until they expire.
+package signingkeys
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fleetify/internal/config"
+	"fleetify/internal/database"
+	"fleetify/pkg/errors"
+	"fleetify/pkg/jwt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// syncInterval is how often each instance reloads the key set and checks
+// whether a rotation is due.
+const syncInterval = time.Minute
+
+// activationDelay lets every instance load a rotated key before any of them
+// signs with it.
+const activationDelay = 2 * syncInterval
+
+// Start loads the key set, creating the first key if there is none, and
+// keeps it in sync and rotated until ctx is done. It does nothing when
+// tokens are signed with the HS256 secret.
+func Start(ctx context.Context) error {
+	if config.AppConfig.JWT.Algorithm == jwt.HS256 {
+		return nil
+	}
+
+	if err := Sync(ctx); err != nil {
+		return err
+	}
+	log.Println("SUCCESS: Token signing keys loaded")
+
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := Sync(ctx); err != nil {
+					errors.LogError("Signing key sync error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func settings() (algorithm string, interval, grace time.Duration) {
+	cfg := config.AppConfig.JWT
+
+	interval, err := time.ParseDuration(cfg.KeyRotationInterval)
+	if err != nil || interval <= 0 {
+		interval = 30 * 24 * time.Hour
+	}
+
+	grace, err = time.ParseDuration(cfg.KeyGracePeriod)
+	if err != nil || grace < 0 {
+		grace = 24 * time.Hour
+	}
+	// A replaced key has to outlive every token it signed.
+	if ttl := jwt.AccessTokenTTL(); grace < ttl {
+		grace = ttl
+	}
+	return cfg.Algorithm, interval, grace
+}
+
+// Sync rotates the keys when due and loads the current set.
+func Sync(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := rotate(ctx); err != nil {
+		return err
+	}
+	return load(ctx)
+}
+
+// rotate adds a key when there is none, the newest is older than the
+// rotation interval or the algorithm changed, and drops keys that were
+// replaced longer than the grace period ago.
+func rotate(ctx context.Context) error {
+	algorithm, interval, grace := settings()
+	now := time.Now()
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Instances starting together must not each add a key.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('signing_keys'))"); err != nil {
+		return err
+	}
+
+	var newestAlgorithm string
+	var newestCreatedAt time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT algorithm, created_at FROM signing_keys
+		ORDER BY activates_at DESC
+		LIMIT 1
+	`).Scan(&newestAlgorithm, &newestCreatedAt)
+
+	switch {
+	case err == pgx.ErrNoRows:
+		// Nothing signs yet, so the first key is used right away.
+		if err := addKey(ctx, tx, algorithm, now, now); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case newestAlgorithm != algorithm || !newestCreatedAt.After(now.Add(-interval)):
+		if err := addKey(ctx, tx, algorithm, now.Add(activationDelay), now); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM signing_keys k
+		WHERE EXISTS (
+			SELECT 1 FROM signing_keys n
+			WHERE n.activates_at > k.activates_at AND n.activates_at < $1
+		)
+	`, now.Add(-grace))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func addKey(ctx context.Context, tx pgx.Tx, algorithm string, activatesAt, now time.Time) error {
+	key, err := jwt.GenerateKey(algorithm)
+	if err != nil {
+		return err
+	}
+	encoded, err := jwt.EncodePrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	kid := uuid.New().String()
+	_, err = tx.Exec(ctx, `
+		INSERT INTO signing_keys (kid, algorithm, private_key, activates_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`, kid, algorithm, encoded, activatesAt, now)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Signing key %s (%s) added, signing from %s", kid, algorithm, activatesAt.Format(time.RFC3339))
+	return nil
+}
+
+func load(ctx context.Context) error {
+	rows, err := database.DB.Query(ctx, "SELECT kid, algorithm, private_key, activates_at FROM signing_keys")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	set := []jwt.SigningKey{}
+	for rows.Next() {
+		var key jwt.SigningKey
+		var encoded string
+		if err := rows.Scan(&key.ID, &key.Algorithm, &encoded, &key.ActivatesAt); err != nil {
+			return err
+		}
+		key.PrivateKey, err = jwt.DecodePrivateKey(encoded)
+		if err != nil {
+			errors.LogErrorf("Signing key decode error", "key %s: %v", key.ID, err)
+			continue
+		}
+		set = append(set, key)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	jwt.SetKeys(set)
+	return nil
+}
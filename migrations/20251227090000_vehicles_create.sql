@@ -0,0 +1,30 @@
+-- Migration: Create table vehicles
+-- Generated at: 2025-12-27T09:00:00+07:00
+-- Generated from model: internal/models/vehicles.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS vehicles (
+	vehicles_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	plate_number TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	brand TEXT,
+	model TEXT,
+	year INTEGER,
+	vehicle_type TEXT,
+	odometer INTEGER,
+	is_active BOOLEAN,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Add table and column comments
+COMMENT ON TABLE vehicles IS 'Table for vehicles';
+COMMENT ON COLUMN vehicles.vehicles_id IS 'Primary key UUID';
+COMMENT ON COLUMN vehicles.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN vehicles.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS vehicles;
@@ -0,0 +1,38 @@
+-- Migration: Create table vehicle_documents
+-- Generated at: 2025-12-27T09:01:00+07:00
+-- Generated from model: internal/models/vehicle_documents.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS vehicle_documents (
+	vehicle_documents_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	vehicle_id UUID NOT NULL,
+	document_type TEXT NOT NULL,
+	document_number TEXT NOT NULL,
+	issue_date TIMESTAMPTZ NOT NULL,
+	expiry_date TIMESTAMPTZ NOT NULL,
+	scan_path TEXT,
+	notes TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE vehicle_documents
+ADD CONSTRAINT fk_vehicle_documents_vehicle
+FOREIGN KEY (vehicle_id) REFERENCES vehicles(vehicles_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_documents_vehicle_id ON vehicle_documents(vehicle_id);
+CREATE INDEX IF NOT EXISTS idx_vehicle_documents_expiry_date ON vehicle_documents(expiry_date);
+
+-- Add table and column comments
+COMMENT ON TABLE vehicle_documents IS 'Table for vehicle_documents';
+COMMENT ON COLUMN vehicle_documents.vehicle_documents_id IS 'Primary key UUID';
+COMMENT ON COLUMN vehicle_documents.document_type IS 'STNK, KIR or INSURANCE';
+COMMENT ON COLUMN vehicle_documents.scan_path IS 'Path of the uploaded document scan';
+COMMENT ON COLUMN vehicle_documents.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN vehicle_documents.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS vehicle_documents;
@@ -0,0 +1,38 @@
+-- Migration: Create table trips
+-- Generated at: 2025-12-27T09:02:00+07:00
+-- Generated from model: internal/models/trips.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS trips (
+	trips_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	vehicle_id UUID NOT NULL,
+	driver_id UUID NOT NULL,
+	origin TEXT NOT NULL,
+	destination TEXT NOT NULL,
+	departure_at TIMESTAMPTZ NOT NULL,
+	arrival_at TIMESTAMPTZ,
+	status TEXT,
+	notes TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE trips
+ADD CONSTRAINT fk_trips_vehicle
+FOREIGN KEY (vehicle_id) REFERENCES vehicles(vehicles_id);
+
+ALTER TABLE trips
+ADD CONSTRAINT fk_trips_driver
+FOREIGN KEY (driver_id) REFERENCES users(users_id);
+
+-- Add table and column comments
+COMMENT ON TABLE trips IS 'Table for trips';
+COMMENT ON COLUMN trips.trips_id IS 'Primary key UUID';
+COMMENT ON COLUMN trips.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN trips.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS trips;
@@ -0,0 +1,57 @@
+-- Migration: Create table tires
+-- Generated at: 2025-12-27T10:00:00+07:00
+-- Generated from model: internal/models/tires.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS tires (
+	tires_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	serial_number TEXT NOT NULL UNIQUE,
+	item_id UUID NOT NULL,
+	purchasing_id UUID NOT NULL,
+	supplier_id UUID NOT NULL,
+	brand TEXT NOT NULL,
+	purchase_cost NUMERIC(10, 2) NOT NULL,
+	total_cost NUMERIC(10, 2) NOT NULL,
+	status TEXT NOT NULL,
+	vehicle_id UUID,
+	position TEXT,
+	mount_odometer INTEGER,
+	total_km INTEGER,
+	tread_depth NUMERIC(10, 2),
+	retread_count INTEGER,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE tires
+ADD CONSTRAINT fk_tires_item
+FOREIGN KEY (item_id) REFERENCES items(items_id);
+
+ALTER TABLE tires
+ADD CONSTRAINT fk_tires_purchasing
+FOREIGN KEY (purchasing_id) REFERENCES purchasings(purchasings_id);
+
+ALTER TABLE tires
+ADD CONSTRAINT fk_tires_supplier
+FOREIGN KEY (supplier_id) REFERENCES suppliers(suppliers_id);
+
+ALTER TABLE tires
+ADD CONSTRAINT fk_tires_vehicle
+FOREIGN KEY (vehicle_id) REFERENCES vehicles(vehicles_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tires_vehicle_position ON tires(vehicle_id, position) WHERE status = 'mounted';
+
+-- Add table and column comments
+COMMENT ON TABLE tires IS 'Table for tires';
+COMMENT ON COLUMN tires.tires_id IS 'Primary key UUID';
+COMMENT ON COLUMN tires.status IS 'in_stock, mounted or scrapped';
+COMMENT ON COLUMN tires.mount_odometer IS 'Vehicle odometer when the tire was mounted';
+COMMENT ON COLUMN tires.total_km IS 'Kilometers accumulated on completed mountings';
+COMMENT ON COLUMN tires.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN tires.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS tires;
@@ -0,0 +1,45 @@
+-- Migration: Create table tire_events
+-- Generated at: 2025-12-27T10:01:00+07:00
+-- Generated from model: internal/models/tire_events.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS tire_events (
+	tire_events_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	tire_id UUID NOT NULL,
+	event_type TEXT NOT NULL,
+	vehicle_id UUID,
+	position TEXT,
+	odometer INTEGER,
+	tread_depth NUMERIC(10, 2),
+	cost NUMERIC(10, 2),
+	notes TEXT,
+	user_id UUID NOT NULL,
+	event_date TIMESTAMPTZ NOT NULL,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE tire_events
+ADD CONSTRAINT fk_tire_events_tire
+FOREIGN KEY (tire_id) REFERENCES tires(tires_id) ON DELETE CASCADE;
+
+ALTER TABLE tire_events
+ADD CONSTRAINT fk_tire_events_vehicle
+FOREIGN KEY (vehicle_id) REFERENCES vehicles(vehicles_id);
+
+ALTER TABLE tire_events
+ADD CONSTRAINT fk_tire_events_user
+FOREIGN KEY (user_id) REFERENCES users(users_id);
+
+CREATE INDEX IF NOT EXISTS idx_tire_events_tire_id ON tire_events(tire_id);
+
+-- Add table and column comments
+COMMENT ON TABLE tire_events IS 'Table for tire_events';
+COMMENT ON COLUMN tire_events.tire_events_id IS 'Primary key UUID';
+COMMENT ON COLUMN tire_events.event_type IS 'receive, mount, dismount, rotate, retread, inspect or scrap';
+COMMENT ON COLUMN tire_events.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN tire_events.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS tire_events;
@@ -0,0 +1,14 @@
+-- Migration: Alter table items
+-- Generated at: 2025-12-27T11:00:00+07:00
+-- Generated from model: internal/models/items.go
+
+	ALTER TABLE items ADD COLUMN IF NOT EXISTS track_serial BOOLEAN NOT NULL DEFAULT false;
+	ALTER TABLE items ADD COLUMN IF NOT EXISTS track_lot BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN items.track_serial IS 'Require serial numbers on goods receipt and issue';
+COMMENT ON COLUMN items.track_lot IS 'Require a lot number on goods receipt and issue';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE items DROP COLUMN IF EXISTS track_serial;
+-- ALTER TABLE items DROP COLUMN IF EXISTS track_lot;
@@ -0,0 +1,36 @@
+-- Migration: Create table goods_receipts
+-- Generated at: 2025-12-27T11:01:00+07:00
+-- Generated from model: internal/models/goods_receipts.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS goods_receipts (
+	goods_receipts_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	purchasing_id UUID NOT NULL,
+	date TIMESTAMPTZ NOT NULL,
+	user_id UUID NOT NULL,
+	notes TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE goods_receipts
+ADD CONSTRAINT fk_goods_receipts_purchasing
+FOREIGN KEY (purchasing_id) REFERENCES purchasings(purchasings_id);
+
+ALTER TABLE goods_receipts
+ADD CONSTRAINT fk_goods_receipts_user
+FOREIGN KEY (user_id) REFERENCES users(users_id);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_purchasing_id ON goods_receipts(purchasing_id);
+
+-- Add table and column comments
+COMMENT ON TABLE goods_receipts IS 'Table for goods_receipts';
+COMMENT ON COLUMN goods_receipts.goods_receipts_id IS 'Primary key UUID';
+COMMENT ON COLUMN goods_receipts.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN goods_receipts.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS goods_receipts;
@@ -0,0 +1,36 @@
+-- Migration: Create table goods_receipt_details
+-- Generated at: 2025-12-27T11:02:00+07:00
+-- Generated from model: internal/models/goods_receipt_details.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS goods_receipt_details (
+	goods_receipt_details_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	goods_receipt_id UUID NOT NULL,
+	item_id UUID NOT NULL,
+	qty INTEGER NOT NULL,
+	unit_cost NUMERIC(10, 2) NOT NULL,
+	lot_number TEXT,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE goods_receipt_details
+ADD CONSTRAINT fk_goods_receipt_details_goods_receipt
+FOREIGN KEY (goods_receipt_id) REFERENCES goods_receipts(goods_receipts_id) ON DELETE CASCADE;
+
+ALTER TABLE goods_receipt_details
+ADD CONSTRAINT fk_goods_receipt_details_item
+FOREIGN KEY (item_id) REFERENCES items(items_id);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_details_item_lot ON goods_receipt_details(item_id, lot_number);
+
+-- Add table and column comments
+COMMENT ON TABLE goods_receipt_details IS 'Table for goods_receipt_details';
+COMMENT ON COLUMN goods_receipt_details.goods_receipt_details_id IS 'Primary key UUID';
+COMMENT ON COLUMN goods_receipt_details.unit_cost IS 'Unit cost taken from the purchasing line';
+COMMENT ON COLUMN goods_receipt_details.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN goods_receipt_details.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS goods_receipt_details;
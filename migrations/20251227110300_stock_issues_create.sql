@@ -0,0 +1,35 @@
+-- Migration: Create table stock_issues
+-- Generated at: 2025-12-27T11:03:00+07:00
+-- Generated from model: internal/models/stock_issues.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS stock_issues (
+	stock_issues_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	vehicle_id UUID,
+	date TIMESTAMPTZ NOT NULL,
+	user_id UUID NOT NULL,
+	notes TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE stock_issues
+ADD CONSTRAINT fk_stock_issues_vehicle
+FOREIGN KEY (vehicle_id) REFERENCES vehicles(vehicles_id);
+
+ALTER TABLE stock_issues
+ADD CONSTRAINT fk_stock_issues_user
+FOREIGN KEY (user_id) REFERENCES users(users_id);
+
+-- Add table and column comments
+COMMENT ON TABLE stock_issues IS 'Table for stock_issues';
+COMMENT ON COLUMN stock_issues.stock_issues_id IS 'Primary key UUID';
+COMMENT ON COLUMN stock_issues.vehicle_id IS 'Vehicle the items were installed on, if any';
+COMMENT ON COLUMN stock_issues.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN stock_issues.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS stock_issues;
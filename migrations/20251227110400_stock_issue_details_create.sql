@@ -0,0 +1,34 @@
+-- Migration: Create table stock_issue_details
+-- Generated at: 2025-12-27T11:04:00+07:00
+-- Generated from model: internal/models/stock_issue_details.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS stock_issue_details (
+	stock_issue_details_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	stock_issue_id UUID NOT NULL,
+	item_id UUID NOT NULL,
+	qty INTEGER NOT NULL,
+	lot_number TEXT,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE stock_issue_details
+ADD CONSTRAINT fk_stock_issue_details_stock_issue
+FOREIGN KEY (stock_issue_id) REFERENCES stock_issues(stock_issues_id) ON DELETE CASCADE;
+
+ALTER TABLE stock_issue_details
+ADD CONSTRAINT fk_stock_issue_details_item
+FOREIGN KEY (item_id) REFERENCES items(items_id);
+
+CREATE INDEX IF NOT EXISTS idx_stock_issue_details_item_lot ON stock_issue_details(item_id, lot_number);
+
+-- Add table and column comments
+COMMENT ON TABLE stock_issue_details IS 'Table for stock_issue_details';
+COMMENT ON COLUMN stock_issue_details.stock_issue_details_id IS 'Primary key UUID';
+COMMENT ON COLUMN stock_issue_details.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN stock_issue_details.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS stock_issue_details;
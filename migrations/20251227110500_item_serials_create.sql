@@ -0,0 +1,46 @@
+-- Migration: Create table item_serials
+-- Generated at: 2025-12-27T11:05:00+07:00
+-- Generated from model: internal/models/item_serials.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS item_serials (
+	item_serials_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	item_id UUID NOT NULL,
+	serial_number TEXT NOT NULL,
+	lot_number TEXT,
+	status TEXT NOT NULL,
+	goods_receipt_id UUID NOT NULL,
+	stock_issue_id UUID,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE item_serials
+ADD CONSTRAINT fk_item_serials_item
+FOREIGN KEY (item_id) REFERENCES items(items_id);
+
+ALTER TABLE item_serials
+ADD CONSTRAINT fk_item_serials_goods_receipt
+FOREIGN KEY (goods_receipt_id) REFERENCES goods_receipts(goods_receipts_id);
+
+ALTER TABLE item_serials
+ADD CONSTRAINT fk_item_serials_stock_issue
+FOREIGN KEY (stock_issue_id) REFERENCES stock_issues(stock_issues_id);
+
+ALTER TABLE item_serials
+ADD CONSTRAINT unique_item_serials_item_serial UNIQUE (item_id, serial_number);
+
+CREATE INDEX IF NOT EXISTS idx_item_serials_serial_number ON item_serials(serial_number);
+
+-- Add table and column comments
+COMMENT ON TABLE item_serials IS 'Table for item_serials';
+COMMENT ON COLUMN item_serials.item_serials_id IS 'Primary key UUID';
+COMMENT ON COLUMN item_serials.status IS 'in_stock or issued';
+COMMENT ON COLUMN item_serials.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN item_serials.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS item_serials;
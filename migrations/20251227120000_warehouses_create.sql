@@ -0,0 +1,35 @@
+-- Migration: Create table warehouses
+-- Generated at: 2025-12-27T12:00:00+07:00
+-- Generated from model: internal/models/warehouses.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS warehouses (
+	warehouses_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	code TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	address TEXT,
+	is_default BOOLEAN NOT NULL DEFAULT false,
+	is_active BOOLEAN NOT NULL DEFAULT true,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default ON warehouses(is_default) WHERE is_default;
+
+-- The default warehouse holds all stock recorded before locations existed
+INSERT INTO warehouses (code, name, address, is_default, is_active, created_at, updated_at)
+VALUES ('MAIN', 'Central Warehouse', 'Jakarta', true, true, NOW(), NOW())
+ON CONFLICT DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE warehouses IS 'Table for warehouses';
+COMMENT ON COLUMN warehouses.warehouses_id IS 'Primary key UUID';
+COMMENT ON COLUMN warehouses.is_default IS 'Receives stock when a document names no warehouse';
+COMMENT ON COLUMN warehouses.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN warehouses.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS warehouses;
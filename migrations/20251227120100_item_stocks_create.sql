@@ -0,0 +1,45 @@
+-- Migration: Create table item_stocks
+-- Generated at: 2025-12-27T12:01:00+07:00
+-- Generated from model: internal/models/item_stocks.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS item_stocks (
+	item_stocks_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	item_id UUID NOT NULL,
+	warehouse_id UUID NOT NULL,
+	qty INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE item_stocks
+ADD CONSTRAINT fk_item_stocks_item
+FOREIGN KEY (item_id) REFERENCES items(items_id) ON DELETE CASCADE;
+
+ALTER TABLE item_stocks
+ADD CONSTRAINT fk_item_stocks_warehouse
+FOREIGN KEY (warehouse_id) REFERENCES warehouses(warehouses_id);
+
+ALTER TABLE item_stocks
+ADD CONSTRAINT unique_item_stocks_item_warehouse UNIQUE (item_id, warehouse_id);
+
+-- Move existing single-number stock into the default warehouse
+INSERT INTO item_stocks (item_id, warehouse_id, qty, created_at, updated_at)
+SELECT i.items_id, w.warehouses_id, i.stock, NOW(), NOW()
+FROM items i
+JOIN warehouses w ON w.is_default
+WHERE COALESCE(i.stock, 0) <> 0
+ON CONFLICT DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE item_stocks IS 'Table for item_stocks';
+COMMENT ON COLUMN item_stocks.item_stocks_id IS 'Primary key UUID';
+COMMENT ON COLUMN item_stocks.qty IS 'On-hand quantity of the item at the warehouse';
+COMMENT ON COLUMN item_stocks.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN item_stocks.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS item_stocks;
@@ -0,0 +1,50 @@
+-- Migration: Create table stock_transfers
+-- Generated at: 2025-12-27T12:02:00+07:00
+-- Generated from model: internal/models/stock_transfers.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS stock_transfers (
+	stock_transfers_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	from_warehouse_id UUID NOT NULL,
+	to_warehouse_id UUID NOT NULL,
+	status TEXT NOT NULL,
+	shipped_at TIMESTAMPTZ NOT NULL,
+	received_at TIMESTAMPTZ,
+	user_id UUID NOT NULL,
+	received_by UUID,
+	notes TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE stock_transfers
+ADD CONSTRAINT fk_stock_transfers_from_warehouse
+FOREIGN KEY (from_warehouse_id) REFERENCES warehouses(warehouses_id);
+
+ALTER TABLE stock_transfers
+ADD CONSTRAINT fk_stock_transfers_to_warehouse
+FOREIGN KEY (to_warehouse_id) REFERENCES warehouses(warehouses_id);
+
+ALTER TABLE stock_transfers
+ADD CONSTRAINT fk_stock_transfers_user
+FOREIGN KEY (user_id) REFERENCES users(users_id);
+
+ALTER TABLE stock_transfers
+ADD CONSTRAINT fk_stock_transfers_received_by
+FOREIGN KEY (received_by) REFERENCES users(users_id);
+
+ALTER TABLE stock_transfers
+ADD CONSTRAINT check_stock_transfers_warehouses CHECK (from_warehouse_id <> to_warehouse_id);
+
+-- Add table and column comments
+COMMENT ON TABLE stock_transfers IS 'Table for stock_transfers';
+COMMENT ON COLUMN stock_transfers.stock_transfers_id IS 'Primary key UUID';
+COMMENT ON COLUMN stock_transfers.status IS 'in_transit, received or cancelled';
+COMMENT ON COLUMN stock_transfers.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN stock_transfers.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS stock_transfers;
@@ -0,0 +1,33 @@
+-- Migration: Create table stock_transfer_details
+-- Generated at: 2025-12-27T12:03:00+07:00
+-- Generated from model: internal/models/stock_transfer_details.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS stock_transfer_details (
+	stock_transfer_details_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	stock_transfer_id UUID NOT NULL,
+	item_id UUID NOT NULL,
+	qty INTEGER NOT NULL,
+	lot_number TEXT,
+	serial_numbers TEXT[] NOT NULL DEFAULT '{}',
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE stock_transfer_details
+ADD CONSTRAINT fk_stock_transfer_details_stock_transfer
+FOREIGN KEY (stock_transfer_id) REFERENCES stock_transfers(stock_transfers_id) ON DELETE CASCADE;
+
+ALTER TABLE stock_transfer_details
+ADD CONSTRAINT fk_stock_transfer_details_item
+FOREIGN KEY (item_id) REFERENCES items(items_id);
+
+-- Add table and column comments
+COMMENT ON TABLE stock_transfer_details IS 'Table for stock_transfer_details';
+COMMENT ON COLUMN stock_transfer_details.stock_transfer_details_id IS 'Primary key UUID';
+COMMENT ON COLUMN stock_transfer_details.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN stock_transfer_details.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS stock_transfer_details;
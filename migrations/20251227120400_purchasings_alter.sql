@@ -0,0 +1,13 @@
+-- Migration: Alter table purchasings
+-- Generated at: 2025-12-27T12:04:00+07:00
+-- Generated from model: internal/models/purchasings.go
+
+	ALTER TABLE purchasings ADD COLUMN IF NOT EXISTS warehouse_id UUID;
+	UPDATE purchasings SET warehouse_id = (SELECT warehouses_id FROM warehouses WHERE is_default) WHERE warehouse_id IS NULL;
+	ALTER TABLE purchasings ALTER COLUMN warehouse_id SET NOT NULL;
+	ALTER TABLE purchasings ADD CONSTRAINT fk_purchasings_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses(warehouses_id);
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE purchasings DROP CONSTRAINT IF EXISTS fk_purchasings_warehouse;
+-- ALTER TABLE purchasings DROP COLUMN IF EXISTS warehouse_id;
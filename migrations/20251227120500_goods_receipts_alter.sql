@@ -0,0 +1,13 @@
+-- Migration: Alter table goods_receipts
+-- Generated at: 2025-12-27T12:05:00+07:00
+-- Generated from model: internal/models/goods_receipts.go
+
+	ALTER TABLE goods_receipts ADD COLUMN IF NOT EXISTS warehouse_id UUID;
+	UPDATE goods_receipts SET warehouse_id = (SELECT warehouses_id FROM warehouses WHERE is_default) WHERE warehouse_id IS NULL;
+	ALTER TABLE goods_receipts ALTER COLUMN warehouse_id SET NOT NULL;
+	ALTER TABLE goods_receipts ADD CONSTRAINT fk_goods_receipts_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses(warehouses_id);
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE goods_receipts DROP CONSTRAINT IF EXISTS fk_goods_receipts_warehouse;
+-- ALTER TABLE goods_receipts DROP COLUMN IF EXISTS warehouse_id;
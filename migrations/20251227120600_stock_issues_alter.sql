@@ -0,0 +1,13 @@
+-- Migration: Alter table stock_issues
+-- Generated at: 2025-12-27T12:06:00+07:00
+-- Generated from model: internal/models/stock_issues.go
+
+	ALTER TABLE stock_issues ADD COLUMN IF NOT EXISTS warehouse_id UUID;
+	UPDATE stock_issues SET warehouse_id = (SELECT warehouses_id FROM warehouses WHERE is_default) WHERE warehouse_id IS NULL;
+	ALTER TABLE stock_issues ALTER COLUMN warehouse_id SET NOT NULL;
+	ALTER TABLE stock_issues ADD CONSTRAINT fk_stock_issues_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses(warehouses_id);
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE stock_issues DROP CONSTRAINT IF EXISTS fk_stock_issues_warehouse;
+-- ALTER TABLE stock_issues DROP COLUMN IF EXISTS warehouse_id;
@@ -0,0 +1,13 @@
+-- Migration: Alter table item_serials
+-- Generated at: 2025-12-27T12:07:00+07:00
+-- Generated from model: internal/models/item_serials.go
+
+	ALTER TABLE item_serials ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(warehouses_id);
+	UPDATE item_serials SET warehouse_id = (SELECT warehouse_id FROM goods_receipts WHERE goods_receipts_id = item_serials.goods_receipt_id) WHERE warehouse_id IS NULL;
+
+COMMENT ON COLUMN item_serials.warehouse_id IS 'Warehouse holding the unit; the source warehouse while in transit';
+COMMENT ON COLUMN item_serials.status IS 'in_stock, in_transit or issued';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE item_serials DROP COLUMN IF EXISTS warehouse_id;
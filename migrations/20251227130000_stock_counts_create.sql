@@ -0,0 +1,48 @@
+-- Migration: Create table stock_counts
+-- Generated at: 2025-12-27T13:00:00+07:00
+-- Generated from model: internal/models/stock_counts.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS stock_counts (
+	stock_counts_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	warehouse_id UUID NOT NULL,
+	status TEXT NOT NULL,
+	freeze_movements BOOLEAN NOT NULL DEFAULT FALSE,
+	started_at TIMESTAMPTZ NOT NULL,
+	submitted_at TIMESTAMPTZ,
+	approved_at TIMESTAMPTZ,
+	user_id UUID NOT NULL,
+	approved_by UUID,
+	notes TEXT,
+	review_notes TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE stock_counts
+ADD CONSTRAINT fk_stock_counts_warehouse
+FOREIGN KEY (warehouse_id) REFERENCES warehouses(warehouses_id);
+
+ALTER TABLE stock_counts
+ADD CONSTRAINT fk_stock_counts_user
+FOREIGN KEY (user_id) REFERENCES users(users_id);
+
+ALTER TABLE stock_counts
+ADD CONSTRAINT fk_stock_counts_approved_by
+FOREIGN KEY (approved_by) REFERENCES users(users_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_counts_open_warehouse ON stock_counts(warehouse_id) WHERE status IN ('counting', 'submitted');
+
+-- Add table and column comments
+COMMENT ON TABLE stock_counts IS 'Table for stock_counts';
+COMMENT ON COLUMN stock_counts.stock_counts_id IS 'Primary key UUID';
+COMMENT ON COLUMN stock_counts.status IS 'counting, submitted, approved or cancelled';
+COMMENT ON COLUMN stock_counts.freeze_movements IS 'Block stock movements in the warehouse while the count is open';
+COMMENT ON COLUMN stock_counts.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN stock_counts.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS stock_counts;
@@ -0,0 +1,46 @@
+-- Migration: Create table stock_count_details
+-- Generated at: 2025-12-27T13:01:00+07:00
+-- Generated from model: internal/models/stock_count_details.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS stock_count_details (
+	stock_count_details_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	stock_count_id UUID NOT NULL,
+	item_id UUID NOT NULL,
+	expected_qty INTEGER NOT NULL,
+	system_qty INTEGER,
+	counted_qty INTEGER,
+	unit_cost NUMERIC(10, 2) NOT NULL,
+	notes TEXT,
+	counted_by UUID,
+	counted_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE stock_count_details
+ADD CONSTRAINT fk_stock_count_details_stock_count
+FOREIGN KEY (stock_count_id) REFERENCES stock_counts(stock_counts_id) ON DELETE CASCADE;
+
+ALTER TABLE stock_count_details
+ADD CONSTRAINT fk_stock_count_details_item
+FOREIGN KEY (item_id) REFERENCES items(items_id);
+
+ALTER TABLE stock_count_details
+ADD CONSTRAINT fk_stock_count_details_counted_by
+FOREIGN KEY (counted_by) REFERENCES users(users_id);
+
+ALTER TABLE stock_count_details
+ADD CONSTRAINT unique_stock_count_details_item UNIQUE (stock_count_id, item_id);
+
+-- Add table and column comments
+COMMENT ON TABLE stock_count_details IS 'Table for stock_count_details';
+COMMENT ON COLUMN stock_count_details.stock_count_details_id IS 'Primary key UUID';
+COMMENT ON COLUMN stock_count_details.expected_qty IS 'Warehouse balance when the count started';
+COMMENT ON COLUMN stock_count_details.system_qty IS 'Warehouse balance when the item was counted; differs from expected_qty by movements made mid-count';
+COMMENT ON COLUMN stock_count_details.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN stock_count_details.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS stock_count_details;
@@ -0,0 +1,13 @@
+-- Migration: Alter table items
+-- Generated at: 2025-12-27T14:00:00+07:00
+-- Generated from model: internal/models/items.go
+
+	ALTER TABLE items ADD COLUMN IF NOT EXISTS avg_cost NUMERIC(14, 4) NOT NULL DEFAULT 0;
+
+UPDATE items SET avg_cost = price WHERE avg_cost = 0;
+
+COMMENT ON COLUMN items.avg_cost IS 'Moving weighted average cost per unit';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE items DROP COLUMN IF EXISTS avg_cost;
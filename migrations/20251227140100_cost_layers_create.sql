@@ -0,0 +1,41 @@
+-- Migration: Create table cost_layers
+-- Generated at: 2025-12-27T14:01:00+07:00
+-- Generated from model: internal/models/cost_layers.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS cost_layers (
+	cost_layers_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	item_id UUID NOT NULL,
+	source_type TEXT NOT NULL,
+	source_id UUID,
+	received_at TIMESTAMPTZ NOT NULL,
+	unit_cost NUMERIC(14, 4) NOT NULL,
+	qty_received INTEGER NOT NULL,
+	qty_remaining INTEGER NOT NULL,
+	created_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE cost_layers
+ADD CONSTRAINT fk_cost_layers_item
+FOREIGN KEY (item_id) REFERENCES items(items_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_item_open ON cost_layers(item_id, received_at) WHERE qty_remaining > 0;
+
+INSERT INTO cost_layers (item_id, source_type, source_id, received_at, unit_cost, qty_received, qty_remaining, created_at)
+SELECT items_id, 'opening', NULL, NOW(), avg_cost, stock, stock, NOW()
+FROM items
+WHERE stock > 0;
+
+-- Add table and column comments
+COMMENT ON TABLE cost_layers IS 'Table for cost_layers';
+COMMENT ON COLUMN cost_layers.cost_layers_id IS 'Primary key UUID';
+COMMENT ON COLUMN cost_layers.source_type IS 'opening, goods_receipt or adjustment';
+COMMENT ON COLUMN cost_layers.qty_remaining IS 'Quantity of the layer not yet consumed, oldest layers are consumed first';
+COMMENT ON COLUMN cost_layers.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN cost_layers.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS cost_layers;
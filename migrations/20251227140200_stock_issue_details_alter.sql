@@ -0,0 +1,14 @@
+-- Migration: Alter table stock_issue_details
+-- Generated at: 2025-12-27T14:02:00+07:00
+-- Generated from model: internal/models/stock_issue_details.go
+
+	ALTER TABLE stock_issue_details ADD COLUMN IF NOT EXISTS unit_cost NUMERIC(14, 4) NOT NULL DEFAULT 0;
+	ALTER TABLE stock_issue_details ADD COLUMN IF NOT EXISTS total_cost NUMERIC(14, 2) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN stock_issue_details.unit_cost IS 'Cost per unit issued under the configured costing method';
+COMMENT ON COLUMN stock_issue_details.total_cost IS 'Cost of goods issued for the line';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE stock_issue_details DROP COLUMN IF EXISTS unit_cost;
+-- ALTER TABLE stock_issue_details DROP COLUMN IF EXISTS total_cost;
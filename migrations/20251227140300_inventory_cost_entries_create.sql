@@ -0,0 +1,43 @@
+-- Migration: Create table inventory_cost_entries
+-- Generated at: 2025-12-27T14:03:00+07:00
+-- Generated from model: internal/models/inventory_cost_entries.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS inventory_cost_entries (
+	inventory_cost_entries_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	item_id UUID NOT NULL,
+	warehouse_id UUID,
+	source_type TEXT NOT NULL,
+	source_id UUID NOT NULL,
+	qty INTEGER NOT NULL,
+	method TEXT NOT NULL,
+	average_cost NUMERIC(14, 2) NOT NULL,
+	fifo_cost NUMERIC(14, 2) NOT NULL,
+	total_cost NUMERIC(14, 2) NOT NULL,
+	created_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE inventory_cost_entries
+ADD CONSTRAINT fk_inventory_cost_entries_item
+FOREIGN KEY (item_id) REFERENCES items(items_id) ON DELETE CASCADE;
+
+ALTER TABLE inventory_cost_entries
+ADD CONSTRAINT fk_inventory_cost_entries_warehouse
+FOREIGN KEY (warehouse_id) REFERENCES warehouses(warehouses_id);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_cost_entries_source ON inventory_cost_entries(source_type, source_id);
+
+-- Add table and column comments
+COMMENT ON TABLE inventory_cost_entries IS 'Table for inventory_cost_entries';
+COMMENT ON COLUMN inventory_cost_entries.inventory_cost_entries_id IS 'Primary key UUID';
+COMMENT ON COLUMN inventory_cost_entries.source_type IS 'stock_issue, stock_count or item_update';
+COMMENT ON COLUMN inventory_cost_entries.qty IS 'Signed quantity, negative when stock leaves';
+COMMENT ON COLUMN inventory_cost_entries.total_cost IS 'Cost booked under method; negative for goods issued or written off';
+COMMENT ON COLUMN inventory_cost_entries.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN inventory_cost_entries.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS inventory_cost_entries;
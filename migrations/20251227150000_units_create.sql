@@ -0,0 +1,32 @@
+-- Migration: Create table units
+-- Generated at: 2025-12-27T15:00:00+07:00
+-- Generated from model: internal/models/units.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS units (
+	units_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	code TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Every free-text unit already used by an item becomes a managed unit
+INSERT INTO units (code, name, created_at, updated_at)
+SELECT DISTINCT LOWER(TRIM(unit)), INITCAP(TRIM(unit)), NOW(), NOW()
+FROM items
+WHERE TRIM(COALESCE(unit, '')) <> ''
+ON CONFLICT DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE units IS 'Table for units';
+COMMENT ON COLUMN units.units_id IS 'Primary key UUID';
+COMMENT ON COLUMN units.code IS 'Lower-case unit code, matches items.unit';
+COMMENT ON COLUMN units.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN units.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS units;
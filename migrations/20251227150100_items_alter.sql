@@ -0,0 +1,19 @@
+-- Migration: Alter table items
+-- Generated at: 2025-12-27T15:01:00+07:00
+-- Generated from model: internal/models/items.go
+
+	ALTER TABLE items ADD COLUMN IF NOT EXISTS unit_id UUID;
+	ALTER TABLE items ADD COLUMN IF NOT EXISTS purchase_unit_id UUID;
+	ALTER TABLE items ADD CONSTRAINT fk_items_unit FOREIGN KEY (unit_id) REFERENCES units(units_id);
+	ALTER TABLE items ADD CONSTRAINT fk_items_purchase_unit FOREIGN KEY (purchase_unit_id) REFERENCES units(units_id);
+	UPDATE items SET unit_id = (SELECT units_id FROM units WHERE code = LOWER(TRIM(items.unit))) WHERE unit_id IS NULL;
+
+COMMENT ON COLUMN items.unit_id IS 'Stock unit; stock and prices are kept in this unit';
+COMMENT ON COLUMN items.purchase_unit_id IS 'Default unit on purchasing lines and goods receipts';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE items DROP CONSTRAINT IF EXISTS fk_items_purchase_unit;
+-- ALTER TABLE items DROP CONSTRAINT IF EXISTS fk_items_unit;
+-- ALTER TABLE items DROP COLUMN IF EXISTS purchase_unit_id;
+-- ALTER TABLE items DROP COLUMN IF EXISTS unit_id;
@@ -0,0 +1,40 @@
+-- Migration: Create table item_units
+-- Generated at: 2025-12-27T15:02:00+07:00
+-- Generated from model: internal/models/item_units.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS item_units (
+	item_units_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	item_id UUID NOT NULL,
+	unit_id UUID NOT NULL,
+	factor INTEGER NOT NULL,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE item_units
+ADD CONSTRAINT fk_item_units_item
+FOREIGN KEY (item_id) REFERENCES items(items_id) ON DELETE CASCADE;
+
+ALTER TABLE item_units
+ADD CONSTRAINT fk_item_units_unit
+FOREIGN KEY (unit_id) REFERENCES units(units_id);
+
+ALTER TABLE item_units
+ADD CONSTRAINT unique_item_units_item_unit UNIQUE (item_id, unit_id);
+
+ALTER TABLE item_units
+ADD CONSTRAINT check_item_units_factor CHECK (factor > 0);
+
+-- Add table and column comments
+COMMENT ON TABLE item_units IS 'Table for item_units';
+COMMENT ON COLUMN item_units.item_units_id IS 'Primary key UUID';
+COMMENT ON COLUMN item_units.factor IS 'Stock units contained in one of this unit, e.g. 200 liter per drum';
+COMMENT ON COLUMN item_units.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN item_units.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS item_units;
@@ -0,0 +1,20 @@
+-- Migration: Alter table purchasing_details
+-- Generated at: 2025-12-27T15:03:00+07:00
+-- Generated from model: internal/models/purchasing_details.go
+
+	ALTER TABLE purchasing_details ADD COLUMN IF NOT EXISTS unit_id UUID;
+	ALTER TABLE purchasing_details ADD COLUMN IF NOT EXISTS unit_qty INTEGER;
+	ALTER TABLE purchasing_details ADD COLUMN IF NOT EXISTS factor INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE purchasing_details ADD CONSTRAINT fk_purchasing_details_unit FOREIGN KEY (unit_id) REFERENCES units(units_id);
+	UPDATE purchasing_details SET unit_qty = qty, unit_id = (SELECT unit_id FROM items WHERE items_id = purchasing_details.item_id) WHERE unit_qty IS NULL;
+	ALTER TABLE purchasing_details ALTER COLUMN unit_qty SET NOT NULL;
+
+COMMENT ON COLUMN purchasing_details.unit_qty IS 'Quantity in unit_id as entered';
+COMMENT ON COLUMN purchasing_details.qty IS 'Quantity in the item stock unit (unit_qty * factor)';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE purchasing_details DROP CONSTRAINT IF EXISTS fk_purchasing_details_unit;
+-- ALTER TABLE purchasing_details DROP COLUMN IF EXISTS factor;
+-- ALTER TABLE purchasing_details DROP COLUMN IF EXISTS unit_qty;
+-- ALTER TABLE purchasing_details DROP COLUMN IF EXISTS unit_id;
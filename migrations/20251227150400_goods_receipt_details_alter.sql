@@ -0,0 +1,20 @@
+-- Migration: Alter table goods_receipt_details
+-- Generated at: 2025-12-27T15:04:00+07:00
+-- Generated from model: internal/models/goods_receipt_details.go
+
+	ALTER TABLE goods_receipt_details ADD COLUMN IF NOT EXISTS unit_id UUID;
+	ALTER TABLE goods_receipt_details ADD COLUMN IF NOT EXISTS unit_qty INTEGER;
+	ALTER TABLE goods_receipt_details ADD COLUMN IF NOT EXISTS factor INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE goods_receipt_details ADD CONSTRAINT fk_goods_receipt_details_unit FOREIGN KEY (unit_id) REFERENCES units(units_id);
+	UPDATE goods_receipt_details SET unit_qty = qty, unit_id = (SELECT unit_id FROM items WHERE items_id = goods_receipt_details.item_id) WHERE unit_qty IS NULL;
+	ALTER TABLE goods_receipt_details ALTER COLUMN unit_qty SET NOT NULL;
+
+COMMENT ON COLUMN goods_receipt_details.unit_qty IS 'Quantity in unit_id as entered';
+COMMENT ON COLUMN goods_receipt_details.qty IS 'Quantity in the item stock unit (unit_qty * factor)';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE goods_receipt_details DROP CONSTRAINT IF EXISTS fk_goods_receipt_details_unit;
+-- ALTER TABLE goods_receipt_details DROP COLUMN IF EXISTS factor;
+-- ALTER TABLE goods_receipt_details DROP COLUMN IF EXISTS unit_qty;
+-- ALTER TABLE goods_receipt_details DROP COLUMN IF EXISTS unit_id;
@@ -0,0 +1,49 @@
+-- Migration: Create table categories
+-- Generated at: 2025-12-27T16:00:00+07:00
+-- Generated from model: internal/models/categories.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS categories (
+	categories_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	parent_id UUID,
+	code TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	description TEXT,
+	default_min_stock INTEGER NOT NULL DEFAULT 0,
+	gl_account TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE categories
+ADD CONSTRAINT fk_categories_parent
+FOREIGN KEY (parent_id) REFERENCES categories(categories_id);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+-- Every free-text item category and supplier type becomes a top-level category
+INSERT INTO categories (code, name, description, gl_account, created_at, updated_at)
+SELECT DISTINCT LOWER(TRIM(value)), INITCAP(TRIM(value)), '', '', NOW(), NOW()
+FROM (
+	SELECT category AS value FROM items
+	UNION
+	SELECT supplier_type AS value FROM suppliers
+) existing
+WHERE TRIM(COALESCE(value, '')) <> ''
+ON CONFLICT DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE categories IS 'Table for categories';
+COMMENT ON COLUMN categories.categories_id IS 'Primary key UUID';
+COMMENT ON COLUMN categories.parent_id IS 'Parent category; NULL for top-level categories';
+COMMENT ON COLUMN categories.code IS 'Lower-case category code, matches items.category and suppliers.supplier_type';
+COMMENT ON COLUMN categories.default_min_stock IS 'Minimum stock applied to new items that do not set one';
+COMMENT ON COLUMN categories.gl_account IS 'General ledger inventory or expense account';
+COMMENT ON COLUMN categories.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN categories.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS categories;
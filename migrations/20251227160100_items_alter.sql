@@ -0,0 +1,17 @@
+-- Migration: Alter table items
+-- Generated at: 2025-12-27T16:01:00+07:00
+-- Generated from model: internal/models/items.go
+
+	ALTER TABLE items ADD COLUMN IF NOT EXISTS category_id UUID;
+	ALTER TABLE items ADD CONSTRAINT fk_items_category FOREIGN KEY (category_id) REFERENCES categories(categories_id);
+	CREATE INDEX IF NOT EXISTS idx_items_category_id ON items(category_id);
+	UPDATE items SET category_id = (SELECT categories_id FROM categories WHERE code = LOWER(TRIM(items.category))) WHERE category_id IS NULL;
+	UPDATE items SET category = LOWER(TRIM(category)) WHERE category_id IS NOT NULL;
+
+COMMENT ON COLUMN items.category_id IS 'Managed category; items.category holds its code';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- DROP INDEX IF EXISTS idx_items_category_id;
+-- ALTER TABLE items DROP CONSTRAINT IF EXISTS fk_items_category;
+-- ALTER TABLE items DROP COLUMN IF EXISTS category_id;
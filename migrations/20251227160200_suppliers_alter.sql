@@ -0,0 +1,17 @@
+-- Migration: Alter table suppliers
+-- Generated at: 2025-12-27T16:02:00+07:00
+-- Generated from model: internal/models/suppliers.go
+
+	ALTER TABLE suppliers ADD COLUMN IF NOT EXISTS category_id UUID;
+	ALTER TABLE suppliers ADD CONSTRAINT fk_suppliers_category FOREIGN KEY (category_id) REFERENCES categories(categories_id);
+	CREATE INDEX IF NOT EXISTS idx_suppliers_category_id ON suppliers(category_id);
+	UPDATE suppliers SET category_id = (SELECT categories_id FROM categories WHERE code = LOWER(TRIM(suppliers.supplier_type))) WHERE category_id IS NULL;
+	UPDATE suppliers SET supplier_type = LOWER(TRIM(supplier_type)) WHERE category_id IS NOT NULL;
+
+COMMENT ON COLUMN suppliers.category_id IS 'Managed category; suppliers.supplier_type holds its code';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- DROP INDEX IF EXISTS idx_suppliers_category_id;
+-- ALTER TABLE suppliers DROP CONSTRAINT IF EXISTS fk_suppliers_category;
+-- ALTER TABLE suppliers DROP COLUMN IF EXISTS category_id;
@@ -0,0 +1,18 @@
+-- Migration: Alter table items
+-- Generated at: 2025-12-27T17:00:00+07:00
+-- Generated from model: internal/models/items.go
+
+	ALTER TABLE items ADD COLUMN IF NOT EXISTS sku TEXT;
+	UPDATE items SET sku = numbered.sku FROM (SELECT items_id, 'ITM-' || LPAD(ROW_NUMBER() OVER (ORDER BY created_at, items_id)::text, 6, '0') AS sku FROM items) numbered WHERE items.items_id = numbered.items_id AND items.sku IS NULL;
+	ALTER TABLE items ALTER COLUMN sku SET NOT NULL;
+	ALTER TABLE items ADD CONSTRAINT unique_items_sku UNIQUE (sku);
+	CREATE SEQUENCE IF NOT EXISTS item_sku_seq;
+	SELECT setval('item_sku_seq', (SELECT COUNT(*) FROM items) + 1, false);
+
+COMMENT ON COLUMN items.sku IS 'Stock keeping unit; generated as ITM-nnnnnn from item_sku_seq when not supplied';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- DROP SEQUENCE IF EXISTS item_sku_seq;
+-- ALTER TABLE items DROP CONSTRAINT IF EXISTS unique_items_sku;
+-- ALTER TABLE items DROP COLUMN IF EXISTS sku;
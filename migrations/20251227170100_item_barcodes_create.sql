@@ -0,0 +1,39 @@
+-- Migration: Create table item_barcodes
+-- Generated at: 2025-12-27T17:01:00+07:00
+-- Generated from model: internal/models/item_barcodes.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS item_barcodes (
+	item_barcodes_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	item_id UUID NOT NULL,
+	symbology TEXT NOT NULL,
+	code TEXT NOT NULL UNIQUE,
+	is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE item_barcodes
+ADD CONSTRAINT fk_item_barcodes_item
+FOREIGN KEY (item_id) REFERENCES items(items_id) ON DELETE CASCADE;
+
+ALTER TABLE item_barcodes
+ADD CONSTRAINT check_item_barcodes_symbology CHECK (symbology IN ('ean13', 'code128', 'qr'));
+
+CREATE INDEX IF NOT EXISTS idx_item_barcodes_item_id ON item_barcodes(item_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_item_barcodes_primary ON item_barcodes(item_id) WHERE is_primary;
+
+-- Add table and column comments
+COMMENT ON TABLE item_barcodes IS 'Table for item_barcodes';
+COMMENT ON COLUMN item_barcodes.item_barcodes_id IS 'Primary key UUID';
+COMMENT ON COLUMN item_barcodes.symbology IS 'ean13, code128 or qr';
+COMMENT ON COLUMN item_barcodes.code IS 'Encoded value; unique so a scan resolves to one item';
+COMMENT ON COLUMN item_barcodes.is_primary IS 'Barcode printed on labels by default';
+COMMENT ON COLUMN item_barcodes.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN item_barcodes.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS item_barcodes;
@@ -0,0 +1,42 @@
+-- Migration: Create table item_price_changes
+-- Generated at: 2025-12-27T18:00:00+07:00
+-- Generated from model: internal/models/item_price_changes.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS item_price_changes (
+	item_price_changes_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	item_id UUID NOT NULL,
+	old_price NUMERIC(10, 2) NOT NULL,
+	new_price NUMERIC(10, 2) NOT NULL,
+	change_pct NUMERIC(10, 2),
+	user_id UUID,
+	reason TEXT,
+	changed_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE item_price_changes
+ADD CONSTRAINT fk_item_price_changes_item
+FOREIGN KEY (item_id) REFERENCES items(items_id) ON DELETE CASCADE;
+
+ALTER TABLE item_price_changes
+ADD CONSTRAINT fk_item_price_changes_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_item_price_changes_item_changed ON item_price_changes(item_id, changed_at);
+CREATE INDEX IF NOT EXISTS idx_item_price_changes_changed_at ON item_price_changes(changed_at);
+
+-- Add table and column comments
+COMMENT ON TABLE item_price_changes IS 'Table for item_price_changes';
+COMMENT ON COLUMN item_price_changes.item_price_changes_id IS 'Primary key UUID';
+COMMENT ON COLUMN item_price_changes.change_pct IS 'Percentage change from old_price; NULL when old_price is zero';
+COMMENT ON COLUMN item_price_changes.user_id IS 'User who changed the price';
+COMMENT ON COLUMN item_price_changes.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN item_price_changes.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS item_price_changes;
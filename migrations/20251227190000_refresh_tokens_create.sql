@@ -0,0 +1,46 @@
+-- Migration: Create table refresh_tokens
+-- Generated at: 2025-12-27T19:00:00+07:00
+-- Generated from model: internal/models/refresh_tokens.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	refresh_tokens_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	family_id UUID NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ,
+	replaced_by UUID,
+	revoked_at TIMESTAMPTZ,
+	user_agent TEXT,
+	ip_address TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE refresh_tokens
+ADD CONSTRAINT fk_refresh_tokens_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+ALTER TABLE refresh_tokens
+ADD CONSTRAINT fk_refresh_tokens_replaced_by
+FOREIGN KEY (replaced_by) REFERENCES refresh_tokens(refresh_tokens_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+
+-- Add table and column comments
+COMMENT ON TABLE refresh_tokens IS 'Table for refresh_tokens';
+COMMENT ON COLUMN refresh_tokens.refresh_tokens_id IS 'Primary key UUID';
+COMMENT ON COLUMN refresh_tokens.family_id IS 'Tokens rotated from the same login share a family; reuse revokes the whole family';
+COMMENT ON COLUMN refresh_tokens.token_hash IS 'SHA-256 of the opaque token; the token itself is never stored';
+COMMENT ON COLUMN refresh_tokens.used_at IS 'Set when the token is rotated; a second use is treated as theft';
+COMMENT ON COLUMN refresh_tokens.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN refresh_tokens.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,37 @@
+-- Migration: Create table revoked_tokens
+-- Generated at: 2025-12-27T20:00:00+07:00
+-- Generated from model: internal/models/revoked_tokens.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	revoked_tokens_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	jti TEXT UNIQUE,
+	user_id UUID NOT NULL,
+	revoked_before TIMESTAMPTZ,
+	expires_at TIMESTAMPTZ NOT NULL,
+	reason TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE revoked_tokens
+ADD CONSTRAINT check_revoked_tokens_target CHECK (jti IS NOT NULL OR revoked_before IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_created_at ON revoked_tokens(created_at);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Add table and column comments
+COMMENT ON TABLE revoked_tokens IS 'Table for revoked_tokens';
+COMMENT ON COLUMN revoked_tokens.revoked_tokens_id IS 'Primary key UUID';
+COMMENT ON COLUMN revoked_tokens.jti IS 'Revoked access token id; NULL for user-wide revocations';
+COMMENT ON COLUMN revoked_tokens.user_id IS 'No foreign key: revocations must outlive deleted users';
+COMMENT ON COLUMN revoked_tokens.revoked_before IS 'Every token of the user issued at or before this time is revoked';
+COMMENT ON COLUMN revoked_tokens.expires_at IS 'After this time the revoked tokens have expired and the row can be purged';
+COMMENT ON COLUMN revoked_tokens.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN revoked_tokens.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS revoked_tokens;
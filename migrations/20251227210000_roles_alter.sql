@@ -0,0 +1,18 @@
+-- Migration: Alter table roles
+-- Generated at: 2025-12-27T21:00:00+07:00
+-- Generated from model: internal/models/roles.go
+
+	INSERT INTO roles (role_oid, role_name, role_description)
+	SELECT DISTINCT UPPER(TRIM(u.role)), UPPER(TRIM(u.role)), 'Created from existing user roles.'
+	FROM users u
+	WHERE NOT EXISTS (SELECT 1 FROM roles r WHERE r.role_oid = UPPER(TRIM(u.role)));
+	UPDATE users SET role = UPPER(TRIM(role)) WHERE role <> UPPER(TRIM(role));
+	ALTER TABLE roles ADD CONSTRAINT unique_roles_role_oid UNIQUE (role_oid);
+	ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(role_oid);
+
+COMMENT ON COLUMN users.role IS 'Role OID; must exist in roles';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+-- ALTER TABLE roles DROP CONSTRAINT IF EXISTS unique_roles_role_oid;
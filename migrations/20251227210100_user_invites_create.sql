@@ -0,0 +1,43 @@
+-- Migration: Create table user_invites
+-- Generated at: 2025-12-27T21:01:00+07:00
+-- Generated from model: internal/models/user_invites.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS user_invites (
+	user_invites_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	token_hash TEXT NOT NULL UNIQUE,
+	role TEXT NOT NULL,
+	email TEXT,
+	created_by UUID,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ,
+	used_by UUID,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE user_invites
+ADD CONSTRAINT fk_user_invites_role
+FOREIGN KEY (role) REFERENCES roles(role_oid) ON DELETE CASCADE;
+
+ALTER TABLE user_invites
+ADD CONSTRAINT fk_user_invites_created_by
+FOREIGN KEY (created_by) REFERENCES users(users_id) ON DELETE SET NULL;
+
+ALTER TABLE user_invites
+ADD CONSTRAINT fk_user_invites_used_by
+FOREIGN KEY (used_by) REFERENCES users(users_id) ON DELETE SET NULL;
+
+-- Add table and column comments
+COMMENT ON TABLE user_invites IS 'Table for user_invites';
+COMMENT ON COLUMN user_invites.user_invites_id IS 'Primary key UUID';
+COMMENT ON COLUMN user_invites.token_hash IS 'SHA-256 of the invite token; the token itself is only shown once';
+COMMENT ON COLUMN user_invites.role IS 'Role given to the user who registers with the invite';
+COMMENT ON COLUMN user_invites.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN user_invites.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS user_invites;
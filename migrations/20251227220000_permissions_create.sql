@@ -0,0 +1,34 @@
+-- Migration: Create table permissions
+-- Generated at: 2025-12-27T22:00:00+07:00
+-- Generated from model: internal/models/permissions.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS permissions (
+	permissions_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	code TEXT NOT NULL UNIQUE,
+	description TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO permissions (code, description, created_at, updated_at) VALUES
+	('users:manage', 'Update, deactivate and delete users; issue invites', NOW(), NOW()),
+	('roles:manage', 'Create roles and assign permissions', NOW(), NOW()),
+	('items:write', 'Create and change items, categories and units', NOW(), NOW()),
+	('warehouses:write', 'Create and change warehouses', NOW(), NOW()),
+	('stock_counts:approve', 'Approve, reject and cancel stock counts', NOW(), NOW()),
+	('purchasings:approve', 'Set purchasings to approved', NOW(), NOW())
+ON CONFLICT (code) DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE permissions IS 'Table for permissions';
+COMMENT ON COLUMN permissions.permissions_id IS 'Primary key UUID';
+COMMENT ON COLUMN permissions.code IS 'Permission checked by middleware.RequirePermission, e.g. items:write';
+COMMENT ON COLUMN permissions.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN permissions.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS permissions;
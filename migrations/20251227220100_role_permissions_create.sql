@@ -0,0 +1,50 @@
+-- Migration: Create table role_permissions
+-- Generated at: 2025-12-27T22:01:00+07:00
+-- Generated from model: internal/models/role_permissions.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role_permissions_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	role_oid TEXT NOT NULL,
+	permission TEXT NOT NULL,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE role_permissions
+ADD CONSTRAINT fk_role_permissions_role
+FOREIGN KEY (role_oid) REFERENCES roles(role_oid) ON DELETE CASCADE;
+
+ALTER TABLE role_permissions
+ADD CONSTRAINT fk_role_permissions_permission
+FOREIGN KEY (permission) REFERENCES permissions(code) ON DELETE CASCADE;
+
+ALTER TABLE role_permissions
+ADD CONSTRAINT unique_role_permissions_role_permission UNIQUE (role_oid, permission);
+
+-- Grants matching the previously hard-coded role checks. ADMIN holds every
+-- permission implicitly.
+INSERT INTO role_permissions (role_oid, permission, created_at, updated_at)
+SELECT r.role_oid, p.code, NOW(), NOW()
+FROM roles r
+JOIN permissions p ON (r.role_oid, p.code) IN (
+	('MANAGER', 'items:write'),
+	('MANAGER', 'warehouses:write'),
+	('MANAGER', 'stock_counts:approve'),
+	('MANAGER', 'purchasings:approve'),
+	('SUPPLIERS', 'items:write'),
+	('SUPPLIERS', 'warehouses:write')
+)
+ON CONFLICT (role_oid, permission) DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE role_permissions IS 'Table for role_permissions';
+COMMENT ON COLUMN role_permissions.role_permissions_id IS 'Primary key UUID';
+COMMENT ON COLUMN role_permissions.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN role_permissions.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS role_permissions;
@@ -0,0 +1,38 @@
+-- Migration: Create table departments
+-- Generated at: 2025-12-27T23:00:00+07:00
+-- Generated from model: internal/models/departments.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS departments (
+	departments_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	code TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	cost_center TEXT,
+	is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO permissions (code, description, created_at, updated_at) VALUES
+	('departments:all', 'See purchasings and users of every department', NOW(), NOW()),
+	('departments:manage', 'Create, change and delete departments', NOW(), NOW())
+ON CONFLICT (code) DO NOTHING;
+
+-- Managers keep seeing everything, as before departments existed.
+INSERT INTO role_permissions (role_oid, permission, created_at, updated_at)
+SELECT role_oid, 'departments:all', NOW(), NOW() FROM roles WHERE role_oid = 'MANAGER'
+ON CONFLICT (role_oid, permission) DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE departments IS 'Table for departments';
+COMMENT ON COLUMN departments.departments_id IS 'Primary key UUID';
+COMMENT ON COLUMN departments.cost_center IS 'Cost center code used for reporting';
+COMMENT ON COLUMN departments.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN departments.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DELETE FROM permissions WHERE code IN ('departments:all', 'departments:manage');
+-- DROP TABLE IF EXISTS departments;
@@ -0,0 +1,37 @@
+-- Migration: Create table user_departments
+-- Generated at: 2025-12-27T23:01:00+07:00
+-- Generated from model: internal/models/user_departments.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS user_departments (
+	user_departments_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	department_id UUID NOT NULL,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE user_departments
+ADD CONSTRAINT fk_user_departments_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+ALTER TABLE user_departments
+ADD CONSTRAINT fk_user_departments_department
+FOREIGN KEY (department_id) REFERENCES departments(departments_id) ON DELETE CASCADE;
+
+ALTER TABLE user_departments
+ADD CONSTRAINT unique_user_departments_user_department UNIQUE (user_id, department_id);
+
+CREATE INDEX IF NOT EXISTS idx_user_departments_department ON user_departments(department_id);
+
+-- Add table and column comments
+COMMENT ON TABLE user_departments IS 'Table for user_departments';
+COMMENT ON COLUMN user_departments.user_departments_id IS 'Primary key UUID';
+COMMENT ON COLUMN user_departments.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN user_departments.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS user_departments;
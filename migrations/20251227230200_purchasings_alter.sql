@@ -0,0 +1,15 @@
+-- Migration: Alter table purchasings
+-- Generated at: 2025-12-27T23:02:00+07:00
+-- Generated from model: internal/models/purchasings.go
+
+	ALTER TABLE purchasings ADD COLUMN IF NOT EXISTS department_id UUID;
+	ALTER TABLE purchasings ADD CONSTRAINT fk_purchasings_department FOREIGN KEY (department_id) REFERENCES departments(departments_id);
+	CREATE INDEX IF NOT EXISTS idx_purchasings_department_id ON purchasings(department_id);
+
+COMMENT ON COLUMN purchasings.department_id IS 'Owning department; purchasings without one are only visible to roles with departments:all';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- DROP INDEX IF EXISTS idx_purchasings_department_id;
+-- ALTER TABLE purchasings DROP CONSTRAINT IF EXISTS fk_purchasings_department;
+-- ALTER TABLE purchasings DROP COLUMN IF EXISTS department_id;
@@ -0,0 +1,11 @@
+-- Migration: Alter table users
+-- Generated at: 2025-12-28T00:00:00+07:00
+-- Generated from model: internal/models/users.go
+
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN users.must_change_password IS 'Set when an admin sets the password; other APIs are refused until the user changes it';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE users DROP COLUMN IF EXISTS must_change_password;
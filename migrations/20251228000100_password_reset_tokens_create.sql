@@ -0,0 +1,35 @@
+-- Migration: Create table password_reset_tokens
+-- Generated at: 2025-12-28T00:01:00+07:00
+-- Generated from model: internal/models/password_reset_tokens.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	password_reset_tokens_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ,
+	ip_address TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE password_reset_tokens
+ADD CONSTRAINT fk_password_reset_tokens_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user ON password_reset_tokens(user_id);
+
+-- Add table and column comments
+COMMENT ON TABLE password_reset_tokens IS 'Table for password_reset_tokens';
+COMMENT ON COLUMN password_reset_tokens.password_reset_tokens_id IS 'Primary key UUID';
+COMMENT ON COLUMN password_reset_tokens.token_hash IS 'SHA-256 of the emailed token; the token itself is never stored';
+COMMENT ON COLUMN password_reset_tokens.ip_address IS 'Client IP that requested the reset';
+COMMENT ON COLUMN password_reset_tokens.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN password_reset_tokens.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS password_reset_tokens;
@@ -0,0 +1,31 @@
+-- Migration: Create table password_histories
+-- Generated at: 2025-12-28T01:00:00+07:00
+-- Generated from model: internal/models/password_histories.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS password_histories (
+	password_histories_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE password_histories
+ADD CONSTRAINT fk_password_histories_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_password_histories_user_created ON password_histories(user_id, created_at DESC);
+
+-- Add table and column comments
+COMMENT ON TABLE password_histories IS 'Table for password_histories';
+COMMENT ON COLUMN password_histories.password_histories_id IS 'Primary key UUID';
+COMMENT ON COLUMN password_histories.password_hash IS 'Bcrypt hash of a replaced password, kept to refuse reuse (PASSWORD_HISTORY)';
+COMMENT ON COLUMN password_histories.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN password_histories.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS password_histories;
@@ -0,0 +1,17 @@
+-- Migration: Alter table users
+-- Generated at: 2025-12-28T02:00:00+07:00
+-- Generated from model: internal/models/users.go
+
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_secret TEXT NOT NULL DEFAULT '';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_last_step BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN users.two_factor_enabled IS 'Set once the user confirmed a TOTP code; logins then need a second step';
+COMMENT ON COLUMN users.two_factor_secret IS 'Base32 TOTP secret; pending until two_factor_enabled is set';
+COMMENT ON COLUMN users.two_factor_last_step IS 'Time step of the last accepted TOTP code, so codes cannot be replayed';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- ALTER TABLE users DROP COLUMN IF EXISTS two_factor_last_step;
+-- ALTER TABLE users DROP COLUMN IF EXISTS two_factor_secret;
+-- ALTER TABLE users DROP COLUMN IF EXISTS two_factor_enabled;
@@ -0,0 +1,31 @@
+-- Migration: Create table user_recovery_codes
+-- Generated at: 2025-12-28T02:01:00+07:00
+-- Generated from model: internal/models/user_recovery_codes.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+	user_recovery_codes_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	code_hash TEXT NOT NULL,
+	used_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (user_id, code_hash)
+);
+
+ALTER TABLE user_recovery_codes
+ADD CONSTRAINT fk_user_recovery_codes_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+-- Add table and column comments
+COMMENT ON TABLE user_recovery_codes IS 'Single-use codes that replace a TOTP code when the device is lost';
+COMMENT ON COLUMN user_recovery_codes.user_recovery_codes_id IS 'Primary key UUID';
+COMMENT ON COLUMN user_recovery_codes.code_hash IS 'SHA-256 of the normalized code; the code itself is only shown once';
+COMMENT ON COLUMN user_recovery_codes.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN user_recovery_codes.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS user_recovery_codes;
@@ -0,0 +1,36 @@
+-- Migration: Create table login_challenges
+-- Generated at: 2025-12-28T02:02:00+07:00
+-- Generated from model: internal/models/login_challenges.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+	login_challenges_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	expires_at TIMESTAMPTZ NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	ip_address TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE login_challenges
+ADD CONSTRAINT fk_login_challenges_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user ON login_challenges(user_id);
+
+-- Add table and column comments
+COMMENT ON TABLE login_challenges IS 'Pending logins that passed the password check and wait for a second factor';
+COMMENT ON COLUMN login_challenges.login_challenges_id IS 'Primary key UUID';
+COMMENT ON COLUMN login_challenges.token_hash IS 'SHA-256 of the challenge token returned by login';
+COMMENT ON COLUMN login_challenges.attempts IS 'Wrong codes entered for this challenge';
+COMMENT ON COLUMN login_challenges.ip_address IS 'Client IP that passed the password check';
+COMMENT ON COLUMN login_challenges.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN login_challenges.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS login_challenges;
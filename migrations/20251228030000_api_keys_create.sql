@@ -0,0 +1,48 @@
+-- Migration: Create table api_keys
+-- Generated at: 2025-12-28T03:00:00+07:00
+-- Generated from model: internal/models/api_keys.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	api_keys_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL UNIQUE,
+	key_hash TEXT NOT NULL UNIQUE,
+	user_id UUID NOT NULL,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+	expires_at TIMESTAMPTZ,
+	last_used_at TIMESTAMPTZ,
+	last_used_ip TEXT,
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE api_keys
+ADD CONSTRAINT fk_api_keys_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
+
+INSERT INTO permissions (code, description, created_at, updated_at) VALUES
+	('api_keys:manage', 'Create, list and revoke API keys', NOW(), NOW())
+ON CONFLICT (code) DO NOTHING;
+
+-- Add table and column comments
+COMMENT ON TABLE api_keys IS 'Keys that integrations use instead of a user password';
+COMMENT ON COLUMN api_keys.api_keys_id IS 'Primary key UUID';
+COMMENT ON COLUMN api_keys.prefix IS 'Start of the key, kept in clear to recognise it';
+COMMENT ON COLUMN api_keys.key_hash IS 'SHA-256 of the whole key; the key itself is only shown once';
+COMMENT ON COLUMN api_keys.user_id IS 'User the key acts as';
+COMMENT ON COLUMN api_keys.scopes IS 'Permissions the key may use, out of those of its user';
+COMMENT ON COLUMN api_keys.allowed_ips IS 'IP addresses or CIDR ranges the key may be used from; empty allows any';
+COMMENT ON COLUMN api_keys.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN api_keys.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS api_keys;
+-- DELETE FROM permissions WHERE code = 'api_keys:manage';
@@ -0,0 +1,31 @@
+-- Migration: Create table signing_keys
+-- Generated at: 2025-12-28T04:00:00+07:00
+-- Generated from model: internal/models/signing_keys.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+	signing_keys_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	kid TEXT NOT NULL UNIQUE,
+	algorithm TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	activates_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_activates_at ON signing_keys(activates_at);
+
+-- Add table and column comments
+COMMENT ON TABLE signing_keys IS 'Keys access tokens are signed with when JWT_ALGORITHM is RS256 or EdDSA';
+COMMENT ON COLUMN signing_keys.signing_keys_id IS 'Primary key UUID';
+COMMENT ON COLUMN signing_keys.kid IS 'Key id put in token headers and the JWKS';
+COMMENT ON COLUMN signing_keys.private_key IS 'PKCS #8 PEM private key';
+COMMENT ON COLUMN signing_keys.activates_at IS 'When the key starts signing; it is published in the JWKS before that';
+COMMENT ON COLUMN signing_keys.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN signing_keys.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS signing_keys;
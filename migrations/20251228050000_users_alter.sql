@@ -0,0 +1,17 @@
+-- Migration: Alter table users
+-- Generated at: 2025-12-28T05:00:00+07:00
+-- Generated from model: internal/models/users.go
+
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer TEXT NOT NULL DEFAULT '';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_identity ON users(oidc_issuer, oidc_subject) WHERE oidc_subject <> '';
+
+COMMENT ON COLUMN users.oidc_issuer IS 'Issuer of the single sign-on identity linked to the user';
+COMMENT ON COLUMN users.oidc_subject IS 'Subject (sub claim) of the linked single sign-on identity; empty when not linked';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- DROP INDEX IF EXISTS idx_users_oidc_identity;
+-- ALTER TABLE users DROP COLUMN IF EXISTS oidc_subject;
+-- ALTER TABLE users DROP COLUMN IF EXISTS oidc_issuer;
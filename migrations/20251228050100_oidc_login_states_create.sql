@@ -0,0 +1,33 @@
+-- Migration: Create table oidc_login_states
+-- Generated at: 2025-12-28T05:01:00+07:00
+-- Generated from model: internal/models/oidc_login_states.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+	oidc_login_states_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	state_hash TEXT NOT NULL UNIQUE,
+	nonce TEXT NOT NULL,
+	code_verifier TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	ip_address TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires ON oidc_login_states(expires_at);
+
+-- Add table and column comments
+COMMENT ON TABLE oidc_login_states IS 'Single sign-on logins sent to the identity provider and not yet completed';
+COMMENT ON COLUMN oidc_login_states.oidc_login_states_id IS 'Primary key UUID';
+COMMENT ON COLUMN oidc_login_states.state_hash IS 'SHA-256 of the state parameter sent to the provider';
+COMMENT ON COLUMN oidc_login_states.nonce IS 'Nonce the returned ID token must carry';
+COMMENT ON COLUMN oidc_login_states.code_verifier IS 'PKCE code verifier presented when redeeming the code';
+COMMENT ON COLUMN oidc_login_states.ip_address IS 'Client IP that started the login';
+COMMENT ON COLUMN oidc_login_states.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN oidc_login_states.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS oidc_login_states;
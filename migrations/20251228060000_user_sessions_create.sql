@@ -0,0 +1,43 @@
+-- Migration: Create table user_sessions
+-- Generated at: 2025-12-28T06:00:00+07:00
+-- Generated from model: internal/models/user_sessions.go
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+	user_sessions_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id UUID NOT NULL,
+	user_agent TEXT,
+	ip_address TEXT,
+	last_seen_at TIMESTAMPTZ NOT NULL,
+	last_seen_ip TEXT,
+	expires_at TIMESTAMPTZ NOT NULL,
+	revoked_at TIMESTAMPTZ,
+	revoked_reason TEXT,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	created_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE user_sessions
+ADD CONSTRAINT fk_user_sessions_user
+FOREIGN KEY (user_id) REFERENCES users(users_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id);
+
+-- Add table and column comments
+COMMENT ON TABLE user_sessions IS 'Login sessions; refresh_tokens.family_id is the session id';
+COMMENT ON COLUMN user_sessions.user_sessions_id IS 'Primary key UUID, carried in access tokens as sid';
+COMMENT ON COLUMN user_sessions.user_agent IS 'User agent of the latest login or refresh';
+COMMENT ON COLUMN user_sessions.ip_address IS 'Client IP the session was created from';
+COMMENT ON COLUMN user_sessions.last_seen_at IS 'Last authenticated request, recorded at most once a minute';
+COMMENT ON COLUMN user_sessions.last_seen_ip IS 'Client IP of the last authenticated request';
+COMMENT ON COLUMN user_sessions.expires_at IS 'When the newest refresh token of the session expires';
+COMMENT ON COLUMN user_sessions.revoked_at IS 'Set when the session was logged out or revoked';
+COMMENT ON COLUMN user_sessions.revoked_reason IS 'Why the session was revoked, e.g. logout or revoked_by_admin';
+COMMENT ON COLUMN user_sessions.created_timestamp IS 'Record creation timestamp';
+COMMENT ON COLUMN user_sessions.updated_timestamp IS 'Record update timestamp';
+
+-- Rollback
+-- DROP TABLE IF EXISTS user_sessions;
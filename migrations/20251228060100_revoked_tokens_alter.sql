@@ -0,0 +1,18 @@
+-- Migration: Alter table revoked_tokens
+-- Generated at: 2025-12-28T06:01:00+07:00
+-- Generated from model: internal/models/revoked_tokens.go
+
+	ALTER TABLE revoked_tokens ADD COLUMN IF NOT EXISTS session_id TEXT;
+
+ALTER TABLE revoked_tokens DROP CONSTRAINT IF EXISTS check_revoked_tokens_target;
+ALTER TABLE revoked_tokens
+ADD CONSTRAINT check_revoked_tokens_target CHECK (jti IS NOT NULL OR revoked_before IS NOT NULL OR session_id IS NOT NULL);
+
+COMMENT ON COLUMN revoked_tokens.session_id IS 'Every token of this login session is revoked';
+
+-- Rollback
+-- Customize rollback statements below (reverse the changes above):
+-- DELETE FROM revoked_tokens WHERE jti IS NULL AND revoked_before IS NULL;
+-- ALTER TABLE revoked_tokens DROP CONSTRAINT IF EXISTS check_revoked_tokens_target;
+-- ALTER TABLE revoked_tokens ADD CONSTRAINT check_revoked_tokens_target CHECK (jti IS NOT NULL OR revoked_before IS NOT NULL);
+-- ALTER TABLE revoked_tokens DROP COLUMN IF EXISTS session_id;
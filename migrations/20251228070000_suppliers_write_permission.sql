@@ -0,0 +1,17 @@
+-- Migration: Add suppliers:write permission
+-- Generated at: 2025-12-28T07:00:00+07:00
+-- Purpose: Guard supplier writes such as the bulk import like items:write guards items
+
+INSERT INTO permissions (code, description, created_at, updated_at) VALUES
+	('suppliers:write', 'Create, change, delete and import suppliers', NOW(), NOW())
+ON CONFLICT (code) DO NOTHING;
+
+-- Same roles as items:write. ADMIN holds every permission implicitly.
+INSERT INTO role_permissions (role_oid, permission, created_at, updated_at)
+SELECT r.role_oid, 'suppliers:write', NOW(), NOW()
+FROM roles r
+WHERE r.role_oid IN ('MANAGER', 'SUPPLIERS')
+ON CONFLICT (role_oid, permission) DO NOTHING;
+
+-- Rollback
+-- DELETE FROM permissions WHERE code = 'suppliers:write';
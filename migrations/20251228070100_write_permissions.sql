@@ -0,0 +1,26 @@
+-- Migration: Add write permissions for purchasing, fleet and stock routes
+-- Generated at: 2025-12-28T07:01:00+07:00
+-- Purpose: Every write route checks a permission, so API keys need a scope for it
+
+INSERT INTO permissions (code, description, created_at, updated_at) VALUES
+	('purchasings:write', 'Create, change and delete purchasings and their lines', NOW(), NOW()),
+	('vehicles:write', 'Create, change and delete vehicles and vehicle documents', NOW(), NOW()),
+	('trips:write', 'Create, change and delete trips', NOW(), NOW()),
+	('tires:write', 'Receive tires and record mounting, rotation, retreading, inspection and scrapping', NOW(), NOW()),
+	('stock:write', 'Post goods receipts, stock issues, stock transfers and stock counts', NOW(), NOW()),
+	('items:labels', 'Print item labels', NOW(), NOW()),
+	('api_keys:admin', 'List and revoke the API keys of every user', NOW(), NOW())
+ON CONFLICT (code) DO NOTHING;
+
+-- These routes used to be open to every signed-in user, so every existing
+-- role keeps them. ADMIN holds every permission implicitly.
+INSERT INTO role_permissions (role_oid, permission, created_at, updated_at)
+SELECT r.role_oid, p.code, NOW(), NOW()
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.role_oid <> 'ADMIN'
+	AND p.code IN ('purchasings:write', 'vehicles:write', 'trips:write', 'tires:write', 'stock:write', 'items:labels')
+ON CONFLICT (role_oid, permission) DO NOTHING;
+
+-- Rollback
+-- DELETE FROM permissions WHERE code IN ('purchasings:write', 'vehicles:write', 'trips:write', 'tires:write', 'stock:write', 'items:labels', 'api_keys:admin');
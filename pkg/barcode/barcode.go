@@ -0,0 +1,193 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	EAN13   = "ean13"
+	Code128 = "code128"
+	QR      = "qr"
+)
+
+// Label is one printed shelf label.
+type Label struct {
+	Title     string
+	Subtitle  string
+	Symbology string
+	Code      string
+}
+
+// Normalize validates code for the symbology and returns it in stored form.
+// A 12-digit EAN-13 gets its check digit appended.
+func Normalize(symbology, code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", fmt.Errorf("barcode is required")
+	}
+
+	switch strings.ToLower(symbology) {
+	case EAN13:
+		for _, r := range code {
+			if r < '0' || r > '9' {
+				return "", fmt.Errorf("EAN-13 barcodes may only contain digits")
+			}
+		}
+		if len(code) == 12 {
+			code += string(checkDigit(code))
+		}
+		if len(code) != 13 {
+			return "", fmt.Errorf("EAN-13 barcodes must have 12 or 13 digits")
+		}
+		if rune(code[12]) != checkDigit(code[:12]) {
+			return "", fmt.Errorf("EAN-13 check digit is invalid")
+		}
+	case Code128:
+		for _, r := range code {
+			if r < 32 || r > 126 {
+				return "", fmt.Errorf("Code128 barcodes may only contain printable ASCII characters")
+			}
+		}
+		if len(code) > 80 {
+			return "", fmt.Errorf("Code128 barcodes may not be longer than 80 characters")
+		}
+	case QR:
+		if len(code) > 1000 {
+			return "", fmt.Errorf("QR codes may not be longer than 1000 characters")
+		}
+	default:
+		return "", fmt.Errorf("unsupported symbology %q; use ean13, code128 or qr", symbology)
+	}
+
+	return code, nil
+}
+
+func checkDigit(code string) rune {
+	sum := 0
+	for i, r := range code {
+		digit := int(r - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return rune('0' + (10-sum%10)%10)
+}
+
+// Render draws the barcode at roughly width x height pixels. One-dimensional
+// codes are widened when the requested width cannot hold every module.
+func Render(symbology, code string, width, height int) (image.Image, error) {
+	var bc barcode.Barcode
+	var err error
+
+	switch strings.ToLower(symbology) {
+	case EAN13:
+		bc, err = ean.Encode(code)
+	case Code128:
+		bc, err = code128.Encode(code)
+	case QR:
+		bc, err = qr.Encode(code, qr.M, qr.Auto)
+		if err == nil && height < width {
+			width = height
+		}
+		height = width
+	default:
+		err = fmt.Errorf("unsupported symbology %q", symbology)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if modules := bc.Bounds().Dx(); width < modules {
+		width = modules
+	}
+
+	return barcode.Scale(bc, width, height)
+}
+
+// WritePNG renders the barcode as a PNG image.
+func WritePNG(w io.Writer, symbology, code string, width, height int) error {
+	img, err := Render(symbology, code, width, height)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// WriteLabelsPDF lays labels out on A4 pages, three across and eight down,
+// each with the title, subtitle, barcode and human-readable code.
+func WriteLabelsPDF(w io.Writer, labels []Label) error {
+	const (
+		columns     = 3
+		rows        = 8
+		marginX     = 7.0
+		marginY     = 10.0
+		labelWidth  = 65.0
+		labelHeight = 34.0
+	)
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(marginX, marginY, marginX)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for i, label := range labels {
+		slot := i % (columns * rows)
+		if slot == 0 {
+			pdf.AddPage()
+		}
+		x := marginX + float64(slot%columns)*labelWidth
+		y := marginY + float64(slot/columns)*labelHeight
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetXY(x+2, y+2)
+		pdf.CellFormat(labelWidth-4, 4, tr(truncate(label.Title, 38)), "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.SetXY(x+2, y+6)
+		pdf.CellFormat(labelWidth-4, 3.5, tr(truncate(label.Subtitle, 48)), "", 0, "L", false, 0, "")
+
+		var buf bytes.Buffer
+		if err := WritePNG(&buf, label.Symbology, label.Code, 600, 200); err != nil {
+			return fmt.Errorf("failed to render barcode %s: %w", label.Code, err)
+		}
+
+		name := fmt.Sprintf("label-%d", i)
+		options := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader(name, options, &buf)
+		if strings.ToLower(label.Symbology) == QR {
+			pdf.ImageOptions(name, x+2, y+10, 20, 20, false, options, 0, "")
+			pdf.SetXY(x+24, y+18)
+			pdf.CellFormat(labelWidth-26, 4, tr(truncate(label.Code, 30)), "", 0, "L", false, 0, "")
+		} else {
+			pdf.ImageOptions(name, x+2, y+10, labelWidth-4, 16, false, options, 0, "")
+			pdf.SetXY(x+2, y+27)
+			pdf.CellFormat(labelWidth-4, 4, tr(truncate(label.Code, 48)), "", 0, "C", false, 0, "")
+		}
+	}
+
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	return pdf.Output(w)
+}
+
+func truncate(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return string(runes[:max-3]) + "..."
+}
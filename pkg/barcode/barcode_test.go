@@ -0,0 +1,63 @@
+package barcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		symbology string
+		code      string
+		want      string
+		wantErr   bool
+	}{
+		{"ean13 with check digit", EAN13, "4006381333931", "4006381333931", false},
+		{"ean13 check digit appended", EAN13, "400638133393", "4006381333931", false},
+		{"ean13 check digit appended again", EAN13, "590123412345", "5901234123457", false},
+		{"ean13 trimmed", EAN13, " 4006381333931 ", "4006381333931", false},
+		{"ean13 symbology is case-insensitive", "EAN13", "4006381333931", "4006381333931", false},
+		{"ean13 wrong check digit", EAN13, "4006381333932", "", true},
+		{"ean13 letters", EAN13, "40063813339A", "", true},
+		{"ean13 too short", EAN13, "40063813339", "", true},
+		{"ean13 too long", EAN13, "40063813339310", "", true},
+		{"code128", Code128, "TIRE-11R22.5", "TIRE-11R22.5", false},
+		{"code128 non-printable", Code128, "TIRE\x01", "", true},
+		{"code128 too long", Code128, strings.Repeat("A", 81), "", true},
+		{"qr", QR, "https://fleetify.local/items/1", "https://fleetify.local/items/1", false},
+		{"qr too long", QR, strings.Repeat("A", 1001), "", true},
+		{"empty", Code128, "  ", "", true},
+		{"unknown symbology", "upc", "123", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.symbology, tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		code string
+		want rune
+	}{
+		{"400638133393", '1'},
+		{"590123412345", '7'},
+		{"978020137962", '4'},
+		{"000000000000", '0'},
+	}
+
+	for _, tt := range tests {
+		if got := checkDigit(tt.code); got != tt.want {
+			t.Errorf("checkDigit(%s) = %c, want %c", tt.code, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package jwt
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"fleetify/internal/config"
 )
 
@@ -11,39 +13,85 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// SessionID is the login session the token belongs to, so the session
+	// can be revoked with all its tokens.
+	SessionID string `json:"sid,omitempty"`
+	// MustChangePassword limits the token to changing the password.
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+	// TwoFactorSetupRequired limits the token to enrolling in two-factor
+	// authentication, which the user's role requires.
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+	// APIKeyID and Scopes are set when the request used an API key instead
+	// of a token. They never appear in a signed token.
+	APIKeyID string   `json:"-"`
+	Scopes   []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID, username, role string) (string, error) {
-	cfg := config.AppConfig.JWT
-	expiresIn, err := time.ParseDuration(cfg.ExpiresIn)
-	if err != nil {
-		expiresIn = 24 * time.Hour
+// AccessTokenTTL is how long an access token stays valid.
+func AccessTokenTTL() time.Duration {
+	expiresIn, err := time.ParseDuration(config.AppConfig.JWT.ExpiresIn)
+	if err != nil || expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
 	}
+	return expiresIn
+}
+
+func GenerateToken(userID, username, role, sessionID string, mustChangePassword, twoFactorSetupRequired bool) (string, error) {
+	cfg := config.AppConfig.JWT
+	expiresIn := AccessTokenTTL()
 
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
+		UserID:                 userID,
+		Username:               username,
+		Role:                   role,
+		SessionID:              sessionID,
+		MustChangePassword:     mustChangePassword,
+		TwoFactorSetupRequired: twoFactorSetupRequired,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.Secret))
+	if cfg.Algorithm == "" || cfg.Algorithm == HS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(cfg.Secret))
+	}
+
+	key, ok := signingKey(time.Now())
+	if !ok {
+		return "", fmt.Errorf("jwt: no signing key loaded")
+	}
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
 	cfg := config.AppConfig.JWT
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if cfg.Algorithm == "" || cfg.Algorithm == HS256 {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return []byte(cfg.Secret), nil
+		}
+
+		// Any key of the set verifies, but only with its own algorithm.
+		kid, _ := token.Header["kid"].(string)
+		key, ok := verificationKey(kid)
+		if !ok || token.Method.Alg() != key.Algorithm {
 			return nil, jwt.ErrSignatureInvalid
 		}
-		return []byte(cfg.Secret), nil
+		return key.PrivateKey.Public(), nil
 	})
 
 	if err != nil {
@@ -0,0 +1,170 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms. HS256 uses the shared JWT secret; the others use the
+// key set loaded with SetKeys.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// SigningKey is one key of the key set, identified in token headers by its
+// ID (kid).
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	// ActivatesAt is when the key starts signing. Keys are published before
+	// that, so verifiers learn them before the first token arrives.
+	ActivatesAt time.Time
+}
+
+var (
+	keysMu sync.RWMutex
+	keys   []SigningKey
+)
+
+// SetKeys replaces the key set. Tokens are signed with the newest active key
+// and verified with any key of the set.
+func SetKeys(set []SigningKey) {
+	sorted := append([]SigningKey(nil), set...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ActivatesAt.Before(sorted[j].ActivatesAt)
+	})
+
+	keysMu.Lock()
+	keys = sorted
+	keysMu.Unlock()
+}
+
+// signingKey returns the key tokens are signed with at now.
+func signingKey(now time.Time) (SigningKey, bool) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].ActivatesAt.After(now) {
+			return keys[i], true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func verificationKey(kid string) (SigningKey, bool) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	for _, key := range keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case HS256:
+		return jwt.SigningMethodHS256, nil
+	case RS256:
+		return jwt.SigningMethodRS256, nil
+	case EdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("jwt: unsupported algorithm %q", algorithm)
+}
+
+// GenerateKey creates a private key for algorithm.
+func GenerateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case RS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case EdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return nil, fmt.Errorf("jwt: cannot generate a key for %q", algorithm)
+}
+
+// EncodePrivateKey returns key as a PKCS #8 PEM block.
+func EncodePrivateKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// DecodePrivateKey parses a key written by EncodePrivateKey.
+func DecodePrivateKey(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("jwt: no PEM block in private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("jwt: unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeys returns the public half of every key in the set.
+func PublicKeys() JWKSet {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range keys {
+		jwk := JWK{Use: "sig", Alg: key.Algorithm, Kid: key.ID}
+		switch public := key.PrivateKey.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
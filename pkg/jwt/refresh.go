@@ -0,0 +1,38 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"fleetify/internal/config"
+)
+
+// RefreshTokenTTL is how long a refresh token stays valid. Every rotation
+// issues a token with a fresh lifetime.
+func RefreshTokenTTL() time.Duration {
+	expiresIn, err := time.ParseDuration(config.AppConfig.JWT.RefreshExpiresIn)
+	if err != nil || expiresIn <= 0 {
+		expiresIn = 7 * 24 * time.Hour
+	}
+	return expiresIn
+}
+
+// NewOpaqueToken returns a random token, such as a refresh token or an
+// invite, and the hash that is stored server-side.
+func NewOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the lookup hash of an opaque token.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,35 @@
+package jwt
+
+import (
+	"testing"
+)
+
+func TestNewOpaqueToken(t *testing.T) {
+	token, hash, err := NewOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != HashOpaqueToken(token) {
+		t.Error("hash does not match HashOpaqueToken of the token")
+	}
+	if hash == token {
+		t.Error("token is stored in plain text")
+	}
+
+	// A rotated token must never equal the one it replaces.
+	next, nextHash, err := NewOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == token || nextHash == hash {
+		t.Error("two tokens are equal")
+	}
+}
+
+func TestHashOpaqueToken(t *testing.T) {
+	// SHA-256 of "abc".
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := HashOpaqueToken("abc"); got != want {
+		t.Errorf("HashOpaqueToken(abc) = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+// Package notify delivers messages such as password reset links to users.
+// SMTP sends real mail; Memory keeps messages in the process for tests and
+// local development.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Default is the notifier handlers send through. It is replaced at startup
+// according to the SMTP configuration.
+var Default Notifier = NewMemory(false)
+
+// SMTP sends plain-text mail through an SMTP server, authenticating when
+// Username is set.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("notify: header contains a line break")
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	body := strings.Join([]string{
+		"From: " + s.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, []string{msg.To}, []byte(body))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// memoryLimit caps how many messages Memory keeps.
+const memoryLimit = 100
+
+// Memory keeps the latest sent messages instead of delivering them. With
+// Verbose set it also writes them to the log so a developer can follow links
+// without a mail server.
+type Memory struct {
+	Verbose bool
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory(verbose bool) *Memory {
+	return &Memory{Verbose: verbose}
+}
+
+func (m *Memory) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	m.messages = append(m.messages, msg)
+	if len(m.messages) > memoryLimit {
+		m.messages = m.messages[len(m.messages)-memoryLimit:]
+	}
+	m.mu.Unlock()
+
+	if m.Verbose {
+		log.Printf("NOTIFY to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	} else {
+		log.Printf("NOTIFY to %s: %s (not delivered, SMTP is not configured)", msg.To, msg.Subject)
+	}
+	return nil
+}
+
+// Messages returns the messages sent so far.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
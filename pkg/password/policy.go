@@ -0,0 +1,113 @@
+package password
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"fleetify/internal/config"
+)
+
+// Policy describes what a new password has to look like.
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// History is how many recent passwords, counting the current one, may
+	// not be used again. Zero allows reuse.
+	History int
+	// RejectCommon refuses passwords from the bundled common password list.
+	RejectCommon bool
+}
+
+// CurrentPolicy returns the policy configured through PASSWORD_* variables.
+func CurrentPolicy() Policy {
+	cfg := config.AppConfig.Password
+	return Policy{
+		MinLength:     cfg.MinLength,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+		History:       cfg.History,
+		RejectCommon:  cfg.RejectCommon,
+	}
+}
+
+// Validate checks password against the policy for the account username.
+// The error reads as a sentence about the password.
+func (p Policy) Validate(password, username string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !upper:
+		return errors.New("password must contain an uppercase letter")
+	case p.RequireLower && !lower:
+		return errors.New("password must contain a lowercase letter")
+	case p.RequireDigit && !digit:
+		return errors.New("password must contain a digit")
+	case p.RequireSymbol && !symbol:
+		return errors.New("password must contain a symbol")
+	}
+
+	if username != "" && strings.EqualFold(password, username) {
+		return errors.New("password must not be the same as the username")
+	}
+
+	if p.RejectCommon && IsCommon(password) {
+		return errors.New("password is too common; choose a less predictable one")
+	}
+
+	return nil
+}
+
+// MatchesAny reports whether password is the password of any of the hashes.
+func MatchesAny(password string, hashes []string) bool {
+	for _, hash := range hashes {
+		if Verify(password, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = func() map[string]bool {
+	set := map[string]bool{}
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = true
+	}
+	return set
+}()
+
+// IsCommon reports whether password is on the bundled list of frequently
+// used and breached passwords.
+func IsCommon(password string) bool {
+	return commonPasswords[strings.ToLower(password)]
+}
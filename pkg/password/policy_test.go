@@ -0,0 +1,82 @@
+package password
+
+import (
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	strict := Policy{
+		MinLength:     8,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		RejectCommon:  true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		username string
+		wantErr  bool
+	}{
+		{"meets every rule", strict, "Tr4ck!ng-Fleet", "alice", false},
+		{"too short", strict, "Ab1!", "alice", true},
+		{"length counts characters", Policy{MinLength: 4}, "äöüß", "", false},
+		{"missing uppercase", strict, "tr4ck!ng-fleet", "alice", true},
+		{"missing lowercase", strict, "TR4CK!NG-FLEET", "alice", true},
+		{"missing digit", strict, "Tracking-Fleet", "alice", true},
+		{"missing symbol", strict, "Tr4ckingFleet", "alice", true},
+		{"symbol not required", Policy{MinLength: 8, RequireDigit: true}, "tr4ckingfleet", "alice", false},
+		{"equals username", Policy{MinLength: 4}, "Alice123", "alice123", true},
+		{"no username given", Policy{MinLength: 4}, "alice123", "", false},
+		{"common password", Policy{MinLength: 6, RejectCommon: true}, "qwerty123", "alice", true},
+		{"common password allowed", Policy{MinLength: 6}, "qwerty123", "alice", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.username)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, want error %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsCommon(t *testing.T) {
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"123456", true},
+		{"QWERTY", true},
+		{"qwerty123", true},
+		{"Tr4ck!ng-Fleet", false},
+		{"", false},
+		{"# Frequently used and breached passwords, compared case-insensitively.", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCommon(tt.password); got != tt.want {
+			t.Errorf("IsCommon(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	hash, err := Hash("old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !MatchesAny("old-password", []string{"not-a-hash", hash}) {
+		t.Error("MatchesAny missed a matching hash")
+	}
+	if MatchesAny("new-password", []string{hash}) {
+		t.Error("MatchesAny matched a different password")
+	}
+	if MatchesAny("old-password", nil) {
+		t.Error("MatchesAny matched without hashes")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,6 +17,7 @@ type QueryParams struct {
 	SortDir  string
 	Search   string
 	Filters  map[string]string
+	Scope    Scope
 }
 
 func ParseQueryParams(c *fiber.Ctx) QueryParams {
@@ -25,6 +27,7 @@ func ParseQueryParams(c *fiber.Ctx) QueryParams {
 		Sort:    "created_at",
 		SortDir: "DESC",
 		Filters: make(map[string]string),
+		Scope:   ScopeFrom(c),
 	}
 
 	if pageStr := c.Query("page"); pageStr != "" {
@@ -146,3 +149,88 @@ func NewPaginatedResponse(data interface{}, totalCount int, page int, limit int)
 	}
 }
 
+// Scope limits list and detail queries to rows that belong to the caller's
+// departments. It is set per request by middleware.DepartmentScope; routes
+// without that middleware get a global scope.
+type Scope struct {
+	Global      bool
+	Departments []string
+}
+
+// ScopeFrom returns the department scope of the request.
+func ScopeFrom(c *fiber.Ctx) Scope {
+	if scope, ok := c.Locals("scope").(Scope); ok {
+		return scope
+	}
+	return Scope{Global: true}
+}
+
+// ApplyScope adds the scope condition to whereClause. expr is a SQL
+// condition with one %s where the department id array goes, for example
+// "p.department_id = ANY(%s)".
+func ApplyScope(whereClause string, args []interface{}, scope Scope, expr string) (string, []interface{}) {
+	if scope.Global {
+		return whereClause, args
+	}
+
+	departments := scope.Departments
+	if departments == nil {
+		departments = []string{}
+	}
+	args = append(args, departments)
+	condition := fmt.Sprintf(expr, fmt.Sprintf("$%d::uuid[]", len(args)))
+
+	if whereClause == "" {
+		return "WHERE " + condition, args
+	}
+	return whereClause + " AND " + condition, args
+}
+
+var (
+	scopesMu sync.RWMutex
+	scopes   = map[string]string{}
+)
+
+// RegisterScope declares how the rows of table belong to departments, so
+// every query built with BuildScopedWhereClause or ScopeTable is limited to
+// the caller's departments. expr is a SQL condition in which %[1]s stands for
+// the table, or the alias a query gives it, and %[2]s for the department id
+// array, for example "%[1]s.department_id = ANY(%[2]s)".
+func RegisterScope(table, expr string) {
+	scopesMu.Lock()
+	defer scopesMu.Unlock()
+	scopes[table] = expr
+}
+
+// scopeExpr returns the registered condition of table for ApplyScope, with
+// the table referred to as alias.
+func scopeExpr(table, alias string) (string, bool) {
+	scopesMu.RLock()
+	expr, ok := scopes[table]
+	scopesMu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	if alias == "" {
+		alias = table
+	}
+	return fmt.Sprintf(expr, alias, "%s"), true
+}
+
+// ScopeTable adds the condition registered for table to whereClause. alias
+// is how the query refers to the table, or "" for its name. Tables without
+// a registered scope are not limited.
+func ScopeTable(whereClause string, args []interface{}, scope Scope, table, alias string) (string, []interface{}) {
+	expr, ok := scopeExpr(table, alias)
+	if !ok {
+		return whereClause, args
+	}
+	return ApplyScope(whereClause, args, scope, expr)
+}
+
+// BuildScopedWhereClause is BuildWhereClause followed by ScopeTable with the
+// scope parsed from the request.
+func BuildScopedWhereClause(params QueryParams, searchFields []string, filterFields map[string]string, table, alias string) (string, []interface{}) {
+	whereClause, args := BuildWhereClause(params, searchFields, filterFields)
+	return ScopeTable(whereClause, args, params.Scope, table, alias)
+}
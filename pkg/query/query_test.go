@@ -0,0 +1,151 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		where     string
+		args      []interface{}
+		scope     Scope
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "global scope is not limited",
+			where:     "WHERE id = $1",
+			args:      []interface{}{"a"},
+			scope:     Scope{Global: true, Departments: []string{"d1"}},
+			wantWhere: "WHERE id = $1",
+			wantArgs:  []interface{}{"a"},
+		},
+		{
+			name:      "adds condition to existing where",
+			where:     "WHERE id = $1",
+			args:      []interface{}{"a"},
+			scope:     Scope{Departments: []string{"d1", "d2"}},
+			wantWhere: "WHERE id = $1 AND department_id = ANY($2::uuid[])",
+			wantArgs:  []interface{}{"a", []string{"d1", "d2"}},
+		},
+		{
+			name:      "starts where clause",
+			scope:     Scope{Departments: []string{"d1"}},
+			wantWhere: "WHERE department_id = ANY($1::uuid[])",
+			wantArgs:  []interface{}{[]string{"d1"}},
+		},
+		{
+			name:      "no departments matches nothing",
+			scope:     Scope{},
+			wantWhere: "WHERE department_id = ANY($1::uuid[])",
+			wantArgs:  []interface{}{[]string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := ApplyScope(tt.where, tt.args, tt.scope, "department_id = ANY(%s)")
+			if where != tt.wantWhere {
+				t.Errorf("where = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestScopeTable(t *testing.T) {
+	RegisterScope("test_orders", "%[1]s.department_id = ANY(%[2]s)")
+	RegisterScope("test_lines", "%[1]s.order_id IN (SELECT o.id FROM test_orders o WHERE o.department_id = ANY(%[2]s))")
+
+	scope := Scope{Departments: []string{"d1"}}
+	tests := []struct {
+		name      string
+		table     string
+		alias     string
+		scope     Scope
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "alias",
+			table:     "test_orders",
+			alias:     "o",
+			scope:     scope,
+			wantWhere: "WHERE o.id = $1 AND o.department_id = ANY($2::uuid[])",
+			wantArgs:  []interface{}{"x", []string{"d1"}},
+		},
+		{
+			name:      "table name without alias",
+			table:     "test_orders",
+			scope:     scope,
+			wantWhere: "WHERE o.id = $1 AND test_orders.department_id = ANY($2::uuid[])",
+			wantArgs:  []interface{}{"x", []string{"d1"}},
+		},
+		{
+			name:      "scoped through another table",
+			table:     "test_lines",
+			alias:     "l",
+			scope:     scope,
+			wantWhere: "WHERE o.id = $1 AND l.order_id IN (SELECT o.id FROM test_orders o WHERE o.department_id = ANY($2::uuid[]))",
+			wantArgs:  []interface{}{"x", []string{"d1"}},
+		},
+		{
+			name:      "unregistered table",
+			table:     "test_unscoped",
+			alias:     "u",
+			scope:     scope,
+			wantWhere: "WHERE o.id = $1",
+			wantArgs:  []interface{}{"x"},
+		},
+		{
+			name:      "global scope",
+			table:     "test_orders",
+			alias:     "o",
+			scope:     Scope{Global: true},
+			wantWhere: "WHERE o.id = $1",
+			wantArgs:  []interface{}{"x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := ScopeTable("WHERE o.id = $1", []interface{}{"x"}, tt.scope, tt.table, tt.alias)
+			if where != tt.wantWhere {
+				t.Errorf("where = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildScopedWhereClause(t *testing.T) {
+	RegisterScope("test_receipts", "%[1]s.department_id = ANY(%[2]s)")
+
+	params := QueryParams{
+		Search:  "tire",
+		Filters: map[string]string{"status": "open", "ignored": "x"},
+		Scope:   Scope{Departments: []string{"d1"}},
+	}
+	where, args := BuildScopedWhereClause(params, []string{"r.notes"}, map[string]string{"status": "r.status"}, "test_receipts", "r")
+
+	wantWhere := "WHERE (r.notes ILIKE $1) AND r.status = $2 AND r.department_id = ANY($3::uuid[])"
+	wantArgs := []interface{}{"%tire%", "open", []string{"d1"}}
+	if where != wantWhere {
+		t.Errorf("where = %q, want %q", where, wantWhere)
+	}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+
+	// The pagination placeholders follow the scope argument.
+	pagination, _ := BuildPaginationClause(QueryParams{Page: 1, Limit: 10}, len(args)+1)
+	if pagination != "LIMIT $4 OFFSET $5" {
+		t.Errorf("pagination = %q, want LIMIT $4 OFFSET $5", pagination)
+	}
+}
@@ -0,0 +1,149 @@
+// Package ratelimit counts hits per key in fixed windows. The counters live
+// in a Store; MemoryStore serves a single instance, and a shared store can be
+// plugged in through DefaultStore so several instances agree on the counts.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store keeps counters that expire at the end of their window.
+type Store interface {
+	// Incr adds a hit to key and returns the count in the current window and
+	// when that window ends. A window starts with the first hit after the
+	// previous one ended.
+	Incr(ctx context.Context, key string, window time.Duration) (int, time.Time, error)
+	// Get returns the count of key without adding a hit. Unknown and expired
+	// keys have a count of zero.
+	Get(ctx context.Context, key string) (int, time.Time, error)
+	// Reset forgets key.
+	Reset(ctx context.Context, key string) error
+}
+
+// DefaultStore is used by the rate limit middleware and the login guard.
+// Replace it before the server starts to share counters between instances.
+var DefaultStore Store = NewMemoryStore()
+
+type counter struct {
+	count   int
+	resetAt time.Time
+}
+
+// MemoryStore is a Store local to the process.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]counter
+	sweptAt  time.Time
+}
+
+// sweepInterval is how often MemoryStore drops expired counters.
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: map[string]counter{},
+		sweptAt:  time.Now(),
+	}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		c = counter{resetAt: now.Add(window)}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, c.resetAt, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || !time.Now().Before(c.resetAt) {
+		return 0, time.Time{}, nil
+	}
+	return c.count, c.resetAt, nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.counters, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// sweep drops expired counters so keys that are never hit again do not pile
+// up.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < sweepInterval {
+		return
+	}
+	for key, c := range s.counters {
+		if !now.Before(c.resetAt) {
+			delete(s.counters, key)
+		}
+	}
+	s.sweptAt = now
+}
+
+// Rule allows Max hits per Window.
+type Rule struct {
+	Max    int
+	Window time.Duration
+}
+
+// ParseRules reads rules written as "name=max/window" separated by commas,
+// e.g. "login=10/1m,register=5/1h".
+func ParseRules(spec string) (map[string]Rule, error) {
+	rules := map[string]Rule{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, limit, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q: missing '='", part)
+		}
+		maxStr, windowStr, ok := strings.Cut(limit, "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q: missing '/'", part)
+		}
+
+		max, err := strconv.Atoi(strings.TrimSpace(maxStr))
+		if err != nil || max <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q: max must be a positive number", part)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q: window must be a positive duration", part)
+		}
+
+		rules[strings.TrimSpace(name)] = Rule{Max: max, Window: window}
+	}
+	return rules, nil
+}
+
+// RetryAfter formats d as whole seconds for the Retry-After header, rounding
+// up so clients do not retry early.
+func RetryAfter(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
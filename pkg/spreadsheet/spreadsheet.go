@@ -0,0 +1,244 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// FormatOf returns the spreadsheet format for a file name, or "" when it is
+// neither CSV nor XLSX.
+func FormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV
+	case ".xlsx":
+		return XLSX
+	}
+	return ""
+}
+
+// ReadRows returns every row of a CSV file or of the first sheet of an XLSX
+// workbook. Cells are trimmed and trailing empty rows dropped.
+func ReadRows(format string, r io.Reader) ([][]string, error) {
+	var rows [][]string
+	var err error
+
+	switch format {
+	case CSV:
+		rows, err = readCSV(r)
+	case XLSX:
+		rows, err = readXLSX(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		for j := range rows[i] {
+			rows[i][j] = strings.TrimSpace(rows[i][j])
+		}
+	}
+	for len(rows) > 0 && IsBlank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := bufio.NewReader(r)
+	if bom, err := reader.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		reader.Discard(3)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return rows, nil
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	workbook, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+	defer workbook.Close()
+
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+
+	rows, err := workbook.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet %s: %w", sheets[0], err)
+	}
+	return rows, nil
+}
+
+// IsBlank reports whether every cell of the row is empty.
+func IsBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Writer streams rows into a CSV file or an XLSX workbook.
+type Writer interface {
+	WriteRow(values []interface{}) error
+	// Flush pushes buffered rows to the underlying writer where the format
+	// allows it.
+	Flush() error
+	// Close finishes the file. XLSX output is only written on Close.
+	Close() error
+}
+
+// ContentType returns the MIME type for a format.
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewWriter starts a file in the given format and writes the header row.
+func NewWriter(format string, w io.Writer, header []string) (Writer, error) {
+	var writer Writer
+	switch format {
+	case CSV:
+		writer = &csvWriter{writer: csv.NewWriter(w)}
+	case XLSX:
+		workbook := excelize.NewFile()
+		stream, err := workbook.NewStreamWriter("Sheet1")
+		if err != nil {
+			workbook.Close()
+			return nil, err
+		}
+		writer = &xlsxWriter{workbook: workbook, stream: stream, out: w}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	values := make([]interface{}, len(header))
+	for i, name := range header {
+		values[i] = name
+	}
+	if err := writer.WriteRow(values); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatCell(value)
+		if _, ok := value.(string); ok {
+			record[i] = EscapeFormula(record[i])
+		}
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+// EscapeFormula prefixes text that a spreadsheet application would run as a
+// formula with a single quote. Only text cells need it; XLSX stores them as
+// strings anyway.
+func EscapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type xlsxWriter struct {
+	workbook *excelize.File
+	stream   *excelize.StreamWriter
+	out      io.Writer
+	row      int
+}
+
+func (w *xlsxWriter) WriteRow(values []interface{}) error {
+	w.row++
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+			cells[i] = ""
+		case string, bool, int, int32, int64, float32, float64:
+			cells[i] = v
+		default:
+			cells[i] = formatCell(v)
+		}
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, cells)
+}
+
+func (w *xlsxWriter) Flush() error {
+	return nil
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.workbook.Close()
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.workbook.Write(w.out)
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
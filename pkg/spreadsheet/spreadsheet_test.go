@@ -0,0 +1,48 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Tire 11R22.5", "Tire 11R22.5"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"a=1", "a=1"},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeFormula(tt.value); got != tt.want {
+			t.Errorf("EscapeFormula(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCSVWriterEscapesTextOnly(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(CSV, &buf, []string{"name", "qty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteRow([]interface{}{"=1+1", -5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "name,qty\n'=1+1,-5\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}